@@ -0,0 +1,205 @@
+package controller
+
+import (
+	"errors"
+	"log"
+	"math/big"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gin-gonic/gin"
+	"github.com/prikshit/blockchain-privacy-module/helpers"
+	"github.com/prikshit/blockchain-privacy-module/internal/airgap"
+	"github.com/prikshit/blockchain-privacy-module/models"
+)
+
+// Builds an unsigned withdrawal envelope for a stealth payment, chunked for QR codes (online side)
+func CreateAirgapEnvelope(c *gin.Context, s *models.Server) {
+	log.Println("Received request to create an air-gapped withdrawal envelope")
+	if !chainEnabled(c, s) {
+		return
+	}
+
+	var req models.AirgapEnvelopeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	ann, err := parseAnnouncement(req.Announcement)
+	if err != nil {
+		log.Println("Invalid announcement:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !common.IsHexAddress(req.To) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid destination address"})
+		return
+	}
+	value, ok := new(big.Int).SetString(req.Value, 10)
+	if !ok || value.Sign() < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid value"})
+		return
+	}
+	var data []byte
+	if req.Data != "" {
+		if data, err = hexutil.Decode(req.Data); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data"})
+			return
+		}
+	}
+	to := common.HexToAddress(req.To)
+	if !screenWithdrawal(c, s, to, data) {
+		return
+	}
+
+	env, err := airgap.NewEnvelope(c.Request.Context(), s.Chain, ann, req.Label, to, value, data)
+	if err != nil {
+		log.Println("Error preparing envelope:", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	chunks, err := env.Chunks(req.ChunkSize)
+	if err != nil {
+		log.Println("Error encoding envelope:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode envelope"})
+		return
+	}
+
+	log.Printf("Returning withdrawal envelope in %d chunks", len(chunks))
+	c.JSON(http.StatusOK, gin.H{
+		"stealth_address": env.StealthAddress.Hex(),
+		"chain_id":        env.ChainID.String(),
+		"nonce":           env.Nonce,
+		"gas":             env.Gas,
+		"max_fee_per_gas": env.GasFeeCap.String(),
+		"chunks":          chunks,
+	})
+}
+
+// Derives the stealth key for a scanned envelope and signs it (offline side; needs no RPC endpoint)
+func SignAirgapEnvelope(c *gin.Context, s *models.Server) {
+	log.Println("Received request to sign an air-gapped withdrawal envelope")
+
+	var req models.AirgapSignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	env, err := airgap.DecodeEnvelope(req.Chunks)
+	if err != nil {
+		log.Println("Invalid envelope:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	signer := &airgap.Signer{PrivacyManager: s.PrivacyManager}
+	if signer.SpendingKey, err = helpers.ParseECDSAPrivKey(req.SpendingPrivKey); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid spending private key"})
+		return
+	}
+	if signer.ViewingKey, err = helpers.ParseECDSAPrivKey(req.ViewingPrivKey); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid viewing private key"})
+		return
+	}
+	if req.KEMSeed != "" {
+		if signer.KEMKey, err = parseKEMSeed(req.KEMSeed); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ML-KEM seed"})
+			return
+		}
+	}
+
+	signed, err := signer.Sign(env)
+	if err != nil {
+		log.Println("Error signing envelope:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	chunks, err := signed.Chunks(req.ChunkSize)
+	if err != nil {
+		log.Println("Error encoding signed transaction:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode signed transaction"})
+		return
+	}
+
+	log.Printf("Returning signed withdrawal in %d chunks", len(chunks))
+	c.JSON(http.StatusOK, gin.H{
+		"stealth_address": signed.StealthAddress.Hex(),
+		"chunks":          chunks,
+	})
+}
+
+// Broadcasts a signed withdrawal scanned from the offline side (online side)
+func BroadcastAirgapTransaction(c *gin.Context, s *models.Server) {
+	log.Println("Received request to broadcast an air-gapped withdrawal")
+	if !chainEnabled(c, s) {
+		return
+	}
+
+	var req models.AirgapBroadcastRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	signed, err := airgap.DecodeSignedTransaction(req.Chunks)
+	if err != nil {
+		log.Println("Invalid signed transaction:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The offline side may have signed a destination listed since the envelope was created
+	tx, err := signed.Transaction()
+	if err != nil {
+		log.Println("Invalid signed transaction:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if tx.To() == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A withdrawal cannot create a contract"})
+		return
+	}
+	if !screenWithdrawal(c, s, *tx.To(), tx.Data()) {
+		return
+	}
+
+	tx, err = airgap.Broadcast(c.Request.Context(), s.Chain, signed)
+	if errors.Is(err, airgap.ErrSenderMismatch) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		log.Println("Error broadcasting withdrawal:", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tx_hash": tx.Hash().Hex()})
+}
+
+// screenWithdrawal refuses a withdrawal to or data that pays a sanctioned address, or whose calldata is not
+// a transfer whose recipient can be screened.
+func screenWithdrawal(c *gin.Context, s *models.Server, to common.Address, data []byte) bool {
+	recipients, err := airgap.Recipients(to, data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	for _, recipient := range recipients {
+		if s.PrivacyManager.Detector.IsSanctioned(recipient) {
+			log.Printf("Sanctioned withdrawal destination: %s\n", recipient.Hex())
+			c.JSON(http.StatusForbidden, gin.H{"error": "Destination address is sanctioned"})
+			return false
+		}
+	}
+	return true
+}
+
+func chainEnabled(c *gin.Context, s *models.Server) bool {
+	if s.Chain == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "No RPC endpoint is configured"})
+		return false
+	}
+	return true
+}
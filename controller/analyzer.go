@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"log"
+	"math/big"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prikshit/blockchain-privacy-module/internal/analyzer"
+	"github.com/prikshit/blockchain-privacy-module/models"
+)
+
+// Inspects a stealth payment history against a transaction dataset and reports privacy leaks per meta-address
+func ReportPrivacyLeaks(c *gin.Context) {
+	log.Println("Received request to analyze stealth payment history")
+
+	var req models.LeakReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	opts := analyzer.Options{SweepWindow: req.SweepWindow}
+	if req.GasTopUpMax != "" {
+		limit, ok := new(big.Int).SetString(req.GasTopUpMax, 10)
+		if !ok || limit.Sign() <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid gas top-up limit"})
+			return
+		}
+		opts.GasTopUpMax = limit
+	}
+
+	reports := analyzer.Analyze(&analyzer.Dataset{
+		Payments:      req.Payments,
+		Transactions:  req.Transactions,
+		MainAddresses: req.MainAddresses,
+	}, opts)
+	c.JSON(http.StatusOK, gin.H{"reports": reports})
+}
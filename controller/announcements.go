@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prikshit/blockchain-privacy-module/internal/announcer"
+	"github.com/prikshit/blockchain-privacy-module/models"
+)
+
+// Queues generated announcements for delayed, batched publication by the relayer
+func QueueAnnouncements(c *gin.Context, s *models.Server) {
+	log.Println("Received request to queue announcements")
+	if !announcementQueueEnabled(c, s) {
+		return
+	}
+
+	var req models.QueueAnnouncementsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	announcements, err := parseAnnouncements(req.Announcements)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	items := make([]gin.H, 0, len(announcements))
+	for _, ann := range announcements {
+		item, err := s.Announcements.Enqueue(ann)
+		if err != nil {
+			log.Println("Error queueing announcement:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue announcement"})
+			return
+		}
+		items = append(items, queuedAnnouncementResponse(item))
+	}
+	c.JSON(http.StatusOK, gin.H{"queued": items})
+}
+
+// Reports how many announcements are waiting and when the next one is due
+func GetAnnouncementQueueStatus(c *gin.Context, s *models.Server) {
+	if !announcementQueueEnabled(c, s) {
+		return
+	}
+
+	status := s.Announcements.Status()
+	response := gin.H{
+		"queued":  status.Queued,
+		"sent":    status.Sent,
+		"batches": status.Batches,
+		"relayer": status.Relayer.Hex(),
+	}
+	if !status.NextRelease.IsZero() {
+		response["next_release_at"] = status.NextRelease
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// Returns one queued or published announcement
+func GetQueuedAnnouncement(c *gin.Context, s *models.Server) {
+	if !announcementQueueEnabled(c, s) {
+		return
+	}
+
+	item, err := s.Announcements.Item(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, queuedAnnouncementResponse(item))
+}
+
+func announcementQueueEnabled(c *gin.Context, s *models.Server) bool {
+	if s.Announcements == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Announcement relayer is not configured"})
+		return false
+	}
+	return true
+}
+
+func queuedAnnouncementResponse(item *announcer.Item) gin.H {
+	response := gin.H{
+		"id":              item.ID,
+		"status":          item.Status,
+		"stealth_address": item.Announcement.StealthAddress.Hex(),
+		"enqueued_at":     item.EnqueuedAt,
+		"release_at":      item.ReleaseAt,
+	}
+	if item.Status == announcer.StatusSent {
+		response["tx_hash"] = item.TxHash.Hex()
+		response["sent_at"] = item.SentAt
+	}
+	return response
+}
@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+	"github.com/prikshit/blockchain-privacy-module/helpers"
+	"github.com/prikshit/blockchain-privacy-module/internal/backup"
+	"github.com/prikshit/blockchain-privacy-module/models"
+)
+
+// Splits a spending/viewing key or a mnemonic into word-encoded Shamir backup shares
+func SplitBackup(c *gin.Context) {
+	log.Println("Received request to split a backup")
+
+	var req models.BackupSplitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	var shares []string
+	var err error
+	switch req.Type {
+	case "private_key":
+		privKey, parseErr := helpers.ParseECDSAPrivKey(req.Secret)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid private key"})
+			return
+		}
+		shares, err = backup.SplitPrivateKey(privKey, req.Threshold, req.Shares)
+	case "mnemonic":
+		shares, err = backup.SplitMnemonic(req.Secret, req.Threshold, req.Shares)
+	}
+	if err != nil {
+		log.Println("Error splitting backup:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.Printf("Returning %d backup shares", len(shares))
+	c.JSON(http.StatusOK, gin.H{
+		"threshold": req.Threshold,
+		"shares":    shares,
+	})
+}
+
+// Restores a key or mnemonic from word-encoded Shamir backup shares
+func RestoreBackup(c *gin.Context) {
+	log.Println("Received request to restore a backup")
+
+	var req models.BackupRestoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	restored, err := backup.Restore(req.Shares)
+	if err != nil {
+		log.Println("Error restoring backup:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.Println("Backup restored successfully")
+	switch restored.Type {
+	case backup.SecretPrivateKey:
+		c.JSON(http.StatusOK, gin.H{
+			"type":        "private_key",
+			"private_key": hexutil.Encode(crypto.FromECDSA(restored.PrivateKey)),
+			"address":     crypto.PubkeyToAddress(restored.PrivateKey.PublicKey).Hex(),
+		})
+	default:
+		c.JSON(http.StatusOK, gin.H{
+			"type":     "mnemonic",
+			"mnemonic": restored.Mnemonic,
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/prikshit/blockchain-privacy-module/internal/consolidation"
+	"github.com/prikshit/blockchain-privacy-module/internal/privacy"
+	"github.com/prikshit/blockchain-privacy-module/models"
+)
+
+// Default spacing between sweeps when the request does not set one.
+const (
+	defaultMinSweepDelay = time.Hour
+	defaultMaxSweepDelay = 6 * time.Hour
+)
+
+// Proposes a sweep schedule for discovered stealth balances that minimises on-chain linkage
+func PlanConsolidation(c *gin.Context) {
+	log.Println("Received request to plan a stealth balance consolidation")
+
+	var req models.ConsolidationPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	balances := make([]consolidation.StealthBalance, 0, len(req.Balances))
+	for _, b := range req.Balances {
+		balance, err := parseStealthBalance(b)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		balances = append(balances, balance)
+	}
+	destinations, err := parseAddressList(req.Destinations)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	mainAddresses, err := parseAddressList(req.MainAddresses)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	opts := consolidation.Options{
+		Destinations:  destinations,
+		MainAddresses: mainAddresses,
+		MaxPerGroup:   req.MaxPerGroup,
+		MinDelay:      time.Duration(req.MinDelaySeconds) * time.Second,
+		MaxDelay:      time.Duration(req.MaxDelaySeconds) * time.Second,
+		Start:         time.Now().UTC(),
+		StartBlock:    req.StartBlock,
+	}
+	if opts.MinDelay == 0 {
+		opts.MinDelay = defaultMinSweepDelay
+	}
+	if opts.MaxDelay == 0 {
+		opts.MaxDelay = max(opts.MinDelay, defaultMaxSweepDelay)
+	}
+
+	plan, err := consolidation.PlanSweeps(balances, opts)
+	if err != nil {
+		log.Println("Error planning consolidation:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sweeps := make([]gin.H, len(plan.Sweeps))
+	for i, s := range plan.Sweeps {
+		sweeps[i] = gin.H{
+			"from":   s.From.Hex(),
+			"to":     s.To.Hex(),
+			"token":  s.Token.Hex(),
+			"amount": s.Amount.String(),
+			"at":     s.At,
+			"block":  s.Block,
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"sweeps":       sweeps,
+		"linked_pairs": plan.LinkedPairs,
+		"warnings":     warningsResponse(plan.Warnings),
+	})
+}
+
+// Flags linkage risks in a sweep schedule proposed by the Recipient
+func EvaluateConsolidation(c *gin.Context) {
+	log.Println("Received request to evaluate a sweep schedule")
+
+	var req models.EvaluateSweepsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	sweeps := make([]*consolidation.Sweep, 0, len(req.Sweeps))
+	for _, s := range req.Sweeps {
+		if !common.IsHexAddress(s.From) || !common.IsHexAddress(s.To) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sweep address"})
+			return
+		}
+		sweeps = append(sweeps, &consolidation.Sweep{
+			From:  common.HexToAddress(s.From),
+			To:    common.HexToAddress(s.To),
+			Block: s.Block,
+		})
+	}
+	mainAddresses, err := parseAddressList(req.MainAddresses)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	linkedPairs, warnings := consolidation.Evaluate(sweeps, mainAddresses)
+	c.JSON(http.StatusOK, gin.H{
+		"linked_pairs": linkedPairs,
+		"warnings":     warningsResponse(warnings),
+	})
+}
+
+func parseStealthBalance(b models.StealthBalancePayload) (consolidation.StealthBalance, error) {
+	if !common.IsHexAddress(b.Address) {
+		return consolidation.StealthBalance{}, fmt.Errorf("invalid stealth address %q", b.Address)
+	}
+	token := privacy.NativeToken
+	if b.Token != "" {
+		if !common.IsHexAddress(b.Token) {
+			return consolidation.StealthBalance{}, fmt.Errorf("invalid token address %q", b.Token)
+		}
+		token = common.HexToAddress(b.Token)
+	}
+	amount, ok := new(big.Int).SetString(b.Amount, 10)
+	if !ok || amount.Sign() < 0 {
+		return consolidation.StealthBalance{}, fmt.Errorf("invalid amount %q", b.Amount)
+	}
+	return consolidation.StealthBalance{Address: common.HexToAddress(b.Address), Token: token, Amount: amount}, nil
+}
+
+func parseAddressList(list []string) ([]common.Address, error) {
+	addresses := make([]common.Address, 0, len(list))
+	for _, a := range list {
+		if !common.IsHexAddress(a) {
+			return nil, fmt.Errorf("invalid address %q", a)
+		}
+		addresses = append(addresses, common.HexToAddress(a))
+	}
+	return addresses, nil
+}
+
+func warningsResponse(warnings []consolidation.Warning) []gin.H {
+	response := make([]gin.H, len(warnings))
+	for i, w := range warnings {
+		addresses := make([]string, len(w.Addresses))
+		for j, a := range w.Addresses {
+			addresses[j] = a.Hex()
+		}
+		response[i] = gin.H{
+			"severity":  w.Severity,
+			"code":      w.Code,
+			"message":   w.Message,
+			"addresses": addresses,
+		}
+	}
+	return response
+}
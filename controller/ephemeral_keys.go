@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+	"github.com/prikshit/blockchain-privacy-module/internal/privacy"
+	"github.com/prikshit/blockchain-privacy-module/models"
+)
+
+// Reports the size and filter accuracy of the ephemeral key store
+func GetEphemeralKeyStatus(c *gin.Context, s *models.Server) {
+	log.Println("Received request for ephemeral key store status")
+
+	if s.PrivacyManager.EphemeralKeys == nil {
+		c.JSON(http.StatusOK, gin.H{"enabled": false})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"enabled": true,
+		"store":   s.PrivacyManager.EphemeralKeys.Stats(),
+	})
+}
+
+// Finds ephemeral keys reused across historical announcements
+func AuditEphemeralKeys(c *gin.Context) {
+	log.Println("Received request to audit announcements for ephemeral key reuse")
+
+	var req models.EphemeralKeyAuditRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	announcements, err := parseAnnouncements(req.Announcements)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reused := privacy.AuditEphemeralKeys(announcements)
+	duplicates := make([]gin.H, len(reused))
+	for i, r := range reused {
+		addresses := make([]string, len(r.StealthAddresses))
+		for j, a := range r.StealthAddresses {
+			addresses[j] = a.Hex()
+		}
+		duplicates[i] = gin.H{
+			"ephemeral_pub_key": hexutil.Encode(crypto.FromECDSAPub(r.EphemeralPubKey)),
+			"stealth_addresses": addresses,
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"announcements": len(announcements),
+		"duplicates":    duplicates,
+	})
+}
@@ -0,0 +1,221 @@
+package controller
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gin-gonic/gin"
+	"github.com/prikshit/blockchain-privacy-module/helpers"
+	"github.com/prikshit/blockchain-privacy-module/internal/escrow"
+	"github.com/prikshit/blockchain-privacy-module/internal/privacy"
+	"github.com/prikshit/blockchain-privacy-module/models"
+)
+
+// Registers a viewing key in escrow alongside its meta-address
+func RegisterEscrow(c *gin.Context, s *models.Server) {
+	log.Println("Received request to register an escrowed viewing key")
+	if !escrowEnabled(c, s) {
+		return
+	}
+
+	var req models.RegisterEscrowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	meta, err := privacy.ParseStealthMetaAddress(req.MetaAddress)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	viewingKey, err := helpers.ParseECDSAPrivKey(req.ViewingPrivKey)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid viewing private key"})
+		return
+	}
+
+	record, err := s.Escrow.Register(req.Actor, meta, viewingKey)
+	if err != nil {
+		log.Println("Error registering escrow:", err)
+		escrowError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, escrowRecordResponse(record))
+}
+
+// Returns an escrow record, including the encrypted share of every committee member
+func GetEscrowRecord(c *gin.Context, s *models.Server) {
+	if !escrowEnabled(c, s) {
+		return
+	}
+
+	record, err := s.Escrow.Record(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, escrowRecordResponse(record))
+}
+
+// Opens a court-ordered release request for an escrowed viewing key
+func RequestEscrowRelease(c *gin.Context, s *models.Server) {
+	log.Println("Received escrow release request")
+	if !escrowEnabled(c, s) {
+		return
+	}
+
+	var req models.ReleaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	release, err := s.Escrow.RequestRelease(req.RequestedBy, req.EscrowID, req.CourtOrder)
+	if err != nil {
+		log.Println("Error opening release request:", err)
+		escrowError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, releaseResponse(release))
+}
+
+// Records a committee member's approval and decrypted share
+func ApproveEscrowRelease(c *gin.Context, s *models.Server) {
+	log.Println("Received escrow release approval")
+	if !escrowEnabled(c, s) {
+		return
+	}
+
+	var req models.ApproveReleaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	share, err := hexutil.DecodeBig(req.Share)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid share"})
+		return
+	}
+
+	release, err := s.Escrow.Approve(c.Param("id"), req.MemberID, share)
+	if err != nil {
+		log.Println("Error approving release:", err)
+		escrowError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, releaseResponse(release))
+}
+
+// Reconstructs the viewing key of an approved request and scans the given announcements with it
+func ExecuteEscrowRelease(c *gin.Context, s *models.Server) {
+	log.Println("Received request to execute an escrow release")
+	if !escrowEnabled(c, s) {
+		return
+	}
+
+	var req models.ExecuteReleaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	announcements, err := parseAnnouncements(req.Announcements)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	results := make([]gin.H, 0)
+	err = s.Escrow.Execute(c.Param("id"), req.Actor, func(viewingKey *ecdsa.PrivateKey, meta *privacy.StealthMetaAddress) int {
+		for _, m := range s.PrivacyManager.ScanAnnouncements(viewingKey, meta.SpendingPubKey, req.Labels, announcements) {
+			results = append(results, gin.H{
+				"label":        m.Label,
+				"announcement": announcementToPayload(m.Announcement),
+			})
+		}
+		return len(results)
+	})
+	if err != nil {
+		log.Println("Error executing release:", err)
+		escrowError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"matches": results})
+}
+
+// Returns the escrow audit trail and whether its hash chain is intact
+func GetEscrowAudit(c *gin.Context, s *models.Server) {
+	if !escrowEnabled(c, s) {
+		return
+	}
+
+	audit := s.Escrow.Audit()
+	c.JSON(http.StatusOK, gin.H{
+		"entries":      audit.Entries(),
+		"chain_intact": audit.Verify() == nil,
+	})
+}
+
+func escrowEnabled(c *gin.Context, s *models.Server) bool {
+	if s.Escrow == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Viewing key escrow is not configured"})
+		return false
+	}
+	return true
+}
+
+// escrowError reports workflow errors with their status and anything else, such as a store failure,
+// as a server error.
+func escrowError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, escrow.ErrUnknownEscrow), errors.Is(err, escrow.ErrUnknownRequest):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, escrow.ErrRequestNotApproved), errors.Is(err, escrow.ErrRequestClosed), errors.Is(err, escrow.ErrAlreadyApproved),
+		errors.Is(err, escrow.ErrSharesNotHeld):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, escrow.ErrViewingKeyMismatch), errors.Is(err, escrow.ErrHybridNotSupported), errors.Is(err, escrow.ErrInvalidShare),
+		errors.Is(err, escrow.ErrUnknownMember), errors.Is(err, escrow.ErrMissingCourtReference):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update the escrow"})
+	}
+}
+
+func escrowRecordResponse(record *escrow.Record) gin.H {
+	shares := make([]gin.H, len(record.Shares))
+	for i, share := range record.Shares {
+		shares[i] = gin.H{
+			"member_id":  share.MemberID,
+			"index":      share.Index,
+			"ciphertext": hexutil.Encode(share.Ciphertext),
+		}
+	}
+	return gin.H{
+		"escrow_id":    record.ID,
+		"meta_address": record.MetaAddress.String(),
+		"threshold":    record.Threshold,
+		"shares":       shares,
+		"created_at":   record.CreatedAt,
+	}
+}
+
+func releaseResponse(release *escrow.ReleaseRequest) gin.H {
+	return gin.H{
+		"request_id":   release.ID,
+		"escrow_id":    release.EscrowID,
+		"court_order":  release.CourtOrder,
+		"requested_by": release.RequestedBy,
+		"status":       release.Status,
+		"approved_by":  release.ApprovedBy(),
+		"created_at":   release.CreatedAt,
+	}
+}
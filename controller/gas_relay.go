@@ -0,0 +1,208 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+	"github.com/prikshit/blockchain-privacy-module/helpers"
+	"github.com/prikshit/blockchain-privacy-module/internal/gasrelay"
+	"github.com/prikshit/blockchain-privacy-module/models"
+)
+
+// defaultSweepDeadline is how long a signed sweep stays valid when the request does not say.
+const defaultSweepDeadline = time.Hour
+
+// Returns the relayer's fee and the permit parameters for sweeping a token out of a stealth address
+func QuoteGasRelay(c *gin.Context, s *models.Server) {
+	log.Println("Received request for a gas relay quote")
+	if !gasRelayEnabled(c, s) {
+		return
+	}
+
+	var req models.GasRelayQuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if !common.IsHexAddress(req.Token) || !common.IsHexAddress(req.Owner) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token or owner address"})
+		return
+	}
+
+	quote, err := s.GasRelayer.Quote(c.Request.Context(), common.HexToAddress(req.Token), common.HexToAddress(req.Owner))
+	if err != nil {
+		log.Println("Error quoting gas relay:", err)
+		c.JSON(gasRelayErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"relayer":          quote.Relayer.Hex(),
+		"token":            quote.Token.Hex(),
+		"owner":            quote.Owner.Hex(),
+		"fee":              quote.Fee.String(),
+		"nonce":            quote.Nonce.String(),
+		"domain_separator": hexutil.Encode(quote.DomainSeparator[:]),
+		"chain_id":         quote.ChainID.String(),
+	})
+}
+
+// Signs a sweep with the Recipient's recovered stealth private key, ready to submit to the relayer
+func SignRelayedSweep(c *gin.Context, s *models.Server) {
+	log.Println("Received request to sign a relayed sweep")
+	if !gasRelayEnabled(c, s) {
+		return
+	}
+
+	var req models.SignSweepRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	stealthKey, err := helpers.ParseECDSAPrivKey(req.StealthPrivKey)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid stealth private key"})
+		return
+	}
+	if !common.IsHexAddress(req.Token) || !common.IsHexAddress(req.Destination) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token or destination address"})
+		return
+	}
+	amount, ok := new(big.Int).SetString(req.Amount, 10)
+	if !ok || amount.Sign() <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid amount"})
+		return
+	}
+	validFor := defaultSweepDeadline
+	if req.DeadlineSeconds > 0 {
+		validFor = time.Duration(req.DeadlineSeconds) * time.Second
+	}
+
+	owner := crypto.PubkeyToAddress(stealthKey.PublicKey)
+	quote, err := s.GasRelayer.Quote(c.Request.Context(), common.HexToAddress(req.Token), owner)
+	if err != nil {
+		log.Println("Error quoting gas relay:", err)
+		c.JSON(gasRelayErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	deadline := big.NewInt(time.Now().Add(validFor).Unix())
+	sweep, err := gasrelay.SignSweep(stealthKey, quote, common.HexToAddress(req.Destination), amount, deadline)
+	if err != nil {
+		log.Println("Error signing sweep:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign sweep"})
+		return
+	}
+
+	log.Printf("Signed sweep from %s", owner.Hex())
+	c.JSON(http.StatusOK, models.SweepRequestPayload{
+		Token:       sweep.Token.Hex(),
+		Owner:       sweep.Owner.Hex(),
+		Destination: sweep.Destination.Hex(),
+		Amount:      sweep.Amount.String(),
+		Fee:         sweep.Fee.String(),
+		Nonce:       sweep.Nonce.String(),
+		Deadline:    sweep.Deadline.String(),
+		Permit:      hexutil.Encode(sweep.Permit),
+		Signature:   hexutil.Encode(sweep.Signature),
+	})
+}
+
+// Relays a signed sweep: the relayer pays gas and takes its fee in the swept token
+func RelaySweep(c *gin.Context, s *models.Server) {
+	log.Println("Received request to relay a sweep")
+	if !gasRelayEnabled(c, s) {
+		return
+	}
+
+	var req models.SweepRequestPayload
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	sweep, err := parseSweepRequest(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := s.GasRelayer.Relay(c.Request.Context(), sweep)
+	if err != nil {
+		log.Println("Error relaying sweep:", err)
+		c.JSON(gasRelayErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"permit_tx":   result.PermitTx.Hex(),
+		"transfer_tx": result.TransferTx.Hex(),
+		"fee_tx":      result.FeeTx.Hex(),
+	})
+}
+
+func gasRelayEnabled(c *gin.Context, s *models.Server) bool {
+	if s.GasRelayer == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Gas relayer is not configured"})
+		return false
+	}
+	return true
+}
+
+func gasRelayErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gasrelay.ErrSanctionedDestination):
+		return http.StatusForbidden
+	case errors.Is(err, gasrelay.ErrUnsupportedToken), errors.Is(err, gasrelay.ErrFeeTooLow),
+		errors.Is(err, gasrelay.ErrAmountTooSmall), errors.Is(err, gasrelay.ErrExpired),
+		errors.Is(err, gasrelay.ErrInvalidSignature):
+		return http.StatusBadRequest
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+func parseSweepRequest(p models.SweepRequestPayload) (*gasrelay.SweepRequest, error) {
+	for _, addr := range []string{p.Token, p.Owner, p.Destination} {
+		if !common.IsHexAddress(addr) {
+			return nil, fmt.Errorf("invalid address %q", addr)
+		}
+	}
+	sweep := &gasrelay.SweepRequest{
+		Token:       common.HexToAddress(p.Token),
+		Owner:       common.HexToAddress(p.Owner),
+		Destination: common.HexToAddress(p.Destination),
+	}
+	for _, field := range []struct {
+		name  string
+		value string
+		out   **big.Int
+	}{
+		{"amount", p.Amount, &sweep.Amount},
+		{"fee", p.Fee, &sweep.Fee},
+		{"nonce", p.Nonce, &sweep.Nonce},
+		{"deadline", p.Deadline, &sweep.Deadline},
+	} {
+		n, ok := new(big.Int).SetString(field.value, 10)
+		if !ok || n.Sign() < 0 || n.BitLen() > 256 {
+			return nil, fmt.Errorf("invalid %s %q", field.name, field.value)
+		}
+		*field.out = n
+	}
+
+	var err error
+	if sweep.Permit, err = hexutil.Decode(p.Permit); err != nil {
+		return nil, fmt.Errorf("invalid permit signature: %v", err)
+	}
+	if sweep.Signature, err = hexutil.Decode(p.Signature); err != nil {
+		return nil, fmt.Errorf("invalid sweep signature: %v", err)
+	}
+	return sweep, nil
+}
@@ -2,7 +2,6 @@ package controller
 
 import (
 	"crypto/ecdsa"
-	"crypto/rand"
 	"encoding/hex"
 	"fmt"
 	"log"
@@ -10,22 +9,100 @@ import (
 
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/gin-gonic/gin"
+	"github.com/prikshit/blockchain-privacy-module/internal/wallet"
+	"github.com/prikshit/blockchain-privacy-module/models"
 )
 
-// Generate a new Account or Key pair
+// maxDerivedAccounts caps how many accounts a single derive request may return.
+const maxDerivedAccounts = 100
+
+// Generate a new Account or Key pair, backed by a fresh BIP-39 mnemonic
 func GenerateAccount(c *gin.Context) {
 	log.Println("Received request to generate a new Ethereum account")
 
-	// Generate a new private key
-	privateKey, err := ecdsa.GenerateKey(crypto.S256(), rand.Reader)
+	// Generate a new mnemonic so the account can be restored in standard wallets
+	mnemonic, err := wallet.NewMnemonic(wallet.MnemonicBits12Words)
+	if err != nil {
+		log.Println("Error generating mnemonic: ", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating mnemonic"})
+		return
+	}
+
+	hdWallet, err := wallet.NewHDWalletFromMnemonic(mnemonic, "")
 	if err != nil {
-		log.Println("Error generating private key: ", err.Error())
+		log.Println("Error creating HD wallet: ", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating private key"})
+		return
+	}
+
+	// Derive the first account at m/44'/60'/0'/0/0
+	account, err := hdWallet.DeriveAccount(0)
+	if err != nil {
+		log.Println("Error deriving account: ", err.Error())
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating private key"})
 		return
 	}
 
 	log.Println("Successfully generated private key")
 
+	// Return the mnemonic and the account details as a JSON response
+	log.Println("Returning generated account details")
+	response := accountResponse(account.PrivateKey)
+	response["mnemonic"] = mnemonic
+	response["derivation_path"] = account.Path.String()
+	c.JSON(http.StatusOK, response)
+}
+
+// Derives accounts by index from an imported BIP-39 mnemonic and optional passphrase
+func DeriveHDAccounts(c *gin.Context) {
+	log.Println("Received request to derive HD accounts")
+
+	var req models.DeriveAccountsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if req.Count == 0 {
+		req.Count = 1
+	}
+	if req.Count < 0 || req.Count > maxDerivedAccounts {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("count must be between 1 and %d", maxDerivedAccounts)})
+		return
+	}
+	if uint64(req.StartIndex)+uint64(req.Count) > uint64(wallet.HardenedKeyStart) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("start_index + count must not exceed %d", wallet.HardenedKeyStart)})
+		return
+	}
+
+	hdWallet, err := wallet.NewHDWalletFromMnemonic(req.Mnemonic, req.Passphrase)
+	if err != nil {
+		log.Println("Error importing mnemonic:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mnemonic"})
+		return
+	}
+
+	derived := make([]gin.H, 0, req.Count)
+	for i := 0; i < req.Count; i++ {
+		index := req.StartIndex + uint32(i)
+		account, err := hdWallet.DeriveAccount(index)
+		if err != nil {
+			log.Printf("Error deriving account %d: %v", index, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error deriving account"})
+			return
+		}
+		response := accountResponse(account.PrivateKey)
+		response["index"] = index
+		response["derivation_path"] = account.Path.String()
+		derived = append(derived, response)
+	}
+
+	log.Printf("Returning %d derived accounts", len(derived))
+	c.JSON(http.StatusOK, gin.H{"accounts": derived})
+}
+
+// accountResponse formats a private key as the account JSON returned by the account endpoints.
+func accountResponse(privateKey *ecdsa.PrivateKey) gin.H {
 	// Get the public key from the private key
 	publicKeyECDSA := privateKey.PublicKey
 
@@ -34,23 +111,16 @@ func GenerateAccount(c *gin.Context) {
 	log.Printf("Generated Ethereum address: %s", address.Hex())
 
 	// Convert the private key to hexadecimal format
-	privateKeyHex := hex.EncodeToString(privateKey.D.Bytes())
-	log.Printf("Private key (hex): %s", privateKeyHex)
+	privateKeyHex := hex.EncodeToString(crypto.FromECDSA(privateKey))
 
 	// Convert the public key (X, Y coordinates) to uncompressed format (0x04 prefix)
-	pubKeyBytes := append([]byte{0x04}, publicKeyECDSA.X.Bytes()...)
-	pubKeyBytes = append(pubKeyBytes, publicKeyECDSA.Y.Bytes()...)
-
-	// Convert the uncompressed public key to hex format
-	pubKeyHex := fmt.Sprintf("0x%x", pubKeyBytes)
+	pubKeyHex := fmt.Sprintf("0x%x", crypto.FromECDSAPub(&publicKeyECDSA))
 	log.Printf("Public key (hex): %s", pubKeyHex)
 
-	// Return the private key, public key (uncompressed), publick key in ecdsa format and Ethereum address as a JSON response
-	log.Println("Returning generated account details")
-	c.JSON(http.StatusOK, gin.H{
+	return gin.H{
 		"private_key":      "0x" + privateKeyHex, // Private key in hexadecimal
 		"public_key":       pubKeyHex,            // Uncompressed public key (secp256k1)
 		"public_key_ecdsa": publicKeyECDSA,       // Public Key ECDSA
 		"address":          address.Hex(),        // Ethereum address (Hex format)
-	})
+	}
 }
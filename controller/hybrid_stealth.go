@@ -0,0 +1,203 @@
+package controller
+
+import (
+	"crypto/mlkem"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+	"github.com/prikshit/blockchain-privacy-module/helpers"
+	"github.com/prikshit/blockchain-privacy-module/internal/privacy"
+	"github.com/prikshit/blockchain-privacy-module/models"
+)
+
+// Generates the Recipient's hybrid (ECDH + ML-KEM-768) keys and meta-address
+func GenerateHybridKeys(c *gin.Context) {
+	log.Println("Received request to generate hybrid stealth keys")
+
+	keys, err := privacy.GenerateHybridKeys()
+	if err != nil {
+		log.Println("Error generating hybrid keys:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating hybrid keys"})
+		return
+	}
+
+	log.Println("Returning hybrid stealth keys")
+	c.JSON(http.StatusOK, gin.H{
+		"spending_priv_key": hexutil.Encode(crypto.FromECDSA(keys.SpendingKey)),
+		"spending_pub_key":  hexutil.Encode(crypto.FromECDSAPub(&keys.SpendingKey.PublicKey)),
+		"viewing_priv_key":  hexutil.Encode(crypto.FromECDSA(keys.ViewingKey)),
+		"kem_seed":          hexutil.Encode(keys.KEMKey.Bytes()),
+		"meta_address":      keys.MetaAddress().String(),
+	})
+}
+
+// Generates the hybrid Stealth Account and its announcement (by Payer)
+func GenerateHybridStealthAccount(c *gin.Context, s *models.Server) {
+	log.Println("Received request to generate a hybrid stealth account")
+
+	var req models.MetaAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	meta, err := privacy.ParseStealthMetaAddress(req.MetaAddress)
+	if err != nil {
+		log.Println("Invalid meta-address:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	stealthPub, ann, err := s.PrivacyManager.GenerateHybridStealthAddress(meta)
+	if err != nil {
+		log.Printf("Error generating hybrid stealth address: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.Println("Returning hybrid stealth account details")
+	c.JSON(http.StatusOK, gin.H{
+		"stealth_pub_key": hexutil.Encode(crypto.FromECDSAPub(stealthPub)),
+		"announcement":    announcementToPayload(ann),
+	})
+}
+
+// Scans announcements for hybrid stealth payments addressed to the Recipient
+func ScanHybridAnnouncements(c *gin.Context, s *models.Server) {
+	log.Println("Received request to scan hybrid announcements")
+
+	var req models.ScanHybridRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	viewingKey, err := helpers.ParseECDSAPrivKey(req.ViewingPrivKey)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid viewing private key"})
+		return
+	}
+	kemKey, err := parseKEMSeed(req.KEMSeed)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ML-KEM seed"})
+		return
+	}
+	spendingPub, err := crypto.UnmarshalPubkey(common.FromHex(req.SpendingPubKey))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid spending public key"})
+		return
+	}
+	announcements, err := parseAnnouncements(req.Announcements)
+	if err != nil {
+		log.Println("Invalid announcement:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	matches := s.PrivacyManager.ScanHybridAnnouncements(viewingKey, kemKey, spendingPub, announcements)
+
+	payloads := make([]models.AnnouncementPayload, 0, len(matches))
+	for _, ann := range matches {
+		payloads = append(payloads, announcementToPayload(ann))
+	}
+	c.JSON(http.StatusOK, gin.H{"matches": payloads})
+}
+
+// Recovers the hybrid stealth Private Key for an announcement (By Recipient)
+func RecoverHybridStealthPrivKey(c *gin.Context, s *models.Server) {
+	log.Println("Received request to recover hybrid stealth private key")
+
+	var req models.RecoverHybridPrivKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	spendingKey, err := helpers.ParseECDSAPrivKey(req.SpendingPrivKey)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid spending private key"})
+		return
+	}
+	viewingKey, err := helpers.ParseECDSAPrivKey(req.ViewingPrivKey)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid viewing private key"})
+		return
+	}
+	kemKey, err := parseKEMSeed(req.KEMSeed)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ML-KEM seed"})
+		return
+	}
+	ann, err := parseAnnouncement(req.Announcement)
+	if err != nil {
+		log.Println("Invalid announcement:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	keys := &privacy.HybridKeys{SpendingKey: spendingKey, ViewingKey: viewingKey, KEMKey: kemKey}
+	recoveredPrivKey, err := s.PrivacyManager.RecoverHybridStealthPrivateKey(keys, ann)
+	if err != nil {
+		log.Println("Error recovering hybrid stealth private key:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.Println("Successfully recovered hybrid stealth private key")
+	c.JSON(http.StatusOK, gin.H{
+		"recovered_priv_key_hex": hexutil.Encode(crypto.FromECDSA(recoveredPrivKey)),
+		"recovered_pub_key_hex":  hexutil.Encode(crypto.FromECDSAPub(&recoveredPrivKey.PublicKey)),
+		"recovered_address":      crypto.PubkeyToAddress(recoveredPrivKey.PublicKey).Hex(),
+	})
+}
+
+func parseKEMSeed(seedHex string) (*mlkem.DecapsulationKey768, error) {
+	seed, err := hexutil.Decode(seedHex)
+	if err != nil {
+		return nil, err
+	}
+	return mlkem.NewDecapsulationKey768(seed)
+}
+
+func parseAnnouncement(p models.AnnouncementPayload) (*privacy.Announcement, error) {
+	if !common.IsHexAddress(p.StealthAddress) {
+		return nil, fmt.Errorf("invalid stealth address %q", p.StealthAddress)
+	}
+	ephemeralPub, err := crypto.UnmarshalPubkey(common.FromHex(p.EphemeralPubKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ephemeral public key: %v", err)
+	}
+	return &privacy.Announcement{
+		StealthAddress:  common.HexToAddress(p.StealthAddress),
+		EphemeralPubKey: ephemeralPub,
+		Metadata:        common.FromHex(p.Metadata),
+	}, nil
+}
+
+func parseAnnouncements(payloads []models.AnnouncementPayload) ([]*privacy.Announcement, error) {
+	announcements := make([]*privacy.Announcement, 0, len(payloads))
+	for _, p := range payloads {
+		ann, err := parseAnnouncement(p)
+		if err != nil {
+			return nil, err
+		}
+		announcements = append(announcements, ann)
+	}
+	return announcements, nil
+}
+
+func announcementToPayload(ann *privacy.Announcement) models.AnnouncementPayload {
+	return models.AnnouncementPayload{
+		StealthAddress:  ann.StealthAddress.Hex(),
+		EphemeralPubKey: hexutil.Encode(crypto.FromECDSAPub(ann.EphemeralPubKey)),
+		Metadata:        hexutil.Encode(ann.Metadata),
+	}
+}
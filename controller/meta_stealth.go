@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+	"github.com/prikshit/blockchain-privacy-module/helpers"
+	"github.com/prikshit/blockchain-privacy-module/internal/privacy"
+	"github.com/prikshit/blockchain-privacy-module/models"
+)
+
+// Derives a labelled sub-meta-address from the Recipient's spending and viewing keys
+func GenerateLabelledMetaAddress(c *gin.Context) {
+	log.Println("Received request to generate a labelled meta-address")
+
+	var req models.LabelledMetaAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	spendingPub, err := crypto.UnmarshalPubkey(common.FromHex(req.SpendingPubKey))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid spending public key"})
+		return
+	}
+	viewingKey, err := helpers.ParseECDSAPrivKey(req.ViewingPrivKey)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid viewing private key"})
+		return
+	}
+
+	meta := &privacy.StealthMetaAddress{
+		SpendingPubKey: privacy.LabelledSpendingPubKey(spendingPub, viewingKey, req.Label),
+		ViewingPubKey:  &viewingKey.PublicKey,
+	}
+
+	log.Printf("Returning meta-address for label %q", req.Label)
+	c.JSON(http.StatusOK, gin.H{
+		"label":        req.Label,
+		"meta_address": meta.String(),
+	})
+}
+
+// Generates a Stealth Account and announcement for a classic or hybrid meta-address (by Payer)
+func GenerateStealthPayment(c *gin.Context, s *models.Server) {
+	log.Println("Received request to generate a stealth payment")
+
+	var req models.MetaAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	meta, err := privacy.ParseStealthMetaAddress(req.MetaAddress)
+	if err != nil {
+		log.Println("Invalid meta-address:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	stealthPub, ann, err := s.PrivacyManager.GenerateStealthPayment(meta)
+	if err != nil {
+		log.Printf("Error generating stealth payment: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.Println("Returning stealth payment details")
+	c.JSON(http.StatusOK, gin.H{
+		"stealth_pub_key": hexutil.Encode(crypto.FromECDSAPub(stealthPub)),
+		"announcement":    announcementToPayload(ann),
+	})
+}
+
+// Scans announcements for payments to the Recipient's meta-address and its labels
+func ScanStealthAnnouncements(c *gin.Context, s *models.Server) {
+	log.Println("Received request to scan stealth announcements")
+
+	var req models.ScanStealthRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	viewingKey, err := helpers.ParseECDSAPrivKey(req.ViewingPrivKey)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid viewing private key"})
+		return
+	}
+	spendingPub, err := crypto.UnmarshalPubkey(common.FromHex(req.SpendingPubKey))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid spending public key"})
+		return
+	}
+	announcements, err := parseAnnouncements(req.Announcements)
+	if err != nil {
+		log.Println("Invalid announcement:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	matches := s.PrivacyManager.ScanAnnouncements(viewingKey, spendingPub, req.Labels, announcements)
+
+	results := make([]gin.H, 0, len(matches))
+	for _, m := range matches {
+		results = append(results, gin.H{
+			"label":        m.Label,
+			"announcement": announcementToPayload(m.Announcement),
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"matches": results})
+}
+
+// Recovers the stealth Private Key for an announcement paid to a (labelled) meta-address (By Recipient)
+func RecoverLabelledStealthPrivKey(c *gin.Context, s *models.Server) {
+	log.Println("Received request to recover labelled stealth private key")
+
+	var req models.RecoverStealthPrivKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	spendingKey, err := helpers.ParseECDSAPrivKey(req.SpendingPrivKey)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid spending private key"})
+		return
+	}
+	viewingKey, err := helpers.ParseECDSAPrivKey(req.ViewingPrivKey)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid viewing private key"})
+		return
+	}
+	ann, err := parseAnnouncement(req.Announcement)
+	if err != nil {
+		log.Println("Invalid announcement:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	keys := &privacy.StealthKeys{SpendingKey: spendingKey, ViewingKey: viewingKey}
+	recoveredPrivKey, err := s.PrivacyManager.RecoverLabelledStealthPrivateKey(keys, req.Label, ann)
+	if err != nil {
+		log.Println("Error recovering stealth private key:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.Println("Successfully recovered labelled stealth private key")
+	c.JSON(http.StatusOK, gin.H{
+		"recovered_priv_key_hex": hexutil.Encode(crypto.FromECDSA(recoveredPrivKey)),
+		"recovered_pub_key_hex":  hexutil.Encode(crypto.FromECDSAPub(&recoveredPrivKey.PublicKey)),
+		"recovered_address":      crypto.PubkeyToAddress(recoveredPrivKey.PublicKey).Hex(),
+	})
+}
@@ -1,14 +1,21 @@
 package controller
 
 import (
+	"errors"
 	"log"
+	"math/big"
 	"net/http"
+	"slices"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/gin-gonic/gin"
+	"github.com/prikshit/blockchain-privacy-module/internal/listwatch"
+	"github.com/prikshit/blockchain-privacy-module/internal/sanctions"
 	"github.com/prikshit/blockchain-privacy-module/models"
 )
 
-// HandleAddSanctionedAddress adds a new address to the sanctioned list.
+// HandleAddSanctionedAddress adds a new address to the internal sanctions list.
 func HandleAddSanctionedAddress(c *gin.Context, s *models.Server) {
 	var request struct {
 		Address string `json:"address" binding:"required"`
@@ -21,16 +28,27 @@ func HandleAddSanctionedAddress(c *gin.Context, s *models.Server) {
 		return
 	}
 
-	log.Printf("Adding address to sanctioned list: %s\n", request.Address)
+	address, err := sanctions.ParseAddress(request.Address)
+	if err != nil {
+		log.Println("Invalid address:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.Printf("Adding address to sanctioned list: %s\n", address.Hex())
 
-	s.PrivacyManager.Detector.AddAddress(request.Address)
+	if err := s.PrivacyManager.Detector.AddAddress(address); err != nil {
+		log.Println("Error adding sanctioned address:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store sanctioned address"})
+		return
+	}
 
-	log.Printf("Address %s added to sanctioned list successfully\n", request.Address)
+	log.Printf("Address %s added to sanctioned list successfully\n", address.Hex())
 
-	c.JSON(http.StatusOK, gin.H{"message": "Address added to sanctioned list"})
+	c.JSON(http.StatusOK, gin.H{"message": "Address added to sanctioned list", "address": address.Hex()})
 }
 
-// HandleRemoveSanctionedAddress removes an address from the sanctioned list.
+// HandleRemoveSanctionedAddress removes an address from the internal sanctions list.
 func HandleRemoveSanctionedAddress(c *gin.Context, s *models.Server) {
 	var request struct {
 		Address string `json:"address" binding:"required"`
@@ -44,19 +62,32 @@ func HandleRemoveSanctionedAddress(c *gin.Context, s *models.Server) {
 		return
 	}
 
-	log.Printf("Removing address from sanctioned list: %s\n", request.Address)
+	address, err := sanctions.ParseAddress(request.Address)
+	if err != nil {
+		log.Println("Invalid address:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	s.PrivacyManager.Detector.RemoveAddress(request.Address)
+	log.Printf("Removing address from sanctioned list: %s\n", address.Hex())
+
+	if err := s.PrivacyManager.Detector.RemoveAddress(address); err != nil {
+		log.Println("Error removing sanctioned address:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store sanctioned address removal"})
+		return
+	}
 
-	log.Printf("Address %s removed from sanctioned list successfully\n", request.Address)
+	log.Printf("Address %s removed from sanctioned list successfully\n", address.Hex())
 
-	c.JSON(http.StatusOK, gin.H{"message": "Address removed from sanctioned list"})
+	c.JSON(http.StatusOK, gin.H{"message": "Address removed from sanctioned list", "address": address.Hex()})
 }
 
-// Verifies the Sanctioned Addresses
+// Verifies the Sanctioned Addresses, now or as of a past time or block
 func HandleCheckSanction(c *gin.Context, s *models.Server) {
 	var req struct {
-		Address string `json:"address"`
+		Address string  `json:"address" binding:"required"`
+		At      string  `json:"at"`    // RFC 3339 time to answer as of
+		Block   *uint64 `json:"block"` // Block whose timestamp to answer as of
 	}
 
 	log.Println("Received request to check sanction status")
@@ -68,13 +99,354 @@ func HandleCheckSanction(c *gin.Context, s *models.Server) {
 		return
 	}
 
-	log.Printf("Checking sanction status for address: %s", req.Address)
+	address, err := sanctions.ParseAddress(req.Address)
+	if err != nil {
+		log.Println("Invalid address:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.At != "" || req.Block != nil {
+		checkSanctionAsOf(c, s, address, req.At, req.Block)
+		return
+	}
+
+	log.Printf("Checking sanction status for address: %s", address.Hex())
 
 	// Sanction check based on the provided address
-	isSanctioned := s.PrivacyManager.Detector.IsSanctioned(req.Address)
+	result := s.PrivacyManager.Detector.Check(address)
 
-	log.Printf("Sanction status for %s: %v", req.Address, isSanctioned)
+	log.Printf("Sanction status for %s: %v", address.Hex(), result.Sanctioned)
 
 	// Return the result as a JSON response
-	c.JSON(http.StatusOK, gin.H{"sanctioned": isSanctioned})
+	// Return every list the address is on, as each carries a different legal meaning
+	response := gin.H{
+		"sanctioned": result.Sanctioned,
+		"address":    address.Hex(),
+		"lists":      listNames(result.Matches),
+		"matches":    result.Matches,
+	}
+	// Explain why a listed address was let through
+	if result.Exemption != nil {
+		log.Printf("Sanctions exemption applied to %s for lists %v", address.Hex(), result.ExemptedLists)
+		response["exemption"] = result.Exemption
+		response["exempted_lists"] = result.ExemptedLists
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// checkSanctionAsOf answers whether address was sanctioned at a past time, given directly or as a block.
+func checkSanctionAsOf(c *gin.Context, s *models.Server, address common.Address, at string, block *uint64) {
+	var asOf time.Time
+	switch {
+	case at != "" && block != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Give either at or block, not both"})
+		return
+	case at != "":
+		var err error
+		if asOf, err = time.Parse(time.RFC3339, at); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "at must be an RFC 3339 time"})
+			return
+		}
+	default:
+		if !chainEnabled(c, s) {
+			return
+		}
+		header, err := s.Chain.HeaderByNumber(c.Request.Context(), new(big.Int).SetUint64(*block))
+		if err != nil {
+			log.Printf("Error fetching block %d: %v\n", *block, err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to fetch block"})
+			return
+		}
+		asOf = time.Unix(int64(header.Time), 0)
+	}
+	asOf = asOf.UTC()
+
+	log.Printf("Checking sanction status for address %s as of %s", address.Hex(), asOf.Format(time.RFC3339))
+	result, err := s.PrivacyManager.Detector.CheckAt(address, asOf)
+	if err != nil {
+		log.Println("Error reading sanctions history:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read sanctions history"})
+		return
+	}
+	// Current index matches are included, so an index list never answers "not sanctioned" for lack of history
+	response := gin.H{
+		"sanctioned": result.Sanctioned,
+		"address":    address.Hex(),
+		"as_of":      asOf,
+		"lists":      listNames(result.Matches),
+		"matches":    result.Matches,
+	}
+	// Explain why a listed address was let through at the time
+	if result.Exemption != nil {
+		response["exemption"] = result.Exemption
+		response["exempted_lists"] = result.ExemptedLists
+	}
+	if block != nil {
+		response["block"] = *block
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// Returns the sanctions exemptions in force, ordered by address
+func HandleGetSanctionsExemptions(c *gin.Context, s *models.Server) {
+	log.Println("Received request for sanctions exemptions")
+
+	exemptions := s.PrivacyManager.Detector.Exemptions()
+	addresses := make([]common.Address, 0, len(exemptions))
+	for address := range exemptions {
+		addresses = append(addresses, address)
+	}
+	slices.SortFunc(addresses, func(a, b common.Address) int { return a.Cmp(b) })
+	exempted := make([]gin.H, 0, len(addresses))
+	for _, address := range addresses {
+		exempted = append(exempted, gin.H{"address": address.Hex(), "exemption": exemptions[address]})
+	}
+	c.JSON(http.StatusOK, gin.H{"exemptions": exempted})
+}
+
+// Exempts an address from some or all sanctions lists, with a justification and an expiry
+func HandleAddSanctionsExemption(c *gin.Context, s *models.Server) {
+	log.Println("Received request to add a sanctions exemption")
+
+	var request struct {
+		Address       string   `json:"address" binding:"required"`
+		Justification string   `json:"justification" binding:"required"`
+		Reference     string   `json:"reference"`
+		Lists         []string `json:"lists"`                      // Lists it overrides; every list when empty
+		Expires       string   `json:"expires" binding:"required"` // RFC 3339 time the exemption lapses
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Println("Invalid request:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	address, err := sanctions.ParseAddress(request.Address)
+	if err != nil {
+		log.Println("Invalid address:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	expires, err := time.Parse(time.RFC3339, request.Expires)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires must be an RFC 3339 time"})
+		return
+	}
+
+	exemption := sanctions.Exemption{
+		Justification: request.Justification,
+		Reference:     request.Reference,
+		Lists:         request.Lists,
+		Expires:       expires.UTC(),
+	}
+	if err := s.PrivacyManager.Detector.Exempt(address, exemption); err != nil {
+		log.Println("Error adding sanctions exemption:", err)
+		if errors.Is(err, sanctions.ErrMissingJustification) || errors.Is(err, sanctions.ErrAlreadyExpired) ||
+			errors.Is(err, sanctions.ErrInvalidListName) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store sanctions exemption"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Sanctions exemption added", "address": address.Hex()})
+}
+
+// Withdraws the sanctions exemption of an address, so its listings apply again
+func HandleRemoveSanctionsExemption(c *gin.Context, s *models.Server) {
+	log.Println("Received request to remove a sanctions exemption")
+
+	var request struct {
+		Address string `json:"address" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Println("Invalid request:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	address, err := sanctions.ParseAddress(request.Address)
+	if err != nil {
+		log.Println("Invalid address:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	removed, err := s.PrivacyManager.Detector.RemoveExemption(address)
+	if err != nil {
+		log.Println("Error removing sanctions exemption:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store sanctions exemption removal"})
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Address has no exemption"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Sanctions exemption removed", "address": address.Hex()})
+}
+
+// Returns every change recorded for an address on any list
+func HandleGetSanctionsHistory(c *gin.Context, s *models.Server) {
+	address, err := sanctions.ParseAddress(c.Param("address"))
+	if err != nil {
+		log.Println("Invalid address:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("Received request for sanctions history of %s\n", address.Hex())
+
+	history, err := s.PrivacyManager.Detector.History(address)
+	if err != nil {
+		log.Println("Error reading sanctions history:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read sanctions history"})
+		return
+	}
+	if history == nil {
+		history = []sanctions.Change{}
+	}
+	c.JSON(http.StatusOK, gin.H{"address": address.Hex(), "history": history})
+}
+
+func listNames(matches []sanctions.Entry) []string {
+	lists := make([]string, 0, len(matches))
+	for _, m := range matches {
+		lists = append(lists, m.List)
+	}
+	return lists
+}
+
+// Returns the sanctions lists and how many addresses each holds
+func HandleGetSanctionsLists(c *gin.Context, s *models.Server) {
+	log.Println("Received request for sanctions lists")
+	c.JSON(http.StatusOK, gin.H{"lists": s.PrivacyManager.Detector.Lists(), "indexes": s.PrivacyManager.Detector.Indexes()})
+}
+
+// Returns the entries of one named sanctions list, with the expiry of those that lapse
+func HandleGetSanctionsList(c *gin.Context, s *models.Server) {
+	list := c.Param("list")
+	log.Printf("Received request for sanctions list %s\n", list)
+
+	entries := s.PrivacyManager.Detector.Entries(list)
+	addresses := make([]common.Address, 0, len(entries))
+	for address := range entries {
+		addresses = append(addresses, address)
+	}
+	slices.SortFunc(addresses, func(a, b common.Address) int { return a.Cmp(b) })
+	listed := make([]gin.H, 0, len(addresses))
+	for _, address := range addresses {
+		listed = append(listed, gin.H{"address": address.Hex(), "entry": entries[address]})
+	}
+	c.JSON(http.StatusOK, gin.H{"list": list, "entries": listed})
+}
+
+// Adds an address to one named sanctions list, recording where the listing comes from and why
+func HandleAddToSanctionsList(c *gin.Context, s *models.Server) {
+	list := c.Param("list")
+	log.Printf("Received request to add an address to sanctions list %s\n", list)
+
+	var request struct {
+		Address   string `json:"address" binding:"required"`
+		Reference string `json:"reference"`
+		Reason    string `json:"reason" binding:"required"`
+		Expires   string `json:"expires"` // RFC 3339 time the listing lapses; never when empty
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Println("Invalid request:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	address, err := sanctions.ParseAddress(request.Address)
+	if err != nil {
+		log.Println("Invalid address:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry := sanctions.Entry{Reference: request.Reference, Reason: request.Reason}
+	if request.Expires != "" {
+		if entry.Expires, err = time.Parse(time.RFC3339, request.Expires); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expires must be an RFC 3339 time"})
+			return
+		}
+		entry.Expires = entry.Expires.UTC()
+	}
+
+	if err := s.PrivacyManager.Detector.Add(list, address, entry); err != nil {
+		log.Println("Error adding address to sanctions list:", err)
+		if errors.Is(err, sanctions.ErrInvalidListName) || errors.Is(err, sanctions.ErrAlreadyExpired) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store sanctions list entry"})
+		}
+		return
+	}
+
+	log.Printf("Address %s added to sanctions list %s\n", address.Hex(), list)
+	c.JSON(http.StatusOK, gin.H{"message": "Address added to sanctions list", "list": list, "address": address.Hex()})
+}
+
+// Removes an address from one named sanctions list, leaving its other listings in place
+func HandleRemoveFromSanctionsList(c *gin.Context, s *models.Server) {
+	list := c.Param("list")
+	log.Printf("Received request to remove an address from sanctions list %s\n", list)
+
+	var request struct {
+		Address string `json:"address" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Println("Invalid request:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	address, err := sanctions.ParseAddress(request.Address)
+	if err != nil {
+		log.Println("Invalid address:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	removed, err := s.PrivacyManager.Detector.Remove(list, address)
+	if err != nil {
+		log.Println("Error removing address from sanctions list:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store sanctions list removal"})
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Address is not on this list"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Address removed from sanctions list", "list": list, "address": address.Hex()})
+}
+
+// Returns the watched list files, the version loaded from each and the recent reload events
+func HandleGetSanctionsReloads(c *gin.Context, s *models.Server) {
+	log.Println("Received request for sanctions list reloads")
+	if s.ListWatcher == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "No sanctions list files are watched"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"sources": s.ListWatcher.Sources(), "events": s.ListWatcher.Events()})
+}
+
+// Loads the current file of a list even if it drops more of the list than a reload may, for an intended delisting
+func HandleForceSanctionsReload(c *gin.Context, s *models.Server) {
+	list := c.Param("list")
+	log.Printf("Received request to force a reload of sanctions list %s\n", list)
+	if s.ListWatcher == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "No sanctions list files are watched"})
+		return
+	}
+	event, err := s.ListWatcher.Force(list)
+	if errors.Is(err, listwatch.ErrUnknownSource) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, listwatch.ErrAlreadyLoaded) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if event.Status == listwatch.StatusFailed {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": event.Error, "event": event})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"event": event})
 }
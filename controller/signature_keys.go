@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+	"github.com/prikshit/blockchain-privacy-module/internal/privacy"
+	"github.com/prikshit/blockchain-privacy-module/models"
+)
+
+// Returns the message a wallet must sign to derive its stealth keys
+func GetStealthKeysMessage(c *gin.Context) {
+	log.Println("Received request for the stealth keys message")
+	c.JSON(http.StatusOK, gin.H{"message": privacy.StealthKeysMessage})
+}
+
+// Derives the Recipient's spending and viewing keys from a wallet signature
+func DeriveStealthKeysFromSignature(c *gin.Context) {
+	log.Println("Received request to derive stealth keys from a signature")
+
+	var req models.DeriveStealthKeysRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if !common.IsHexAddress(req.Address) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid address"})
+		return
+	}
+	signature, err := hexutil.Decode(req.Signature)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid signature encoding"})
+		return
+	}
+	confirmation, err := hexutil.Decode(req.ConfirmSignature)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid confirm_signature encoding"})
+		return
+	}
+
+	keys, err := privacy.DeriveStealthKeysFromSignature(common.HexToAddress(req.Address), signature, confirmation)
+	if err != nil {
+		log.Println("Error deriving stealth keys:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.Println("Returning derived stealth keys")
+	c.JSON(http.StatusOK, gin.H{
+		"spending_priv_key": hexutil.Encode(crypto.FromECDSA(keys.SpendingKey)),
+		"spending_pub_key":  hexutil.Encode(crypto.FromECDSAPub(&keys.SpendingKey.PublicKey)),
+		"viewing_priv_key":  hexutil.Encode(crypto.FromECDSA(keys.ViewingKey)),
+		"meta_address":      keys.MetaAddress().String(),
+	})
+}
@@ -0,0 +1,197 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+	"github.com/prikshit/blockchain-privacy-module/helpers"
+	"github.com/prikshit/blockchain-privacy-module/internal/privacy"
+	"github.com/prikshit/blockchain-privacy-module/internal/smartaccount"
+	"github.com/prikshit/blockchain-privacy-module/models"
+)
+
+// Generates a stealth payment whose funds go to the counterfactual smart account of the stealth owner (by Payer).
+// The announcement names the stealth owner, so the Recipient scans for it as usual.
+func GenerateSmartAccountPayment(c *gin.Context, s *models.Server) {
+	log.Println("Received request to generate a smart account stealth payment")
+	if !smartAccountsEnabled(c, s) {
+		return
+	}
+
+	var req models.MetaAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	meta, err := privacy.ParseStealthMetaAddress(req.MetaAddress)
+	if err != nil {
+		log.Println("Invalid meta-address:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	_, ann, err := s.PrivacyManager.GenerateStealthPayment(meta)
+	if err != nil {
+		log.Printf("Error generating stealth payment: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account := s.SmartAccounts.AccountAddress(ann.StealthAddress)
+	log.Printf("Returning smart account %s for stealth owner %s", account.Hex(), ann.StealthAddress.Hex())
+	c.JSON(http.StatusOK, gin.H{
+		"account_address": account.Hex(),
+		"owner":           ann.StealthAddress.Hex(),
+		"announcement":    announcementToPayload(ann),
+	})
+}
+
+// Builds and signs a user operation that deploys the Recipient's smart account if needed and sweeps it
+func SignSmartAccountSweep(c *gin.Context, s *models.Server) {
+	log.Println("Received request to sign a smart account sweep")
+	if !smartAccountsEnabled(c, s) {
+		return
+	}
+
+	var req models.SmartAccountSweepRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	ownerKey, err := helpers.ParseECDSAPrivKey(req.StealthPrivKey)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid stealth private key"})
+		return
+	}
+	if !common.IsHexAddress(req.Destination) || (req.Token != "" && !common.IsHexAddress(req.Token)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token or destination address"})
+		return
+	}
+	token := privacy.NativeToken
+	if req.Token != "" {
+		token = common.HexToAddress(req.Token)
+	}
+	destination := common.HexToAddress(req.Destination)
+	if s.PrivacyManager.Detector.IsSanctioned(destination) {
+		log.Printf("Sanctioned sweep destination: %s\n", destination.Hex())
+		c.JSON(http.StatusForbidden, gin.H{"error": "Destination address is sanctioned"})
+		return
+	}
+
+	var amount *big.Int
+	if req.Amount != "" {
+		if amount, err = parseQuantity("amount", req.Amount); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	var gas smartaccount.Gas
+	if gas.MaxFeePerGas, err = parseQuantity("max_fee_per_gas", req.MaxFeePerGas); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if gas.MaxPriorityFeePerGas, err = parseQuantity("max_priority_fee_per_gas", req.MaxPriorityFeePerGas); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	paymaster, err := parsePaymaster(req.Paymaster)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	op, err := s.SmartAccounts.BuildSweep(c.Request.Context(), ownerKey, token, destination, amount, gas, paymaster)
+	if errors.Is(err, smartaccount.ErrNothingToSweep) || errors.Is(err, smartaccount.ErrValueTooLarge) ||
+		errors.Is(err, smartaccount.ErrNeedsPaymaster) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		log.Println("Error building user operation:", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to build user operation"})
+		return
+	}
+
+	log.Printf("Signed sweep user operation for %s", crypto.PubkeyToAddress(ownerKey.PublicKey).Hex())
+	c.JSON(http.StatusOK, gin.H{
+		"entry_point":    s.SmartAccounts.EntryPoint().Hex(),
+		"user_op_hash":   op.Hash(s.SmartAccounts.EntryPoint(), s.SmartAccounts.ChainID()).Hex(),
+		"user_operation": userOperationToPayload(op),
+	})
+}
+
+func smartAccountsEnabled(c *gin.Context, s *models.Server) bool {
+	if s.SmartAccounts == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Smart accounts are not configured"})
+		return false
+	}
+	return true
+}
+
+// parseQuantity parses a non-negative decimal amount below 2^256; an empty string is zero.
+func parseQuantity(name, value string) (*big.Int, error) {
+	if value == "" {
+		return new(big.Int), nil
+	}
+	n, ok := new(big.Int).SetString(value, 10)
+	if !ok || n.Sign() < 0 || n.BitLen() > 256 {
+		return nil, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return n, nil
+}
+
+func parsePaymaster(p *models.PaymasterPayload) (*smartaccount.Paymaster, error) {
+	if p == nil {
+		return nil, nil
+	}
+	if !common.IsHexAddress(p.Address) {
+		return nil, fmt.Errorf("invalid paymaster address %q", p.Address)
+	}
+	paymaster := &smartaccount.Paymaster{Address: common.HexToAddress(p.Address)}
+	var err error
+	if paymaster.PaymasterVerificationGasLimit, err = parseQuantity("verification_gas_limit", p.VerificationGasLimit); err != nil {
+		return nil, err
+	}
+	if paymaster.PaymasterPostOpGasLimit, err = parseQuantity("post_op_gas_limit", p.PostOpGasLimit); err != nil {
+		return nil, err
+	}
+	if p.Data != "" {
+		if paymaster.PaymasterData, err = hexutil.Decode(p.Data); err != nil {
+			return nil, fmt.Errorf("invalid paymaster data: %v", err)
+		}
+	}
+	return paymaster, nil
+}
+
+func userOperationToPayload(op *smartaccount.UserOperation) models.UserOperationPayload {
+	payload := models.UserOperationPayload{
+		Sender:               op.Sender.Hex(),
+		Nonce:                hexutil.EncodeBig(op.Nonce),
+		CallData:             hexutil.Encode(op.CallData),
+		CallGasLimit:         hexutil.EncodeBig(op.CallGasLimit),
+		VerificationGasLimit: hexutil.EncodeBig(op.VerificationGasLimit),
+		PreVerificationGas:   hexutil.EncodeBig(op.PreVerificationGas),
+		MaxFeePerGas:         hexutil.EncodeBig(op.MaxFeePerGas),
+		MaxPriorityFeePerGas: hexutil.EncodeBig(op.MaxPriorityFeePerGas),
+		Signature:            hexutil.Encode(op.Signature),
+	}
+	if op.Factory != nil {
+		payload.Factory = op.Factory.Hex()
+		payload.FactoryData = hexutil.Encode(op.FactoryData)
+	}
+	if p := op.Paymaster; p != nil {
+		payload.Paymaster = p.Address.Hex()
+		payload.PaymasterVerificationGasLimit = hexutil.EncodeBig(p.PaymasterVerificationGasLimit)
+		payload.PaymasterPostOpGasLimit = hexutil.EncodeBig(p.PaymasterPostOpGasLimit)
+		payload.PaymasterData = hexutil.Encode(p.PaymasterData)
+	}
+	return payload
+}
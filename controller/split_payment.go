@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"log"
+	"math/big"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+	"github.com/prikshit/blockchain-privacy-module/internal/privacy"
+	"github.com/prikshit/blockchain-privacy-module/models"
+)
+
+// Default number of outputs a split payment is spread over when the request leaves it open.
+const (
+	defaultMinSplitOutputs = 2
+	defaultMaxSplitOutputs = 5
+	maxSplitOutputs        = 20
+)
+
+// Plans a payment split across several stealth addresses of one meta-address (by Payer)
+func PlanSplitPayment(c *gin.Context, s *models.Server) {
+	log.Println("Received request to plan a split stealth payment")
+
+	var req models.SplitPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	meta, err := privacy.ParseStealthMetaAddress(req.MetaAddress)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	amount, ok := new(big.Int).SetString(req.Amount, 10)
+	if !ok || amount.BitLen() > 256 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Amount must be a base-10 integer in the token's smallest unit, below 2^256"})
+		return
+	}
+	token := privacy.NativeToken
+	if req.Token != "" {
+		if !common.IsHexAddress(req.Token) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token address"})
+			return
+		}
+		token = common.HexToAddress(req.Token)
+	}
+
+	opts := privacy.SplitOptions{MinOutputs: req.MinOutputs, MaxOutputs: req.MaxOutputs}
+	if opts.MinOutputs == 0 {
+		opts.MinOutputs = defaultMinSplitOutputs
+	}
+	if opts.MaxOutputs == 0 {
+		opts.MaxOutputs = max(opts.MinOutputs, defaultMaxSplitOutputs)
+	}
+	if opts.MaxOutputs > maxSplitOutputs {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Too many outputs requested"})
+		return
+	}
+
+	plan, err := s.PrivacyManager.PlanSplitPayment(meta, token, amount, opts)
+	if err != nil {
+		log.Println("Error planning split payment:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	outputs := make([]gin.H, len(plan.Outputs))
+	for i, out := range plan.Outputs {
+		outputs[i] = gin.H{
+			"stealth_pub_key": hexutil.Encode(crypto.FromECDSAPub(out.StealthPubKey)),
+			"announcement":    announcementToPayload(out.Announcement),
+			"transfer": gin.H{
+				"token":  out.Transfer.Token.Hex(),
+				"to":     out.Transfer.To.Hex(),
+				"amount": out.Transfer.Amount.String(),
+				"data":   hexutil.Encode(out.Transfer.Data),
+			},
+		}
+	}
+
+	log.Printf("Returning split payment plan with %d outputs", len(outputs))
+	c.JSON(http.StatusOK, gin.H{
+		"token":   plan.Token.Hex(),
+		"total":   plan.Total.String(),
+		"outputs": outputs,
+	})
+}
@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gin-gonic/gin"
+	"github.com/prikshit/blockchain-privacy-module/helpers"
+	"github.com/prikshit/blockchain-privacy-module/internal/threshold"
+	"github.com/prikshit/blockchain-privacy-module/models"
+)
+
+// Splits a Recipient's spending key into threshold shares (trusted dealer)
+func SplitSpendingKey(c *gin.Context) {
+	log.Println("Received request to split a spending key")
+
+	var req models.SplitKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	privKey, err := helpers.ParseECDSAPrivKey(req.PrivKey)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid private key"})
+		return
+	}
+
+	shares, err := threshold.SplitPrivateKey(privKey, req.Threshold, req.Parties)
+	if err != nil {
+		log.Println("Error splitting key:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payloads := make([]models.KeySharePayload, len(shares))
+	for i, share := range shares {
+		payloads[i] = keyShareToPayload(share)
+	}
+
+	log.Printf("Returning %d key shares", len(payloads))
+	c.JSON(http.StatusOK, gin.H{"shares": payloads})
+}
+
+// Computes one party's partial ECDH with the ephemeral public key
+func ComputePartialECDH(c *gin.Context) {
+	log.Println("Received request to compute a partial ECDH")
+
+	var req models.PartialECDHRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	share, err := parseKeyShare(req.Share)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ephemeralPub, err := crypto.UnmarshalPubkey(common.FromHex(req.EphemeralPubKey))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ephemeral public key"})
+		return
+	}
+
+	partial := share.PartialECDH(ephemeralPub)
+	c.JSON(http.StatusOK, gin.H{"partial": models.PartialECDHPayload{
+		Index: partial.Index,
+		Point: hexutil.Encode(crypto.FromECDSAPub(partial.Point)),
+	}})
+}
+
+// Computes one party's share of the stealth private key from the broadcast partial ECDH results
+func RecoverStealthKeyShare(c *gin.Context, s *models.Server) {
+	log.Println("Received request to recover a stealth key share")
+
+	var req models.StealthKeyShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	share, err := parseKeyShare(req.Share)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	partials := make([]*threshold.PartialECDH, 0, len(req.Partials))
+	for _, p := range req.Partials {
+		point, err := crypto.UnmarshalPubkey(common.FromHex(p.Point))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid partial from party %d", p.Index)})
+			return
+		}
+		partials = append(partials, &threshold.PartialECDH{Index: p.Index, Point: point})
+	}
+
+	stealthShare, err := s.PrivacyManager.RecoverStealthKeyShare(share, partials)
+	if err != nil {
+		log.Println("Error recovering stealth key share:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"stealth_share":   keyShareToPayload(stealthShare),
+		"stealth_address": crypto.PubkeyToAddress(*stealthShare.PublicKey).Hex(),
+	})
+}
+
+func parseKeyShare(p models.KeySharePayload) (*threshold.KeyShare, error) {
+	value, err := hexutil.DecodeBig(p.Value)
+	if err != nil || value.Cmp(crypto.S256().Params().N) >= 0 {
+		return nil, fmt.Errorf("invalid share value")
+	}
+	pub, err := crypto.UnmarshalPubkey(common.FromHex(p.PublicKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid share public key")
+	}
+	if p.Index < 1 || p.Threshold < 2 {
+		return nil, fmt.Errorf("invalid share index or threshold")
+	}
+	return &threshold.KeyShare{Index: p.Index, Threshold: p.Threshold, Value: value, PublicKey: pub}, nil
+}
+
+func keyShareToPayload(share *threshold.KeyShare) models.KeySharePayload {
+	return models.KeySharePayload{
+		Index:     share.Index,
+		Threshold: share.Threshold,
+		Value:     hexutil.EncodeBig(share.Value),
+		PublicKey: hexutil.Encode(crypto.FromECDSAPub(share.PublicKey)),
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"math/big"
 
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/crypto/secp256k1"
 )
 
@@ -57,3 +58,25 @@ func ParseECDSAPubKey(hexKey string) (*ecdsa.PublicKey, error) {
 
 	return pubKey, nil
 }
+
+// ParseECDSAPrivKey converts a "0x"-prefixed hex private key to an *ecdsa.PrivateKey on secp256k1.
+func ParseECDSAPrivKey(hexKey string) (*ecdsa.PrivateKey, error) {
+	if len(hexKey) < 2 || hexKey[:2] != "0x" {
+		err := fmt.Errorf("private key must start with '0x'")
+		log.Println("Error:", err)
+		return nil, err
+	}
+
+	privKeyBytes, err := hex.DecodeString(hexKey[2:])
+	if err != nil {
+		log.Printf("Failed to decode hex string: %v", err)
+		return nil, fmt.Errorf("failed to decode hex string: %v", err)
+	}
+
+	privKey, err := crypto.ToECDSA(privKeyBytes)
+	if err != nil {
+		log.Printf("Failed to parse private key: %v", err)
+		return nil, fmt.Errorf("invalid private key: %v", err)
+	}
+	return privKey, nil
+}
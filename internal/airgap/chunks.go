@@ -0,0 +1,137 @@
+package airgap
+
+import (
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"strconv"
+	"strings"
+)
+
+// DefaultChunkSize is the number of payload characters per chunk. With the header, each chunk fits a
+// version 10 QR code in alphanumeric mode at the lowest error correction level.
+const DefaultChunkSize = 300
+
+// chunkPrefix starts every chunk. Chunks only use the QR alphanumeric character set (upper case
+// letters, digits and " $%*+-./:"), which packs 5.5 bits per character instead of 8 in byte mode.
+const chunkPrefix = "STEALTH"
+
+// Chunk kinds
+const (
+	KindEnvelope = "ENV"
+	KindSigned   = "SIG"
+)
+
+var (
+	ErrInvalidChunk    = errors.New("invalid chunk")
+	ErrMismatchedChunk = errors.New("chunk belongs to a different payload")
+	ErrIncomplete      = errors.New("chunks are missing")
+	ErrChecksum        = errors.New("payload checksum mismatch")
+)
+
+// base32 without padding stays inside the QR alphanumeric set
+var chunkEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// EncodeChunks splits payload into chunks of at most size payload characters, formatted as
+// STEALTH:<kind>:<index>/<total>:<crc32>:<base32 data>. Chunks can be scanned in any order.
+func EncodeChunks(kind string, payload []byte, size int) []string {
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+	data := chunkEncoding.EncodeToString(payload)
+	total := (len(data) + size - 1) / size
+	if total == 0 {
+		total = 1
+	}
+	checksum := crc32.ChecksumIEEE(payload)
+
+	chunks := make([]string, 0, total)
+	for i := 0; i < total; i++ {
+		end := min((i+1)*size, len(data))
+		chunks = append(chunks, fmt.Sprintf("%s:%s:%d/%d:%08X:%s", chunkPrefix, kind, i+1, total, checksum, data[i*size:end]))
+	}
+	return chunks
+}
+
+// Decoder reassembles a payload from chunks scanned in any order. Repeated chunks are ignored.
+type Decoder struct {
+	kind     string
+	total    int
+	checksum uint32
+	parts    map[int]string
+}
+
+// NewDecoder returns a decoder accepting chunks of kind.
+func NewDecoder(kind string) *Decoder {
+	return &Decoder{kind: kind, parts: make(map[int]string)}
+}
+
+// Add records one chunk. The first chunk fixes the payload the others must belong to.
+func (d *Decoder) Add(chunk string) error {
+	fields := strings.Split(strings.TrimSpace(chunk), ":")
+	if len(fields) != 5 || fields[0] != chunkPrefix {
+		return ErrInvalidChunk
+	}
+	if fields[1] != d.kind {
+		return fmt.Errorf("%w: kind %s, expected %s", ErrMismatchedChunk, fields[1], d.kind)
+	}
+	indexText, totalText, ok := strings.Cut(fields[2], "/")
+	if !ok {
+		return ErrInvalidChunk
+	}
+	index, err1 := strconv.Atoi(indexText)
+	total, err2 := strconv.Atoi(totalText)
+	checksum, err3 := strconv.ParseUint(fields[3], 16, 32)
+	if err1 != nil || err2 != nil || err3 != nil || index < 1 || index > total {
+		return ErrInvalidChunk
+	}
+
+	if len(d.parts) == 0 {
+		d.total, d.checksum = total, uint32(checksum)
+	} else if total != d.total || uint32(checksum) != d.checksum {
+		return ErrMismatchedChunk
+	}
+	d.parts[index] = fields[4]
+	return nil
+}
+
+// Progress returns how many of the chunks have been scanned; total is 0 before the first chunk.
+func (d *Decoder) Progress() (scanned, total int) {
+	return len(d.parts), d.total
+}
+
+// Complete reports whether every chunk has been scanned.
+func (d *Decoder) Complete() bool {
+	return d.total > 0 && len(d.parts) == d.total
+}
+
+// Payload returns the reassembled payload once every chunk has been scanned.
+func (d *Decoder) Payload() ([]byte, error) {
+	if !d.Complete() {
+		return nil, fmt.Errorf("%w: have %d of %d", ErrIncomplete, len(d.parts), d.total)
+	}
+	var data strings.Builder
+	for i := 1; i <= d.total; i++ {
+		data.WriteString(d.parts[i])
+	}
+	payload, err := chunkEncoding.DecodeString(data.String())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidChunk, err)
+	}
+	if crc32.ChecksumIEEE(payload) != d.checksum {
+		return nil, ErrChecksum
+	}
+	return payload, nil
+}
+
+// DecodeChunks reassembles a payload of kind from a complete set of chunks.
+func DecodeChunks(kind string, chunks []string) ([]byte, error) {
+	d := NewDecoder(kind)
+	for _, chunk := range chunks {
+		if err := d.Add(chunk); err != nil {
+			return nil, err
+		}
+	}
+	return d.Payload()
+}
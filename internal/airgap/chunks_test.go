@@ -0,0 +1,67 @@
+package airgap
+
+import (
+	"crypto/rand"
+	mrand "math/rand"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// qrAlphanumeric matches strings a QR code can hold in alphanumeric mode.
+var qrAlphanumeric = regexp.MustCompile(`^[0-9A-Z $%*+\-./:]*$`)
+
+func TestChunksRoundTripInAnyOrder(t *testing.T) {
+	payload := make([]byte, 1500)
+	_, err := rand.Read(payload)
+	require.NoError(t, err)
+
+	chunks := EncodeChunks(KindEnvelope, payload, 200)
+	require.Len(t, chunks, 12)
+	for _, chunk := range chunks {
+		assert.Regexp(t, qrAlphanumeric, chunk)
+	}
+
+	mrand.Shuffle(len(chunks), func(i, j int) { chunks[i], chunks[j] = chunks[j], chunks[i] })
+	d := NewDecoder(KindEnvelope)
+	for i, chunk := range chunks {
+		require.NoError(t, d.Add(chunk))
+		require.NoError(t, d.Add(chunk)) // Scanning a code twice is harmless
+		scanned, total := d.Progress()
+		assert.Equal(t, i+1, scanned)
+		assert.Equal(t, len(chunks), total)
+	}
+	decoded, err := d.Payload()
+	require.NoError(t, err)
+	assert.Equal(t, payload, decoded)
+}
+
+func TestDecoderRejectsBadChunks(t *testing.T) {
+	chunks := EncodeChunks(KindEnvelope, []byte("withdrawal envelope payload"), 8)
+
+	_, err := DecodeChunks(KindEnvelope, chunks[1:])
+	assert.ErrorIs(t, err, ErrIncomplete)
+
+	_, err = DecodeChunks(KindSigned, chunks)
+	assert.ErrorIs(t, err, ErrMismatchedChunk)
+
+	other := EncodeChunks(KindEnvelope, []byte("another envelope payload!!!"), 8)
+	_, err = DecodeChunks(KindEnvelope, append([]string{other[0]}, chunks[1:]...))
+	assert.ErrorIs(t, err, ErrMismatchedChunk)
+
+	// Flip one data character
+	tampered := append([]string{}, chunks...)
+	last := tampered[1]
+	replacement := byte('A')
+	if last[len(last)-1] == 'A' {
+		replacement = 'B'
+	}
+	tampered[1] = last[:len(last)-1] + string(replacement)
+	_, err = DecodeChunks(KindEnvelope, tampered)
+	assert.Error(t, err)
+
+	assert.ErrorIs(t, NewDecoder(KindEnvelope).Add("not a chunk"), ErrInvalidChunk)
+	assert.ErrorIs(t, NewDecoder(KindEnvelope).Add("STEALTH:ENV:3/2:00000000:AA"), ErrInvalidChunk)
+}
@@ -0,0 +1,225 @@
+package airgap
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/mlkem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/prikshit/blockchain-privacy-module/internal/chain"
+	"github.com/prikshit/blockchain-privacy-module/internal/privacy"
+)
+
+// Version is the envelope format version.
+const Version = 1
+
+var (
+	ErrUnsupportedVersion = errors.New("unsupported envelope version")
+	ErrSenderMismatch     = errors.New("transaction is not signed by the stealth address")
+)
+
+// Envelope is an unsigned withdrawal from a stealth address. The online side builds it from the
+// announcement and the chain state; the offline side derives the stealth key from the announcement
+// and signs the transaction skeleton without ever going online.
+type Envelope struct {
+	Version         uint
+	ChainID         *big.Int
+	StealthAddress  common.Address
+	EphemeralPubKey []byte // Compressed
+	Metadata        []byte
+	Label           string // Label of the meta-address the payment was made to, empty for none
+
+	// Transaction skeleton
+	Nonce     uint64
+	To        common.Address
+	Value     *big.Int
+	Data      []byte
+	Gas       uint64
+	GasTipCap *big.Int
+	GasFeeCap *big.Int
+}
+
+// SignedTransaction is what the offline side hands back for broadcast.
+type SignedTransaction struct {
+	Version        uint
+	StealthAddress common.Address
+	RawTx          []byte
+}
+
+// NewEnvelope prepares a withdrawal of value (with data) from the announced stealth address to to,
+// filling in the nonce, gas and fees from the chain.
+func NewEnvelope(ctx context.Context, backend chain.Backend, ann *privacy.Announcement, label string, to common.Address, value *big.Int, data []byte) (*Envelope, error) {
+	log.Printf("Preparing air-gapped withdrawal from %s\n", ann.StealthAddress.Hex())
+
+	chainID, err := backend.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reading chain ID: %w", err)
+	}
+	skeleton, err := chain.Skeleton(ctx, backend, ann.StealthAddress, to, value, data, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{
+		Version:         Version,
+		ChainID:         chainID,
+		StealthAddress:  ann.StealthAddress,
+		EphemeralPubKey: crypto.CompressPubkey(ann.EphemeralPubKey),
+		Metadata:        ann.Metadata,
+		Label:           label,
+		Nonce:           skeleton.Nonce,
+		To:              to,
+		Value:           skeleton.Value,
+		Data:            skeleton.Data,
+		Gas:             skeleton.Gas,
+		GasTipCap:       skeleton.GasTipCap,
+		GasFeeCap:       skeleton.GasFeeCap,
+	}, nil
+}
+
+// Announcement returns the announcement the envelope withdraws from.
+func (e *Envelope) Announcement() (*privacy.Announcement, error) {
+	ephemeralPub, err := crypto.DecompressPubkey(e.EphemeralPubKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ephemeral public key: %w", err)
+	}
+	return &privacy.Announcement{StealthAddress: e.StealthAddress, EphemeralPubKey: ephemeralPub, Metadata: e.Metadata}, nil
+}
+
+// Chunks encodes the envelope as QR-friendly chunks of at most size characters of payload.
+func (e *Envelope) Chunks(size int) ([]string, error) {
+	payload, err := rlp.EncodeToBytes(e)
+	if err != nil {
+		return nil, err
+	}
+	return EncodeChunks(KindEnvelope, payload, size), nil
+}
+
+// DecodeEnvelope reassembles an envelope from its chunks.
+func DecodeEnvelope(chunks []string) (*Envelope, error) {
+	payload, err := DecodeChunks(KindEnvelope, chunks)
+	if err != nil {
+		return nil, err
+	}
+	var e Envelope
+	if err := rlp.DecodeBytes(payload, &e); err != nil {
+		return nil, fmt.Errorf("decoding envelope: %w", err)
+	}
+	if e.Version != Version {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, e.Version)
+	}
+	return &e, nil
+}
+
+// Signer is the offline side. It holds the recipient's keys and never needs a network connection.
+type Signer struct {
+	PrivacyManager *privacy.PrivacyManager
+	SpendingKey    *ecdsa.PrivateKey
+	ViewingKey     *ecdsa.PrivateKey
+	KEMKey         *mlkem.DecapsulationKey768 // Only needed for hybrid payments
+}
+
+// Sign derives the stealth key for the envelope's announcement and signs the transaction skeleton.
+func (s *Signer) Sign(e *Envelope) (*SignedTransaction, error) {
+	ann, err := e.Announcement()
+	if err != nil {
+		return nil, err
+	}
+
+	var stealthKey *ecdsa.PrivateKey
+	if s.KEMKey != nil && len(ann.Metadata) >= privacy.HybridMetadataSize {
+		stealthKey, err = s.PrivacyManager.RecoverHybridStealthPrivateKey(&privacy.HybridKeys{
+			SpendingKey: s.SpendingKey,
+			ViewingKey:  s.ViewingKey,
+			KEMKey:      s.KEMKey,
+		}, ann)
+	} else {
+		stealthKey, err = s.PrivacyManager.RecoverLabelledStealthPrivateKey(&privacy.StealthKeys{
+			SpendingKey: s.SpendingKey,
+			ViewingKey:  s.ViewingKey,
+		}, e.Label, ann)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	to := e.To
+	tx, err := types.SignNewTx(stealthKey, types.LatestSignerForChainID(e.ChainID), &types.DynamicFeeTx{
+		ChainID:   e.ChainID,
+		Nonce:     e.Nonce,
+		GasTipCap: e.GasTipCap,
+		GasFeeCap: e.GasFeeCap,
+		Gas:       e.Gas,
+		To:        &to,
+		Value:     e.Value,
+		Data:      e.Data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Signed withdrawal %s from %s offline\n", tx.Hash().Hex(), e.StealthAddress.Hex())
+	return &SignedTransaction{Version: Version, StealthAddress: e.StealthAddress, RawTx: raw}, nil
+}
+
+// Chunks encodes the signed transaction as QR-friendly chunks of at most size characters of payload.
+func (s *SignedTransaction) Chunks(size int) ([]string, error) {
+	payload, err := rlp.EncodeToBytes(s)
+	if err != nil {
+		return nil, err
+	}
+	return EncodeChunks(KindSigned, payload, size), nil
+}
+
+// DecodeSignedTransaction reassembles a signed transaction from its chunks.
+func DecodeSignedTransaction(chunks []string) (*SignedTransaction, error) {
+	payload, err := DecodeChunks(KindSigned, chunks)
+	if err != nil {
+		return nil, err
+	}
+	var s SignedTransaction
+	if err := rlp.DecodeBytes(payload, &s); err != nil {
+		return nil, fmt.Errorf("decoding signed transaction: %w", err)
+	}
+	if s.Version != Version {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, s.Version)
+	}
+	return &s, nil
+}
+
+// Transaction decodes the signed transaction and checks that the stealth address signed it.
+func (s *SignedTransaction) Transaction() (*types.Transaction, error) {
+	var tx types.Transaction
+	if err := tx.UnmarshalBinary(s.RawTx); err != nil {
+		return nil, fmt.Errorf("decoding transaction: %w", err)
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), &tx)
+	if err != nil || from != s.StealthAddress {
+		return nil, ErrSenderMismatch
+	}
+	return &tx, nil
+}
+
+// Broadcast submits the signed transaction. This is the online side's last step.
+func Broadcast(ctx context.Context, backend ethereum.TransactionSender, s *SignedTransaction) (*types.Transaction, error) {
+	tx, err := s.Transaction()
+	if err != nil {
+		return nil, err
+	}
+	if err := backend.SendTransaction(ctx, tx); err != nil {
+		return nil, fmt.Errorf("sending transaction: %w", err)
+	}
+	log.Printf("Broadcast withdrawal %s from %s\n", tx.Hash().Hex(), s.StealthAddress.Hex())
+	return tx, nil
+}
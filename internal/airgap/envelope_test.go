@@ -0,0 +1,118 @@
+package airgap
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/prikshit/blockchain-privacy-module/internal/privacy"
+	"github.com/prikshit/blockchain-privacy-module/internal/sanctions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var destination = common.HexToAddress("0x000000000000000000000000000000000000de57")
+
+func TestOfflineSigningRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	pm := privacy.NewPrivacyManager(sanctions.NewDetector(nil))
+	spendingKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	viewingKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	keys := &privacy.StealthKeys{SpendingKey: spendingKey, ViewingKey: viewingKey}
+
+	// The payer pays a labelled meta-address
+	_, ann, err := pm.GenerateStealthPayment(keys.LabelledMetaAddress("cold"))
+	require.NoError(t, err)
+	backend := simulated.NewBackend(types.GenesisAlloc{ann.StealthAddress: {Balance: big.NewInt(params.Ether)}})
+	t.Cleanup(func() { backend.Close() })
+
+	// Online: build the envelope and show it as QR codes
+	amount := big.NewInt(params.Ether / 2)
+	env, err := NewEnvelope(ctx, backend.Client(), ann, "cold", destination, amount, nil)
+	require.NoError(t, err)
+	envChunks, err := env.Chunks(DefaultChunkSize)
+	require.NoError(t, err)
+
+	// Offline: scan, derive the stealth key and sign
+	scanned, err := DecodeEnvelope(envChunks)
+	require.NoError(t, err)
+	assert.Equal(t, env.StealthAddress, scanned.StealthAddress)
+	assert.Equal(t, "cold", scanned.Label)
+	assert.Equal(t, amount, scanned.Value)
+	signer := &Signer{PrivacyManager: privacy.NewPrivacyManager(sanctions.NewDetector(nil)), SpendingKey: spendingKey, ViewingKey: viewingKey}
+	signed, err := signer.Sign(scanned)
+	require.NoError(t, err)
+	sigChunks, err := signed.Chunks(DefaultChunkSize)
+	require.NoError(t, err)
+
+	// Online: scan the signed transaction and broadcast it
+	received, err := DecodeSignedTransaction(sigChunks)
+	require.NoError(t, err)
+	tx, err := Broadcast(ctx, backend.Client(), received)
+	require.NoError(t, err)
+	backend.Commit()
+
+	receipt, err := backend.Client().TransactionReceipt(ctx, tx.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	balance, err := backend.Client().BalanceAt(ctx, destination, nil)
+	require.NoError(t, err)
+	assert.Equal(t, amount, balance)
+}
+
+func TestSignRejectsForeignKeys(t *testing.T) {
+	pm := privacy.NewPrivacyManager(sanctions.NewDetector(nil))
+	spendingKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	viewingKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	keys := &privacy.StealthKeys{SpendingKey: spendingKey, ViewingKey: viewingKey}
+	_, ann, err := pm.GenerateStealthPayment(keys.MetaAddress())
+	require.NoError(t, err)
+
+	env := &Envelope{
+		Version:         Version,
+		ChainID:         big.NewInt(1),
+		StealthAddress:  ann.StealthAddress,
+		EphemeralPubKey: crypto.CompressPubkey(ann.EphemeralPubKey),
+		Metadata:        ann.Metadata,
+		To:              destination,
+		Value:           big.NewInt(1),
+		Gas:             params.TxGas,
+		GasTipCap:       big.NewInt(1),
+		GasFeeCap:       big.NewInt(2),
+	}
+	otherKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signer := &Signer{PrivacyManager: pm, SpendingKey: otherKey, ViewingKey: viewingKey}
+	_, err = signer.Sign(env)
+	assert.ErrorIs(t, err, privacy.ErrAnnouncementMismatch)
+}
+
+func TestSignedTransactionMustComeFromStealthAddress(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(big.NewInt(1)), &types.DynamicFeeTx{
+		ChainID: big.NewInt(1), To: &destination, Value: big.NewInt(1), Gas: params.TxGas,
+		GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2),
+	})
+	require.NoError(t, err)
+	raw, err := tx.MarshalBinary()
+	require.NoError(t, err)
+
+	signed := &SignedTransaction{Version: Version, StealthAddress: destination, RawTx: raw}
+	_, err = signed.Transaction()
+	assert.ErrorIs(t, err, ErrSenderMismatch)
+
+	signed.StealthAddress = crypto.PubkeyToAddress(key.PublicKey)
+	decoded, err := signed.Transaction()
+	require.NoError(t, err)
+	assert.Equal(t, tx.Hash(), decoded.Hash())
+}
@@ -0,0 +1,38 @@
+package airgap
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Selectors of the ERC-20 calls whose recipient can be screened.
+var (
+	transferSelector     = []byte{0xa9, 0x05, 0x9c, 0xbb} // transfer(address,uint256)
+	transferFromSelector = []byte{0x23, 0xb8, 0x72, 0xdd} // transferFrom(address,address,uint256)
+)
+
+var ErrUnscreenableCall = errors.New("withdrawal calldata is not a plain ERC-20 transfer")
+
+// Recipients returns the addresses a withdrawal to with data pays: to itself for an ETH transfer, and
+// also the recipient for an ERC-20 transfer or transferFrom on token to. Any other call could pay anyone,
+// so it is refused with ErrUnscreenableCall.
+func Recipients(to common.Address, data []byte) ([]common.Address, error) {
+	if len(data) == 0 {
+		return []common.Address{to}, nil
+	}
+	var word []byte
+	switch {
+	case bytes.HasPrefix(data, transferSelector) && len(data) == 4+2*32:
+		word = data[4 : 4+32]
+	case bytes.HasPrefix(data, transferFromSelector) && len(data) == 4+3*32:
+		word = data[4+32 : 4+2*32]
+	default:
+		return nil, ErrUnscreenableCall
+	}
+	if !bytes.Equal(word[:12], make([]byte, 12)) {
+		return nil, ErrUnscreenableCall
+	}
+	return []common.Address{to, common.BytesToAddress(word[12:])}, nil
+}
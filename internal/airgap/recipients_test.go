@@ -0,0 +1,41 @@
+package airgap
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecipients(t *testing.T) {
+	token := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	owner := common.HexToAddress("0x722122dF12D4e14e13Ac3b6895a86e84145b6967")
+	recipient := common.HexToAddress("0x8589427373D6D84E98730D7795D8f6f8731FDA16")
+	amount := common.LeftPadBytes([]byte{1}, 32)
+
+	recipients, err := Recipients(recipient, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []common.Address{recipient}, recipients)
+
+	transfer := append(append(common.CopyBytes(transferSelector), common.LeftPadBytes(recipient.Bytes(), 32)...), amount...)
+	recipients, err = Recipients(token, transfer)
+	require.NoError(t, err)
+	assert.Equal(t, []common.Address{token, recipient}, recipients)
+
+	transferFrom := append(common.CopyBytes(transferFromSelector), common.LeftPadBytes(owner.Bytes(), 32)...)
+	transferFrom = append(append(transferFrom, common.LeftPadBytes(recipient.Bytes(), 32)...), amount...)
+	recipients, err = Recipients(token, transferFrom)
+	require.NoError(t, err)
+	assert.Equal(t, []common.Address{token, recipient}, recipients)
+
+	// Any other call, or a malformed transfer, cannot be screened
+	_, err = Recipients(token, []byte{0x09, 0x5e, 0xa7, 0xb3})
+	assert.ErrorIs(t, err, ErrUnscreenableCall)
+	_, err = Recipients(token, transfer[:len(transfer)-1])
+	assert.ErrorIs(t, err, ErrUnscreenableCall)
+	dirty := common.CopyBytes(transfer)
+	dirty[4] = 1
+	_, err = Recipients(token, dirty)
+	assert.ErrorIs(t, err, ErrUnscreenableCall)
+}
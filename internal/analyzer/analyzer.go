@@ -0,0 +1,309 @@
+package analyzer
+
+import (
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Severity levels, from most to least urgent.
+const (
+	SeverityHigh   = "high"
+	SeverityMedium = "medium"
+	SeverityLow    = "low"
+)
+
+// Finding codes.
+const (
+	LeakEphemeralKeyReuse = "ephemeral_key_reuse"
+	LeakFundedFromMain    = "funded_from_main_address"
+	LeakGasTopUp          = "gas_top_up"
+	LeakLinkableGasTopUp  = "linkable_gas_top_up"
+	LeakPromptSweep       = "prompt_sweep"
+	LeakCorrelatedSweeps  = "correlated_sweeps"
+)
+
+// Default analysis thresholds.
+const (
+	DefaultSweepWindow = 25                     // Blocks (~5 minutes)
+	DefaultGasTopUpWei = 10_000_000_000_000_000 // 0.01 ETH
+)
+
+// Payment is one entry of the stealth payment history.
+type Payment struct {
+	MetaAddress     string         `json:"meta_address"`
+	StealthAddress  common.Address `json:"stealth_address"`
+	EphemeralPubKey hexutil.Bytes  `json:"ephemeral_pub_key"`
+	Block           uint64         `json:"block"`
+	Timestamp       time.Time      `json:"timestamp"`
+}
+
+// Transaction is one plain value transfer from the local transaction dataset.
+type Transaction struct {
+	Hash      common.Hash    `json:"hash"`
+	From      common.Address `json:"from"`
+	To        common.Address `json:"to"`
+	Value     *big.Int       `json:"value"`
+	Block     uint64         `json:"block"`
+	Timestamp time.Time      `json:"timestamp"`
+}
+
+// Dataset is the input to the analyzer. MainAddresses maps a meta-address to the recipient's public addresses.
+type Dataset struct {
+	Payments      []Payment                   `json:"payments"`
+	Transactions  []Transaction               `json:"transactions"`
+	MainAddresses map[string][]common.Address `json:"main_addresses"`
+}
+
+// Options tunes the heuristics.
+type Options struct {
+	SweepWindow uint64   // Blocks within which a sweep is considered correlated
+	GasTopUpMax *big.Int // Largest ETH transfer treated as a gas top-up
+}
+
+// Finding is one detected leak.
+type Finding struct {
+	Severity         string           `json:"severity"`
+	Code             string           `json:"code"`
+	Message          string           `json:"message"`
+	StealthAddresses []common.Address `json:"stealth_addresses,omitempty"`
+	Transactions     []common.Hash    `json:"transactions,omitempty"`
+}
+
+// Report lists the leaks found for one meta-address.
+type Report struct {
+	MetaAddress string         `json:"meta_address"`
+	Payments    int            `json:"payments"`
+	Summary     map[string]int `json:"summary"`
+	Findings    []Finding      `json:"findings"`
+}
+
+// Analyze inspects the payment history against the transaction dataset and returns one report per
+// meta-address, sorted by meta-address, with findings ordered by severity.
+func Analyze(data *Dataset, opts Options) []*Report {
+	if opts.SweepWindow == 0 {
+		opts.SweepWindow = DefaultSweepWindow
+	}
+	if opts.GasTopUpMax == nil {
+		opts.GasTopUpMax = big.NewInt(DefaultGasTopUpWei)
+	}
+	log.Printf("Analyzing %d payments against %d transactions\n", len(data.Payments), len(data.Transactions))
+
+	byMeta := make(map[string][]Payment)
+	for _, p := range data.Payments {
+		byMeta[p.MetaAddress] = append(byMeta[p.MetaAddress], p)
+	}
+
+	metas := make([]string, 0, len(byMeta))
+	for meta := range byMeta {
+		metas = append(metas, meta)
+	}
+	sort.Strings(metas)
+
+	reports := make([]*Report, 0, len(metas))
+	for _, meta := range metas {
+		payments := byMeta[meta]
+		a := &metaAnalysis{
+			payments: payments,
+			stealth:  make(map[common.Address]Payment, len(payments)),
+			main:     make(map[common.Address]bool),
+			opts:     opts,
+		}
+		for _, p := range payments {
+			a.stealth[p.StealthAddress] = p
+		}
+		for _, m := range data.MainAddresses[meta] {
+			a.main[m] = true
+		}
+
+		a.ephemeralReuse()
+		a.funding(data.Transactions)
+		a.sweeps(data.Transactions)
+
+		sort.SliceStable(a.findings, func(i, j int) bool {
+			return severityRank(a.findings[i].Severity) < severityRank(a.findings[j].Severity)
+		})
+		summary := map[string]int{SeverityHigh: 0, SeverityMedium: 0, SeverityLow: 0}
+		for _, f := range a.findings {
+			summary[f.Severity]++
+		}
+		reports = append(reports, &Report{MetaAddress: meta, Payments: len(payments), Summary: summary, Findings: a.findings})
+	}
+	return reports
+}
+
+type metaAnalysis struct {
+	payments []Payment
+	stealth  map[common.Address]Payment
+	main     map[common.Address]bool
+	opts     Options
+	findings []Finding
+}
+
+func (a *metaAnalysis) add(f Finding) {
+	a.findings = append(a.findings, f)
+}
+
+// ephemeralReuse flags ephemeral public keys announced more than once to the recipient; reuse lets observers
+// link the payments. Only the recipient's own payments are counted, so a report never names another
+// recipient's stealth addresses. Payments without an ephemeral key are skipped.
+func (a *metaAnalysis) ephemeralReuse() {
+	uses := make(map[string][]Payment)
+	for _, p := range a.payments {
+		if len(p.EphemeralPubKey) > 0 {
+			uses[string(p.EphemeralPubKey)] = append(uses[string(p.EphemeralPubKey)], p)
+		}
+	}
+	reported := make(map[string]bool)
+	for _, p := range a.payments {
+		key := string(p.EphemeralPubKey)
+		if len(uses[key]) < 2 || reported[key] {
+			continue
+		}
+		reported[key] = true
+		var addresses []common.Address
+		for _, u := range uses[key] {
+			addresses = append(addresses, u.StealthAddress)
+		}
+		a.add(Finding{
+			Severity:         SeverityHigh,
+			Code:             LeakEphemeralKeyReuse,
+			Message:          fmt.Sprintf("ephemeral key %s was announced %d times", hexutil.Encode(p.EphemeralPubKey), len(uses[key])),
+			StealthAddresses: addresses,
+		})
+	}
+}
+
+// funding flags stealth addresses funded by the recipient's main address and gas top-ups.
+// A top-up links its funder to the stealth address; a funder that tops up several of the
+// recipient's stealth addresses links them to each other, and one that is itself a stealth
+// address of the recipient links two payments outright.
+func (a *metaAnalysis) funding(txs []Transaction) {
+	topUps := make(map[common.Address][]Transaction)
+	for _, tx := range txs {
+		if _, ok := a.stealth[tx.To]; !ok {
+			continue
+		}
+		if a.main[tx.From] {
+			a.add(Finding{
+				Severity:         SeverityHigh,
+				Code:             LeakFundedFromMain,
+				Message:          fmt.Sprintf("stealth address %s was funded by main address %s", tx.To.Hex(), tx.From.Hex()),
+				StealthAddresses: []common.Address{tx.To},
+				Transactions:     []common.Hash{tx.Hash},
+			})
+			continue
+		}
+		if tx.Value != nil && tx.Value.Sign() > 0 && tx.Value.Cmp(a.opts.GasTopUpMax) <= 0 {
+			topUps[tx.From] = append(topUps[tx.From], tx)
+		}
+	}
+
+	funders := make([]common.Address, 0, len(topUps))
+	for funder := range topUps {
+		funders = append(funders, funder)
+	}
+	sort.Slice(funders, func(i, j int) bool { return funders[i].Cmp(funders[j]) < 0 })
+
+	for _, funder := range funders {
+		var addresses []common.Address
+		var hashes []common.Hash
+		seen := make(map[common.Address]bool)
+		for _, tx := range topUps[funder] {
+			if !seen[tx.To] {
+				seen[tx.To] = true
+				addresses = append(addresses, tx.To)
+			}
+			hashes = append(hashes, tx.Hash)
+		}
+
+		finding := Finding{StealthAddresses: addresses, Transactions: hashes}
+		_, fromStealth := a.stealth[funder]
+		switch {
+		case fromStealth:
+			finding.Severity = SeverityHigh
+			finding.Code = LeakLinkableGasTopUp
+			finding.Message = fmt.Sprintf("stealth address %s topped up gas on %d other stealth addresses", funder.Hex(), len(addresses))
+			finding.StealthAddresses = append([]common.Address{funder}, addresses...)
+		case len(addresses) > 1:
+			finding.Severity = SeverityMedium
+			finding.Code = LeakLinkableGasTopUp
+			finding.Message = fmt.Sprintf("%s topped up gas on %d stealth addresses, linking them", funder.Hex(), len(addresses))
+		default:
+			finding.Severity = SeverityLow
+			finding.Code = LeakGasTopUp
+			finding.Message = fmt.Sprintf("%s topped up gas on stealth address %s", funder.Hex(), addresses[0].Hex())
+		}
+		a.add(finding)
+	}
+}
+
+// sweeps flags withdrawals made soon after the announcement and withdrawals from several stealth
+// addresses close together in time.
+func (a *metaAnalysis) sweeps(txs []Transaction) {
+	var outgoing []Transaction
+	for _, tx := range txs {
+		payment, ok := a.stealth[tx.From]
+		if !ok {
+			continue
+		}
+		outgoing = append(outgoing, tx)
+		if tx.Block >= payment.Block && tx.Block-payment.Block <= a.opts.SweepWindow {
+			a.add(Finding{
+				Severity:         SeverityMedium,
+				Code:             LeakPromptSweep,
+				Message:          fmt.Sprintf("stealth address %s was swept %d blocks after its announcement", tx.From.Hex(), tx.Block-payment.Block),
+				StealthAddresses: []common.Address{tx.From},
+				Transactions:     []common.Hash{tx.Hash},
+			})
+		}
+	}
+
+	// Cluster sweeps whose blocks lie within the window of each other
+	sort.Slice(outgoing, func(i, j int) bool { return outgoing[i].Block < outgoing[j].Block })
+	for start := 0; start < len(outgoing); {
+		end := start + 1
+		for end < len(outgoing) && outgoing[end].Block-outgoing[end-1].Block <= a.opts.SweepWindow {
+			end++
+		}
+		cluster := outgoing[start:end]
+		start = end
+
+		var addresses []common.Address
+		var hashes []common.Hash
+		seen := make(map[common.Address]bool)
+		for _, tx := range cluster {
+			if !seen[tx.From] {
+				seen[tx.From] = true
+				addresses = append(addresses, tx.From)
+			}
+			hashes = append(hashes, tx.Hash)
+		}
+		if len(addresses) < 2 {
+			continue
+		}
+		a.add(Finding{
+			Severity:         SeverityHigh,
+			Code:             LeakCorrelatedSweeps,
+			Message:          fmt.Sprintf("%d stealth addresses were swept within blocks %d-%d", len(addresses), cluster[0].Block, cluster[len(cluster)-1].Block),
+			StealthAddresses: addresses,
+			Transactions:     hashes,
+		})
+	}
+}
+
+func severityRank(severity string) int {
+	switch severity {
+	case SeverityHigh:
+		return 0
+	case SeverityMedium:
+		return 1
+	default:
+		return 2
+	}
+}
@@ -0,0 +1,131 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	metaA = "st:eth:0xaaaa"
+	metaB = "st:eth:0xbbbb"
+)
+
+var (
+	mainA    = common.HexToAddress("0x00000000000000000000000000000000000000a0")
+	funder   = common.HexToAddress("0x00000000000000000000000000000000000000f0")
+	stranger = common.HexToAddress("0x00000000000000000000000000000000000000f1")
+	wallet   = common.HexToAddress("0x00000000000000000000000000000000000000d0")
+)
+
+func stealthAddr(i byte) common.Address {
+	return common.BytesToAddress([]byte{0x5e, i})
+}
+
+func codes(r *Report) []string {
+	var out []string
+	for _, f := range r.Findings {
+		out = append(out, f.Code)
+	}
+	return out
+}
+
+func TestAnalyzeReportsLeaks(t *testing.T) {
+	data := &Dataset{
+		Payments: []Payment{
+			{MetaAddress: metaA, StealthAddress: stealthAddr(1), EphemeralPubKey: []byte{0x02, 0x01}, Block: 100},
+			{MetaAddress: metaA, StealthAddress: stealthAddr(2), EphemeralPubKey: []byte{0x02, 0x02}, Block: 200},
+			// Reuses the ephemeral key of the first payment
+			{MetaAddress: metaA, StealthAddress: stealthAddr(3), EphemeralPubKey: []byte{0x02, 0x01}, Block: 300},
+			// Another recipient's payments are not reported to this one, and missing keys are not reuse
+			{MetaAddress: metaB, StealthAddress: stealthAddr(4), EphemeralPubKey: []byte{0x02, 0x01}, Block: 400},
+			{MetaAddress: metaB, StealthAddress: stealthAddr(5), Block: 500},
+			{MetaAddress: metaB, StealthAddress: stealthAddr(6), Block: 600},
+		},
+		Transactions: []Transaction{
+			{Hash: common.Hash{1}, From: mainA, To: stealthAddr(1), Value: big.NewInt(1e18), Block: 101},
+			{Hash: common.Hash{2}, From: funder, To: stealthAddr(2), Value: big.NewInt(1e15), Block: 210},
+			{Hash: common.Hash{3}, From: funder, To: stealthAddr(3), Value: big.NewInt(1e15), Block: 310},
+			// Swept right after the announcement, then a second address in the same window
+			{Hash: common.Hash{4}, From: stealthAddr(2), To: wallet, Value: big.NewInt(1e17), Block: 5000},
+			{Hash: common.Hash{5}, From: stealthAddr(3), To: wallet, Value: big.NewInt(1e17), Block: 5010},
+			{Hash: common.Hash{6}, From: stealthAddr(1), To: wallet, Value: big.NewInt(1e17), Block: 110},
+			{Hash: common.Hash{7}, From: stranger, To: stealthAddr(4), Value: big.NewInt(1e15), Block: 401},
+		},
+		MainAddresses: map[string][]common.Address{metaA: {mainA}},
+	}
+
+	reports := Analyze(data, Options{})
+	assert.Len(t, reports, 2)
+
+	a := reports[0]
+	assert.Equal(t, metaA, a.MetaAddress)
+	assert.Equal(t, 3, a.Payments)
+	assert.ElementsMatch(t, []string{
+		LeakEphemeralKeyReuse, LeakFundedFromMain, LeakCorrelatedSweeps,
+		LeakLinkableGasTopUp, LeakPromptSweep,
+	}, codes(a))
+	assert.Equal(t, 3, a.Summary[SeverityHigh])
+	assert.Equal(t, 2, a.Summary[SeverityMedium])
+	assert.Equal(t, SeverityHigh, a.Findings[0].Severity)
+
+	for _, f := range a.Findings {
+		switch f.Code {
+		case LeakEphemeralKeyReuse:
+			assert.Equal(t, []common.Address{stealthAddr(1), stealthAddr(3)}, f.StealthAddresses)
+		case LeakLinkableGasTopUp:
+			assert.Equal(t, []common.Address{stealthAddr(2), stealthAddr(3)}, f.StealthAddresses)
+		case LeakCorrelatedSweeps:
+			assert.Equal(t, []common.Hash{{4}, {5}}, f.Transactions)
+		case LeakPromptSweep:
+			assert.Equal(t, []common.Address{stealthAddr(1)}, f.StealthAddresses)
+		}
+	}
+
+	b := reports[1]
+	assert.Equal(t, metaB, b.MetaAddress)
+	assert.Equal(t, 3, b.Payments)
+	assert.ElementsMatch(t, []string{LeakGasTopUp}, codes(b))
+	assert.Equal(t, 1, b.Summary[SeverityLow])
+}
+
+func TestAnalyzeCleanHistory(t *testing.T) {
+	data := &Dataset{
+		Payments: []Payment{
+			{MetaAddress: metaA, StealthAddress: stealthAddr(1), EphemeralPubKey: []byte{0x02, 0x01}, Block: 100},
+			{MetaAddress: metaA, StealthAddress: stealthAddr(2), EphemeralPubKey: []byte{0x02, 0x02}, Block: 200},
+		},
+		Transactions: []Transaction{
+			{Hash: common.Hash{1}, From: stealthAddr(1), To: wallet, Value: big.NewInt(1e17), Block: 1000},
+			{Hash: common.Hash{2}, From: stealthAddr(2), To: wallet, Value: big.NewInt(1e17), Block: 2000},
+		},
+		MainAddresses: map[string][]common.Address{metaA: {mainA}},
+	}
+
+	reports := Analyze(data, Options{})
+	assert.Len(t, reports, 1)
+	assert.Empty(t, reports[0].Findings)
+	assert.Equal(t, map[string]int{SeverityHigh: 0, SeverityMedium: 0, SeverityLow: 0}, reports[0].Summary)
+}
+
+func TestDatasetJSONRoundTrip(t *testing.T) {
+	raw := `{
+		"payments": [{"meta_address": "st:eth:0xaaaa", "stealth_address": "0x0000000000000000000000000000000000005e01",
+			"ephemeral_pub_key": "0x0201", "block": 100, "timestamp": "2026-01-01T00:00:00Z"}],
+		"transactions": [{"hash": "0x0100000000000000000000000000000000000000000000000000000000000000",
+			"from": "0x00000000000000000000000000000000000000a0", "to": "0x0000000000000000000000000000000000005e01",
+			"value": 1000000000000000000, "block": 101, "timestamp": "2026-01-01T00:00:12Z"}],
+		"main_addresses": {"st:eth:0xaaaa": ["0x00000000000000000000000000000000000000a0"]}
+	}`
+	var data Dataset
+	assert.NoError(t, json.Unmarshal([]byte(raw), &data))
+
+	reports := Analyze(&data, Options{})
+	out, err := json.Marshal(reports)
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), `"code":"funded_from_main_address"`)
+	assert.Contains(t, string(out), `"severity":"high"`)
+}
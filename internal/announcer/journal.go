@@ -0,0 +1,197 @@
+package announcer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/prikshit/blockchain-privacy-module/internal/privacy"
+)
+
+// journal is an append-only file of queue changes, one JSON record per line: an announcement that was
+// queued, or a batch that was sent. Every record is synced before the change takes effect, so a restart
+// loses no queued announcement. The file is compacted to the pending announcements when opened.
+type journal struct {
+	file *os.File
+}
+
+type journalRecord struct {
+	Queued *journalItem `json:"queued,omitempty"`
+	Sent   []string     `json:"sent,omitempty"` // IDs of the announcements published in TxHash
+	TxHash common.Hash  `json:"tx_hash,omitzero"`
+}
+
+type journalItem struct {
+	ID              string         `json:"id"`
+	StealthAddress  common.Address `json:"stealth_address"`
+	EphemeralPubKey hexutil.Bytes  `json:"ephemeral_pub_key"` // Compressed
+	Metadata        hexutil.Bytes  `json:"metadata"`
+	EnqueuedAt      time.Time      `json:"enqueued_at"`
+	ReleaseAt       time.Time      `json:"release_at"`
+}
+
+// openJournal loads the journal at path, creating it if needed, and returns the announcements still
+// pending, in the order they were queued. A partial last record left by an interrupted write is dropped.
+func openJournal(path string) (*journal, []*Item, error) {
+	log.Printf("Opening announcement queue journal at %s\n", path)
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+
+	var pending []*Item
+	byID := make(map[string]*Item)
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var record journalRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			if i == len(lines)-1 {
+				log.Printf("Dropping a partial announcement queue record of %d bytes\n", len(line))
+				break
+			}
+			return nil, nil, fmt.Errorf("announcement queue journal line %d: %w", i+1, err)
+		}
+		if record.Queued != nil {
+			item, err := record.Queued.item()
+			if err != nil {
+				return nil, nil, fmt.Errorf("announcement queue journal line %d: %w", i+1, err)
+			}
+			byID[item.ID] = item
+			pending = append(pending, item)
+		}
+		for _, id := range record.Sent {
+			delete(byID, id)
+		}
+	}
+	kept := pending[:0]
+	for _, item := range pending {
+		if byID[item.ID] != nil {
+			kept = append(kept, item)
+		}
+	}
+
+	j, err := compactJournal(path, kept)
+	if err != nil {
+		return nil, nil, err
+	}
+	return j, kept, nil
+}
+
+// compactJournal rewrites the journal at path to hold only items, replacing the old file once the new one
+// is complete, and opens it for appending.
+func compactJournal(path string, items []*Item) (*journal, error) {
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp)
+	w := bufio.NewWriter(file)
+	for _, item := range items {
+		if err := writeRecord(w, journalRecord{Queued: newJournalItem(item)}); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return nil, err
+	}
+
+	file, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &journal{file: file}, nil
+}
+
+// queued records a newly queued announcement.
+func (j *journal) queued(item *Item) error {
+	return j.append(journalRecord{Queued: newJournalItem(item)})
+}
+
+// sent records the announcements published in one transaction.
+func (j *journal) sent(batch []*Item, hash common.Hash) error {
+	record := journalRecord{TxHash: hash}
+	for _, item := range batch {
+		record.Sent = append(record.Sent, item.ID)
+	}
+	return j.append(record)
+}
+
+func (j *journal) append(record journalRecord) error {
+	var buf bytes.Buffer
+	if err := writeRecord(&buf, record); err != nil {
+		return err
+	}
+	if _, err := j.file.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("writing announcement queue journal: %w", err)
+	}
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("writing announcement queue journal: %w", err)
+	}
+	return nil
+}
+
+func (j *journal) Close() error {
+	return j.file.Close()
+}
+
+func writeRecord(w io.Writer, record journalRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(line, '\n'))
+	return err
+}
+
+func newJournalItem(item *Item) *journalItem {
+	return &journalItem{
+		ID:              item.ID,
+		StealthAddress:  item.Announcement.StealthAddress,
+		EphemeralPubKey: crypto.CompressPubkey(item.Announcement.EphemeralPubKey),
+		Metadata:        item.Announcement.Metadata,
+		EnqueuedAt:      item.EnqueuedAt,
+		ReleaseAt:       item.ReleaseAt,
+	}
+}
+
+func (ji *journalItem) item() (*Item, error) {
+	ephemeralPub, err := crypto.DecompressPubkey(ji.EphemeralPubKey)
+	if err != nil {
+		return nil, fmt.Errorf("announcement %s: %w", ji.ID, err)
+	}
+	return &Item{
+		ID: ji.ID,
+		Announcement: &privacy.Announcement{
+			StealthAddress:  ji.StealthAddress,
+			EphemeralPubKey: ephemeralPub,
+			Metadata:        ji.Metadata,
+		},
+		EnqueuedAt: ji.EnqueuedAt,
+		ReleaseAt:  ji.ReleaseAt,
+		Status:     StatusQueued,
+	}, nil
+}
@@ -0,0 +1,361 @@
+package announcer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/prikshit/blockchain-privacy-module/internal/chain"
+	"github.com/prikshit/blockchain-privacy-module/internal/privacy"
+)
+
+// SchemeSecp256k1 is the ERC-5564 scheme ID of the secp256k1 stealth scheme with view tags.
+const SchemeSecp256k1 = 1
+
+// Canonical deployments of the ERC-5564 announcer and Multicall3, at the same address on every chain.
+var (
+	DefaultAnnouncerAddress = common.HexToAddress("0x55649E01B5Df198D18D95b5cc5051630cfD45564")
+	DefaultMulticallAddress = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+)
+
+// Defaults used when Config leaves a field unset.
+const (
+	DefaultMinDelay = 10 * time.Minute
+	DefaultMaxDelay = 2 * time.Hour
+	DefaultMaxBatch = 20
+)
+
+// sentRetention is how long a sent announcement can still be looked up before it is pruned.
+const sentRetention = 24 * time.Hour
+
+// Item statuses.
+const (
+	StatusQueued = "queued"
+	StatusSent   = "sent"
+)
+
+var (
+	ErrInvalidDelay = errors.New("invalid announcement delay range")
+	ErrUnknownItem  = errors.New("unknown queued announcement")
+)
+
+var (
+	announcerABI = mustParseABI(`[{"type":"function","name":"announce","stateMutability":"nonpayable","inputs":[
+		{"name":"schemeId","type":"uint256"},{"name":"stealthAddress","type":"address"},
+		{"name":"ephemeralPubKey","type":"bytes"},{"name":"metadata","type":"bytes"}],"outputs":[]}]`)
+	multicallABI = mustParseABI(`[{"type":"function","name":"aggregate3","stateMutability":"payable","inputs":[
+		{"name":"calls","type":"tuple[]","components":[{"name":"target","type":"address"},
+		{"name":"allowFailure","type":"bool"},{"name":"callData","type":"bytes"}]}],
+		"outputs":[{"name":"returnData","type":"tuple[]","components":[{"name":"success","type":"bool"},{"name":"returnData","type":"bytes"}]}]}]`)
+)
+
+// multicall3Call mirrors Multicall3's Call3 struct for ABI encoding.
+type multicall3Call struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+// Config controls delays, batching and the contracts used.
+type Config struct {
+	Announcer common.Address
+	Multicall common.Address
+	SchemeID  int64
+	MinDelay  time.Duration // Shortest time an announcement is held
+	MaxDelay  time.Duration // Longest time an announcement is held
+	MaxBatch  int           // Most announcements per transaction
+	Journal   string        // File pending announcements are kept in across restarts; memory only when empty
+}
+
+// Item is one announcement waiting in, or sent from, the queue.
+type Item struct {
+	ID           string
+	Announcement *privacy.Announcement
+	EnqueuedAt   time.Time
+	ReleaseAt    time.Time
+	Status       string
+	TxHash       common.Hash
+	SentAt       time.Time
+}
+
+// Status summarises the queue.
+type Status struct {
+	Queued      int
+	Sent        int
+	Batches     int
+	NextRelease time.Time // Zero when nothing is queued
+	Relayer     common.Address
+}
+
+// Queue holds announcements for a random delay and publishes due ones in batches through Multicall3,
+// so the announcement time and sender reveal nothing about the payer.
+type Queue struct {
+	mu      sync.Mutex
+	flushMu sync.Mutex
+	cfg     Config
+	sender  *chain.Sender
+	journal *journal // nil for a queue kept in memory only
+	items   map[string]*Item
+	queued  []*Item
+	sent    int
+	batches int
+	now     func() time.Time
+}
+
+// NewQueue returns a queue publishing from the sender's relayer key.
+func NewQueue(cfg Config, sender *chain.Sender) (*Queue, error) {
+	if cfg.Announcer == (common.Address{}) {
+		cfg.Announcer = DefaultAnnouncerAddress
+	}
+	if cfg.Multicall == (common.Address{}) {
+		cfg.Multicall = DefaultMulticallAddress
+	}
+	if cfg.SchemeID == 0 {
+		cfg.SchemeID = SchemeSecp256k1
+	}
+	if cfg.MinDelay == 0 && cfg.MaxDelay == 0 {
+		cfg.MinDelay, cfg.MaxDelay = DefaultMinDelay, DefaultMaxDelay
+	}
+	if cfg.MinDelay < 0 || cfg.MaxDelay < cfg.MinDelay {
+		return nil, ErrInvalidDelay
+	}
+	if cfg.MaxBatch <= 0 {
+		cfg.MaxBatch = DefaultMaxBatch
+	}
+
+	log.Printf("Announcement queue publishing to %s via %s with %s-%s delay\n", cfg.Announcer.Hex(), cfg.Multicall.Hex(), cfg.MinDelay, cfg.MaxDelay)
+	q := &Queue{cfg: cfg, sender: sender, items: make(map[string]*Item), now: time.Now}
+	if cfg.Journal != "" {
+		j, pending, err := openJournal(cfg.Journal)
+		if err != nil {
+			return nil, err
+		}
+		q.journal = j
+		for _, item := range pending {
+			q.items[item.ID] = item
+			q.queued = append(q.queued, item)
+		}
+		log.Printf("Restored %d queued announcements\n", len(pending))
+	}
+	return q, nil
+}
+
+// Close releases the journal. Queued announcements stay in it and are published after a restart.
+func (q *Queue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.journal == nil {
+		return nil
+	}
+	return q.journal.Close()
+}
+
+// Enqueue holds ann until a random release time within the configured delay range.
+func (q *Queue) Enqueue(ann *privacy.Announcement) (*Item, error) {
+	delay, err := randomDuration(q.cfg.MinDelay, q.cfg.MaxDelay)
+	if err != nil {
+		return nil, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	item := &Item{
+		ID:           newID(),
+		Announcement: ann,
+		EnqueuedAt:   now,
+		ReleaseAt:    now.Add(delay),
+		Status:       StatusQueued,
+	}
+	if q.journal != nil {
+		if err := q.journal.queued(item); err != nil {
+			return nil, err
+		}
+	}
+	q.items[item.ID] = item
+	q.queued = append(q.queued, item)
+
+	log.Printf("Queued announcement %s for %s\n", item.ID, ann.StealthAddress.Hex())
+	return item.snapshot(), nil
+}
+
+// Item returns a copy of the queued or sent announcement with the given ID.
+func (q *Queue) Item(id string) (*Item, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	item, ok := q.items[id]
+	if !ok {
+		return nil, ErrUnknownItem
+	}
+	return item.snapshot(), nil
+}
+
+// Status returns queue counters and the next release time.
+func (q *Queue) Status() Status {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	status := Status{Queued: len(q.queued), Sent: q.sent, Batches: q.batches, Relayer: q.sender.Address()}
+	for _, item := range q.queued {
+		if status.NextRelease.IsZero() || item.ReleaseAt.Before(status.NextRelease) {
+			status.NextRelease = item.ReleaseAt
+		}
+	}
+	return status
+}
+
+// Flush publishes up to MaxBatch due announcements in one multicall transaction, in random order.
+// It returns nil when nothing is due. On failure the announcements stay queued.
+func (q *Queue) Flush(ctx context.Context) (*types.Transaction, error) {
+	q.flushMu.Lock()
+	defer q.flushMu.Unlock()
+
+	batch := q.takeDue()
+	if len(batch) == 0 {
+		return nil, nil
+	}
+
+	data, err := q.encodeBatch(batch)
+	if err == nil {
+		var tx *types.Transaction
+		tx, err = q.sender.Send(ctx, q.cfg.Multicall, nil, data)
+		if err == nil {
+			q.markSent(batch, tx.Hash())
+			log.Printf("Published %d announcements in %s\n", len(batch), tx.Hash().Hex())
+			return tx, nil
+		}
+	}
+
+	log.Printf("Error publishing %d announcements: %v\n", len(batch), err)
+	q.mu.Lock()
+	q.queued = append(q.queued, batch...)
+	q.mu.Unlock()
+	return nil, err
+}
+
+// Run flushes the queue every interval until ctx is cancelled.
+func (q *Queue) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Keep sending while full batches are due
+			for {
+				tx, err := q.Flush(ctx)
+				if err != nil || tx == nil {
+					break
+				}
+			}
+		}
+	}
+}
+
+// takeDue removes up to MaxBatch due items from the queue, earliest first, and shuffles them. It also
+// prunes items sent longer than sentRetention ago.
+func (q *Queue) takeDue() []*Item {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for id, item := range q.items {
+		if item.Status == StatusSent && q.now().Sub(item.SentAt) > sentRetention {
+			delete(q.items, id)
+		}
+	}
+
+	sort.SliceStable(q.queued, func(i, j int) bool { return q.queued[i].ReleaseAt.Before(q.queued[j].ReleaseAt) })
+	now := q.now()
+	n := 0
+	for n < len(q.queued) && n < q.cfg.MaxBatch && !q.queued[n].ReleaseAt.After(now) {
+		n++
+	}
+	batch := append([]*Item(nil), q.queued[:n]...)
+	q.queued = q.queued[n:]
+
+	for i := len(batch) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			break
+		}
+		batch[i], batch[j.Int64()] = batch[j.Int64()], batch[i]
+	}
+	return batch
+}
+
+func (q *Queue) markSent(batch []*Item, hash common.Hash) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// The batch is already published; failing to record it only means it is published again after a restart
+	if q.journal != nil {
+		if err := q.journal.sent(batch, hash); err != nil {
+			log.Printf("Error recording published announcements in %s: %v\n", hash.Hex(), err)
+		}
+	}
+	now := q.now()
+	for _, item := range batch {
+		item.Status = StatusSent
+		item.TxHash = hash
+		item.SentAt = now
+	}
+	q.sent += len(batch)
+	q.batches++
+}
+
+// encodeBatch builds the aggregate3 calldata with one announce call per item.
+func (q *Queue) encodeBatch(batch []*Item) ([]byte, error) {
+	calls := make([]multicall3Call, len(batch))
+	for i, item := range batch {
+		ann := item.Announcement
+		data, err := announcerABI.Pack("announce", big.NewInt(q.cfg.SchemeID), ann.StealthAddress, crypto.CompressPubkey(ann.EphemeralPubKey), ann.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("encoding announcement %s: %w", item.ID, err)
+		}
+		calls[i] = multicall3Call{Target: q.cfg.Announcer, CallData: data}
+	}
+	return multicallABI.Pack("aggregate3", calls)
+}
+
+func (item *Item) snapshot() *Item {
+	c := *item
+	return &c
+}
+
+// randomDuration returns a uniformly random duration in [min, max].
+func randomDuration(min, max time.Duration) (time.Duration, error) {
+	r, err := rand.Int(rand.Reader, big.NewInt(int64(max-min)+1))
+	if err != nil {
+		return 0, err
+	}
+	return min + time.Duration(r.Int64()), nil
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
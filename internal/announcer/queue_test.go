@@ -0,0 +1,292 @@
+package announcer
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/program"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/prikshit/blockchain-privacy-module/internal/chain"
+	"github.com/prikshit/blockchain-privacy-module/internal/privacy"
+	"github.com/prikshit/blockchain-privacy-module/internal/sanctions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	announcementTopic = crypto.Keccak256Hash([]byte("Announcement(uint256,address,address,bytes,bytes)"))
+	announcerEventABI = mustParseABI(`[{"type":"event","name":"Announcement","anonymous":false,"inputs":[
+		{"name":"schemeId","type":"uint256","indexed":true},{"name":"stealthAddress","type":"address","indexed":true},
+		{"name":"caller","type":"address","indexed":true},{"name":"ephemeralPubKey","type":"bytes","indexed":false},
+		{"name":"metadata","type":"bytes","indexed":false}]}]`)
+)
+
+// announcerCode is a hand-assembled stand-in for the ERC-5564 announcer. It treats every call as
+// announce(schemeId, stealthAddress, ephemeralPubKey, metadata) with canonical ABI encoding and
+// re-emits the two bytes arguments, rebasing their offsets past the two static arguments.
+func announcerCode() []byte {
+	p := program.New()
+	p.Op(vm.CALLDATASIZE).Push(68).Op(vm.SWAP1, vm.SUB)           // size = calldatasize - 68
+	p.Op(vm.DUP1).Push(68).Push(0).Op(vm.CALLDATACOPY)            // memory[0:size] = calldata[68:]
+	p.Push(64).Push(0).Op(vm.MLOAD, vm.SUB).Push(0).Op(vm.MSTORE) // rebase the ephemeralPubKey offset
+	p.Push(64).Push(32).Op(vm.MLOAD, vm.SUB).Push(32).Op(vm.MSTORE)
+	p.Op(vm.CALLER).Push(36).Op(vm.CALLDATALOAD).Push(4).Op(vm.CALLDATALOAD).Push(announcementTopic)
+	p.Op(vm.DUP5).Push(0).Op(vm.LOG4, vm.STOP)
+	return p.Bytes()
+}
+
+// multicallCode is a hand-assembled stand-in for Multicall3's aggregate3: it calls every target with
+// its calldata and reverts when a call fails without allowFailure. Return data is not collected.
+func multicallCode() []byte {
+	p := program.New()
+	// Exit paths come first so every jump target is known when it is pushed
+	const start = 9
+	p.Push(start).Op(vm.JUMP)
+	_, end := p.Jumpdest()
+	p.Op(vm.STOP)
+	_, fail := p.Jumpdest()
+	p.Push0().Op(vm.DUP1, vm.REVERT)
+	if _, pc := p.Jumpdest(); pc != start {
+		panic("multicall stand-in layout changed")
+	}
+
+	p.Push(4).Op(vm.CALLDATALOAD).Push(4).Op(vm.ADD)             // arr = 4 + offset
+	p.Op(vm.DUP1, vm.CALLDATALOAD, vm.SWAP1).Push(32).Op(vm.ADD) // [base, n]
+	p.Push(0)                                                    // [i, base, n]
+	_, loop := p.Jumpdest()
+	p.Op(vm.DUP3, vm.DUP2, vm.LT, vm.ISZERO).Push(end).Op(vm.JUMPI)
+	p.Op(vm.DUP1).Push(32).Op(vm.MUL, vm.DUP3, vm.ADD, vm.CALLDATALOAD, vm.DUP3, vm.ADD) // [elem, i, base, n]
+	p.Op(vm.DUP1).Push(64).Op(vm.ADD, vm.CALLDATALOAD, vm.DUP2, vm.ADD)                  // [bpos, elem, ...]
+	p.Op(vm.DUP1, vm.CALLDATALOAD)                                                       // [blen, bpos, elem, ...]
+	p.Op(vm.DUP1, vm.DUP3).Push(32).Op(vm.ADD).Push(0).Op(vm.CALLDATACOPY)               // memory[0:blen] = callData
+	p.Push(0).Op(vm.DUP1, vm.DUP3).Push(0).Op(vm.DUP1, vm.DUP8, vm.CALLDATALOAD, vm.GAS, vm.CALL)
+	p.Op(vm.DUP4).Push(32).Op(vm.ADD, vm.CALLDATALOAD, vm.OR, vm.ISZERO).Push(fail).Op(vm.JUMPI)
+	p.Op(vm.POP, vm.POP, vm.POP).Push(1).Op(vm.ADD).Push(loop).Op(vm.JUMP)
+	return p.Bytes()
+}
+
+type testChain struct {
+	backend   *simulated.Backend
+	sender    *chain.Sender
+	announcer common.Address
+	multicall common.Address
+	reverter  common.Address
+}
+
+func newTestChain(t *testing.T) *testChain {
+	relayerKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	announcer := common.HexToAddress("0x5564")
+	multicall := common.HexToAddress("0xca11")
+	reverter := common.HexToAddress("0xdead")
+	backend := simulated.NewBackend(types.GenesisAlloc{
+		crypto.PubkeyToAddress(relayerKey.PublicKey): {Balance: big.NewInt(params.Ether)},
+		announcer: {Code: announcerCode()},
+		multicall: {Code: multicallCode()},
+		reverter:  {Code: program.New().Push(0).Op(vm.DUP1, vm.REVERT).Bytes()},
+	})
+	t.Cleanup(func() { backend.Close() })
+
+	sender, err := chain.NewSender(context.Background(), backend.Client(), relayerKey)
+	require.NoError(t, err)
+	return &testChain{backend: backend, sender: sender, announcer: announcer, multicall: multicall, reverter: reverter}
+}
+
+func generateAnnouncements(t *testing.T, n int) []*privacy.Announcement {
+	pm := privacy.NewPrivacyManager(sanctions.NewDetector(nil))
+	spendingKey, _ := crypto.GenerateKey()
+	viewingKey, _ := crypto.GenerateKey()
+	keys := &privacy.StealthKeys{SpendingKey: spendingKey, ViewingKey: viewingKey}
+
+	var announcements []*privacy.Announcement
+	for range n {
+		_, ann, err := pm.GenerateStealthPayment(keys.MetaAddress())
+		require.NoError(t, err)
+		announcements = append(announcements, ann)
+	}
+	return announcements
+}
+
+func TestQueuePublishesDelayedBatches(t *testing.T) {
+	tc := newTestChain(t)
+	queue, err := NewQueue(Config{
+		Announcer: tc.announcer,
+		Multicall: tc.multicall,
+		MinDelay:  time.Minute,
+		MaxDelay:  time.Hour,
+		MaxBatch:  3,
+	}, tc.sender)
+	require.NoError(t, err)
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	queue.now = func() time.Time { return now }
+
+	announcements := generateAnnouncements(t, 4)
+	byAddress := make(map[common.Address]*privacy.Announcement)
+	var ids []string
+	for _, ann := range announcements {
+		item, err := queue.Enqueue(ann)
+		require.NoError(t, err)
+		assert.Equal(t, StatusQueued, item.Status)
+		assert.False(t, item.ReleaseAt.Before(now.Add(time.Minute)))
+		assert.False(t, item.ReleaseAt.After(now.Add(time.Hour)))
+		byAddress[ann.StealthAddress] = ann
+		ids = append(ids, item.ID)
+	}
+
+	// Nothing is due before the minimum delay
+	tx, err := queue.Flush(context.Background())
+	assert.NoError(t, err)
+	assert.Nil(t, tx)
+	assert.Equal(t, 4, queue.Status().Queued)
+
+	// After the maximum delay everything is due, but batches are capped
+	now = now.Add(time.Hour)
+	first, err := queue.Flush(context.Background())
+	require.NoError(t, err)
+	second, err := queue.Flush(context.Background())
+	require.NoError(t, err)
+	tc.backend.Commit()
+
+	status := queue.Status()
+	assert.Equal(t, 0, status.Queued)
+	assert.Equal(t, 4, status.Sent)
+	assert.Equal(t, 2, status.Batches)
+	assert.Equal(t, tc.sender.Address(), status.Relayer)
+
+	// Both batches come from the relayer and carry 3 and 1 announcements
+	client := tc.backend.Client()
+	for _, sent := range []*types.Transaction{first, second} {
+		receipt, err := client.TransactionReceipt(context.Background(), sent.Hash())
+		require.NoError(t, err)
+		assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+		assert.Equal(t, tc.multicall, *sent.To())
+	}
+
+	logs, err := client.FilterLogs(context.Background(), ethereum.FilterQuery{
+		Addresses: []common.Address{tc.announcer},
+		Topics:    [][]common.Hash{{announcementTopic}},
+	})
+	require.NoError(t, err)
+	require.Len(t, logs, 4)
+	event := announcerEventABI.Events["Announcement"]
+	for _, l := range logs {
+		assert.Equal(t, common.BigToHash(big.NewInt(SchemeSecp256k1)), l.Topics[1])
+		assert.Equal(t, common.BytesToHash(tc.multicall.Bytes()), l.Topics[3])
+
+		ann, ok := byAddress[common.BytesToAddress(l.Topics[2].Bytes())]
+		require.True(t, ok)
+		values, err := event.Inputs.NonIndexed().Unpack(l.Data)
+		require.NoError(t, err)
+		assert.Equal(t, crypto.CompressPubkey(ann.EphemeralPubKey), values[0])
+		assert.Equal(t, ann.Metadata, values[1])
+	}
+
+	for _, id := range ids {
+		item, err := queue.Item(id)
+		require.NoError(t, err)
+		assert.Equal(t, StatusSent, item.Status)
+		assert.Contains(t, []common.Hash{first.Hash(), second.Hash()}, item.TxHash)
+	}
+}
+
+func TestQueueKeepsAnnouncementsWhenSendingFails(t *testing.T) {
+	tc := newTestChain(t)
+	queue, err := NewQueue(Config{
+		Announcer: tc.announcer,
+		Multicall: tc.reverter,
+		MinDelay:  time.Second,
+		MaxDelay:  time.Second,
+	}, tc.sender)
+	require.NoError(t, err)
+
+	now := time.Now()
+	queue.now = func() time.Time { return now }
+	item, err := queue.Enqueue(generateAnnouncements(t, 1)[0])
+	require.NoError(t, err)
+
+	now = now.Add(time.Second)
+	tx, err := queue.Flush(context.Background())
+	assert.Error(t, err)
+	assert.Nil(t, tx)
+
+	status := queue.Status()
+	assert.Equal(t, 1, status.Queued)
+	assert.Equal(t, 0, status.Sent)
+	assert.Equal(t, item.ReleaseAt, status.NextRelease)
+}
+
+func TestQueueSurvivesRestart(t *testing.T) {
+	tc := newTestChain(t)
+	cfg := Config{
+		Announcer: tc.announcer,
+		Multicall: tc.multicall,
+		MinDelay:  time.Minute,
+		MaxDelay:  time.Minute,
+		MaxBatch:  2,
+		Journal:   filepath.Join(t.TempDir(), "announcements.jsonl"),
+	}
+	queue, err := NewQueue(cfg, tc.sender)
+	require.NoError(t, err)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	queue.now = func() time.Time { return now }
+
+	var ids []string
+	for _, ann := range generateAnnouncements(t, 3) {
+		item, err := queue.Enqueue(ann)
+		require.NoError(t, err)
+		ids = append(ids, item.ID)
+	}
+	now = now.Add(time.Minute)
+	sent, err := queue.Flush(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, sent)
+	tc.backend.Commit()
+	require.NoError(t, queue.Close())
+
+	// Only the announcement that was not published is restored, with its release time
+	queue, err = NewQueue(cfg, tc.sender)
+	require.NoError(t, err)
+	defer queue.Close()
+	queue.now = func() time.Time { return now }
+	assert.Equal(t, 1, queue.Status().Queued)
+	var pending *Item
+	for _, id := range ids {
+		if item, err := queue.Item(id); err == nil {
+			pending = item
+		}
+	}
+	require.NotNil(t, pending)
+	assert.Equal(t, StatusQueued, pending.Status)
+	assert.True(t, pending.ReleaseAt.Equal(now))
+
+	tx, err := queue.Flush(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, tx)
+	assert.Equal(t, 0, queue.Status().Queued)
+
+	// Sent announcements are pruned once they are past the retention period
+	now = now.Add(sentRetention + time.Minute)
+	tx, err = queue.Flush(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, tx)
+	_, err = queue.Item(pending.ID)
+	assert.ErrorIs(t, err, ErrUnknownItem)
+	assert.Equal(t, 1, queue.Status().Sent)
+}
+
+func TestNewQueueRejectsInvalidDelay(t *testing.T) {
+	tc := newTestChain(t)
+	_, err := NewQueue(Config{MinDelay: time.Hour, MaxDelay: time.Minute}, tc.sender)
+	assert.ErrorIs(t, err, ErrInvalidDelay)
+}
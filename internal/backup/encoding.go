@@ -0,0 +1,168 @@
+package backup
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// Shares are written as words from the BIP-39 English list (11 bits per word), so they can be
+// transcribed by hand. Layout: version | type | threshold | index | id (2) | length | value | checksum (4).
+const (
+	encodingVersion = 1
+	headerSize      = 7
+	checksumSize    = 4
+	bitsPerWord     = 11
+)
+
+var (
+	ErrInvalidEncoding = errors.New("invalid share encoding")
+	ErrChecksum        = errors.New("share checksum mismatch, check for transcription errors")
+)
+
+// Encode returns the share as a space-separated list of words.
+func (s *Share) Encode() string {
+	raw := make([]byte, 0, headerSize+len(s.Value)+checksumSize)
+	raw = append(raw, encodingVersion, byte(s.Type), s.Threshold, s.Index)
+	raw = binary.BigEndian.AppendUint16(raw, s.ID)
+	raw = append(raw, byte(len(s.Value)))
+	raw = append(raw, s.Value...)
+	checksum := sha256.Sum256(raw)
+	raw = append(raw, checksum[:checksumSize]...)
+
+	wordList := bip39.GetWordList()
+	words := make([]string, 0, (len(raw)*8+bitsPerWord-1)/bitsPerWord)
+	var acc uint32
+	var bits uint
+	for _, b := range raw {
+		acc = acc<<8 | uint32(b)
+		bits += 8
+		for bits >= bitsPerWord {
+			bits -= bitsPerWord
+			words = append(words, wordList[(acc>>bits)&0x7ff])
+		}
+	}
+	if bits > 0 {
+		words = append(words, wordList[(acc<<(bitsPerWord-bits))&0x7ff])
+	}
+	return strings.Join(words, " ")
+}
+
+// DecodeShare parses a share written by Share.Encode, verifying its checksum.
+func DecodeShare(encoded string) (*Share, error) {
+	words := strings.Fields(strings.ToLower(encoded))
+
+	raw := make([]byte, 0, len(words)*bitsPerWord/8)
+	var acc uint32
+	var bits uint
+	for _, w := range words {
+		index, ok := bip39.GetWordIndex(w)
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown word %q", ErrInvalidEncoding, w)
+		}
+		acc = acc<<bitsPerWord | uint32(index)
+		bits += bitsPerWord
+		for bits >= 8 {
+			bits -= 8
+			raw = append(raw, byte(acc>>bits))
+		}
+	}
+
+	if len(raw) < headerSize+checksumSize {
+		return nil, fmt.Errorf("%w: too short", ErrInvalidEncoding)
+	}
+	if raw[0] != encodingVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidEncoding, raw[0])
+	}
+	valueLen := int(raw[6])
+	total := headerSize + valueLen + checksumSize
+	if len(raw) < total {
+		return nil, fmt.Errorf("%w: truncated", ErrInvalidEncoding)
+	}
+
+	checksum := sha256.Sum256(raw[:headerSize+valueLen])
+	if string(checksum[:checksumSize]) != string(raw[headerSize+valueLen:total]) {
+		return nil, ErrChecksum
+	}
+
+	return &Share{
+		Type:      SecretType(raw[1]),
+		Threshold: raw[2],
+		Index:     raw[3],
+		ID:        binary.BigEndian.Uint16(raw[4:6]),
+		Value:     append([]byte(nil), raw[headerSize:headerSize+valueLen]...),
+	}, nil
+}
+
+// Restored is a secret recovered from encoded shares. Exactly one of PrivateKey and Mnemonic is set.
+type Restored struct {
+	Type       SecretType
+	PrivateKey *ecdsa.PrivateKey
+	Mnemonic   string
+}
+
+// SplitPrivateKey backs up a spending or viewing key as encoded shares.
+func SplitPrivateKey(key *ecdsa.PrivateKey, threshold, n int) ([]string, error) {
+	return splitEncoded(crypto.FromECDSA(key), SecretPrivateKey, threshold, n)
+}
+
+// SplitMnemonic backs up a BIP-39 mnemonic as encoded shares by splitting its entropy.
+func SplitMnemonic(mnemonic string, threshold, n int) ([]string, error) {
+	entropy, err := bip39.EntropyFromMnemonic(mnemonic)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mnemonic: %w", err)
+	}
+	return splitEncoded(entropy, SecretMnemonic, threshold, n)
+}
+
+// Restore decodes and combines encoded shares back into the original key or mnemonic.
+func Restore(encoded []string) (*Restored, error) {
+	shares := make([]*Share, 0, len(encoded))
+	for i, e := range encoded {
+		share, err := DecodeShare(e)
+		if err != nil {
+			return nil, fmt.Errorf("share %d: %w", i+1, err)
+		}
+		shares = append(shares, share)
+	}
+
+	secret, typ, err := Combine(shares)
+	if err != nil {
+		return nil, err
+	}
+
+	switch typ {
+	case SecretPrivateKey:
+		key, err := crypto.ToECDSA(secret)
+		if err != nil {
+			return nil, err
+		}
+		return &Restored{Type: typ, PrivateKey: key}, nil
+	case SecretMnemonic:
+		mnemonic, err := bip39.NewMnemonic(secret)
+		if err != nil {
+			return nil, err
+		}
+		return &Restored{Type: typ, Mnemonic: mnemonic}, nil
+	default:
+		return nil, ErrUnknownSecretType
+	}
+}
+
+func splitEncoded(secret []byte, typ SecretType, threshold, n int) ([]string, error) {
+	shares, err := Split(secret, typ, threshold, n)
+	if err != nil {
+		return nil, err
+	}
+	encoded := make([]string, len(shares))
+	for i, s := range shares {
+		encoded[i] = s.Encode()
+	}
+	return encoded, nil
+}
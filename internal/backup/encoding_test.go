@@ -0,0 +1,82 @@
+package backup
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/tyler-smith/go-bip39"
+)
+
+func TestShareEncodingRoundTrip(t *testing.T) {
+	shares, err := Split([]byte("round trip secret"), SecretMnemonic, 2, 3)
+	assert.NoError(t, err)
+
+	for _, s := range shares {
+		decoded, err := DecodeShare(s.Encode())
+		assert.NoError(t, err)
+		assert.Equal(t, s, decoded)
+	}
+
+	// Decoding is case and whitespace tolerant
+	decoded, err := DecodeShare("  " + strings.ToUpper(shares[0].Encode()) + "\n")
+	assert.NoError(t, err)
+	assert.Equal(t, shares[0], decoded)
+}
+
+func TestShareEncodingDetectsCorruption(t *testing.T) {
+	shares, err := Split([]byte("secret"), SecretPrivateKey, 2, 3)
+	assert.NoError(t, err)
+	words := strings.Fields(shares[0].Encode())
+
+	// A mistranscribed word fails the checksum
+	swapped := append([]string(nil), words...)
+	if swapped[3] == "abandon" {
+		swapped[3] = "ability"
+	} else {
+		swapped[3] = "abandon"
+	}
+	_, err = DecodeShare(strings.Join(swapped, " "))
+	assert.ErrorIs(t, err, ErrChecksum)
+
+	// A word outside the list
+	_, err = DecodeShare(strings.Join(append(words[:1:1], "notaword"), " "))
+	assert.ErrorIs(t, err, ErrInvalidEncoding)
+
+	// A missing word
+	_, err = DecodeShare(strings.Join(words[:len(words)-2], " "))
+	assert.Error(t, err)
+}
+
+func TestRestorePrivateKeyAndMnemonic(t *testing.T) {
+	key, err := ecdsa.GenerateKey(crypto.S256(), rand.Reader)
+	assert.NoError(t, err)
+
+	encoded, err := SplitPrivateKey(key, 2, 3)
+	assert.NoError(t, err)
+	restored, err := Restore([]string{encoded[2], encoded[0]})
+	assert.NoError(t, err)
+	assert.Equal(t, SecretPrivateKey, restored.Type)
+	assert.Equal(t, key.D, restored.PrivateKey.D)
+
+	_, err = Restore(encoded[:1])
+	assert.ErrorIs(t, err, ErrNotEnoughShares)
+
+	entropy, err := bip39.NewEntropy(256)
+	assert.NoError(t, err)
+	mnemonic, err := bip39.NewMnemonic(entropy)
+	assert.NoError(t, err)
+
+	encoded, err = SplitMnemonic(mnemonic, 3, 5)
+	assert.NoError(t, err)
+	restored, err = Restore(encoded[1:4])
+	assert.NoError(t, err)
+	assert.Equal(t, SecretMnemonic, restored.Type)
+	assert.Equal(t, mnemonic, restored.Mnemonic)
+
+	_, err = SplitMnemonic("not a mnemonic", 2, 3)
+	assert.Error(t, err)
+}
@@ -0,0 +1,177 @@
+package backup
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// SecretType tells Restore how to interpret the recovered bytes.
+type SecretType byte
+
+const (
+	SecretPrivateKey SecretType = 1
+	SecretMnemonic   SecretType = 2
+)
+
+// digestSize is the number of secret-digest bytes appended before splitting, so that a
+// combination of wrong or corrupted shares is detected instead of yielding a wrong secret.
+const digestSize = 4
+
+var (
+	ErrInvalidThreshold  = errors.New("threshold must satisfy 1 < threshold <= shares <= 255")
+	ErrNotEnoughShares   = errors.New("not enough shares to recover the secret")
+	ErrDuplicateShare    = errors.New("duplicate share index")
+	ErrMismatchedShares  = errors.New("shares belong to different backups")
+	ErrDigestMismatch    = errors.New("recovered secret failed its integrity check")
+	ErrUnknownSecretType = errors.New("unknown secret type")
+	ErrSecretTooLong     = errors.New("secret is too long to back up")
+)
+
+// Share is one Shamir share of a backed-up secret, split byte-wise over GF(256).
+// All shares of one backup carry the same ID, Type and Threshold.
+type Share struct {
+	ID        uint16
+	Type      SecretType
+	Threshold byte
+	Index     byte
+	Value     []byte
+}
+
+// Split divides secret into n shares, any threshold of which recover it.
+func Split(secret []byte, typ SecretType, threshold, n int) ([]*Share, error) {
+	if threshold < 2 || threshold > n || n > 255 {
+		return nil, ErrInvalidThreshold
+	}
+	if len(secret) == 0 || len(secret)+digestSize > 255 {
+		return nil, ErrSecretTooLong
+	}
+	log.Printf("Splitting %d-byte secret into %d-of-%d shares\n", len(secret), threshold, n)
+
+	digest := sha256.Sum256(secret)
+	payload := append(append([]byte{}, secret...), digest[:digestSize]...)
+
+	var id [2]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, err
+	}
+
+	shares := make([]*Share, n)
+	for i := range shares {
+		shares[i] = &Share{
+			ID:        binary.BigEndian.Uint16(id[:]),
+			Type:      typ,
+			Threshold: byte(threshold),
+			Index:     byte(i + 1),
+			Value:     make([]byte, len(payload)),
+		}
+	}
+
+	// Each byte of the payload gets its own random polynomial with that byte as constant term
+	coeffs := make([]byte, threshold-1)
+	for pos, b := range payload {
+		if _, err := rand.Read(coeffs); err != nil {
+			return nil, err
+		}
+		for _, share := range shares {
+			share.Value[pos] = evalPolynomial(b, coeffs, share.Index)
+		}
+	}
+	return shares, nil
+}
+
+// Combine recovers the secret from at least Threshold shares of the same backup.
+func Combine(shares []*Share) ([]byte, SecretType, error) {
+	if len(shares) == 0 || len(shares) < int(shares[0].Threshold) {
+		return nil, 0, ErrNotEnoughShares
+	}
+
+	first := shares[0]
+	seen := make(map[byte]bool)
+	for _, s := range shares {
+		if s.ID != first.ID || s.Type != first.Type || s.Threshold != first.Threshold || len(s.Value) != len(first.Value) {
+			return nil, 0, ErrMismatchedShares
+		}
+		if s.Index == 0 || seen[s.Index] {
+			return nil, 0, fmt.Errorf("%w: %d", ErrDuplicateShare, s.Index)
+		}
+		seen[s.Index] = true
+	}
+	if len(first.Value) <= digestSize {
+		return nil, 0, ErrDigestMismatch
+	}
+
+	// Lagrange interpolation at x = 0 over exactly Threshold shares
+	used := shares[:first.Threshold]
+	payload := make([]byte, len(first.Value))
+	for i, si := range used {
+		basis := byte(1)
+		for j, sj := range used {
+			if i != j {
+				basis = gfMul(basis, gfDiv(sj.Index, sj.Index^si.Index))
+			}
+		}
+		for pos := range payload {
+			payload[pos] ^= gfMul(basis, si.Value[pos])
+		}
+	}
+
+	secret, digest := payload[:len(payload)-digestSize], payload[len(payload)-digestSize:]
+	expected := sha256.Sum256(secret)
+	if subtle.ConstantTimeCompare(expected[:digestSize], digest) != 1 {
+		log.Println("Recovered secret does not match its digest")
+		return nil, 0, ErrDigestMismatch
+	}
+
+	log.Printf("Recovered secret from %d shares\n", len(used))
+	return secret, first.Type, nil
+}
+
+// evalPolynomial evaluates constant + c1*x + c2*x^2 + ... over GF(256).
+func evalPolynomial(constant byte, coeffs []byte, x byte) byte {
+	result := byte(0)
+	for i := len(coeffs) - 1; i >= 0; i-- {
+		result = gfMul(result, x) ^ coeffs[i]
+	}
+	return gfMul(result, x) ^ constant
+}
+
+// GF(256) arithmetic with the AES reduction polynomial x^8 + x^4 + x^3 + x + 1.
+var gfExp, gfLog = func() ([512]byte, [256]byte) {
+	var exp [512]byte
+	var lg [256]byte
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		exp[i] = x
+		lg[x] = byte(i)
+		// Multiply by the generator 3
+		hi := x & 0x80
+		x2 := x << 1
+		if hi != 0 {
+			x2 ^= 0x1b
+		}
+		x ^= x2
+	}
+	for i := 255; i < 512; i++ {
+		exp[i] = exp[i-255]
+	}
+	return exp, lg
+}()
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+255-int(gfLog[b])]
+}
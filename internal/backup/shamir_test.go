@@ -0,0 +1,68 @@
+package backup
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitAndCombine(t *testing.T) {
+	secret := []byte("a 32 byte secret for the backup!")
+
+	shares, err := Split(secret, SecretPrivateKey, 3, 5)
+	assert.NoError(t, err)
+	assert.Len(t, shares, 5)
+
+	// Every combination of 3 shares recovers the secret
+	for i := 0; i < 5; i++ {
+		for j := i + 1; j < 5; j++ {
+			for k := j + 1; k < 5; k++ {
+				recovered, typ, err := Combine([]*Share{shares[k], shares[i], shares[j]})
+				assert.NoError(t, err)
+				assert.Equal(t, secret, recovered)
+				assert.Equal(t, SecretPrivateKey, typ)
+			}
+		}
+	}
+
+	// No single share reveals the secret
+	for _, s := range shares {
+		assert.False(t, bytes.Contains(s.Value, secret))
+	}
+}
+
+func TestCombineRejectsBadShares(t *testing.T) {
+	secret := []byte("another secret")
+	shares, err := Split(secret, SecretPrivateKey, 3, 5)
+	assert.NoError(t, err)
+
+	// Insufficient shares
+	_, _, err = Combine(shares[:2])
+	assert.ErrorIs(t, err, ErrNotEnoughShares)
+	_, _, err = Combine(nil)
+	assert.ErrorIs(t, err, ErrNotEnoughShares)
+
+	// Duplicate shares
+	_, _, err = Combine([]*Share{shares[0], shares[0], shares[1]})
+	assert.ErrorIs(t, err, ErrDuplicateShare)
+
+	// Shares from another backup of the same secret
+	others, err := Split(secret, SecretPrivateKey, 3, 5)
+	assert.NoError(t, err)
+	others[0].ID = shares[0].ID ^ 1
+	_, _, err = Combine([]*Share{shares[0], shares[1], others[0]})
+	assert.ErrorIs(t, err, ErrMismatchedShares)
+
+	// A corrupted share value is caught by the digest
+	corrupted := *shares[2]
+	corrupted.Value = append([]byte(nil), shares[2].Value...)
+	corrupted.Value[0] ^= 0xff
+	_, _, err = Combine([]*Share{shares[0], shares[1], &corrupted})
+	assert.ErrorIs(t, err, ErrDigestMismatch)
+
+	_, err = Split(secret, SecretPrivateKey, 1, 5)
+	assert.ErrorIs(t, err, ErrInvalidThreshold)
+	_, err = Split(make([]byte, 252), SecretPrivateKey, 2, 3)
+	assert.ErrorIs(t, err, ErrSecretTooLong)
+}
@@ -0,0 +1,74 @@
+package bloom
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+)
+
+// Filter is a Bloom filter sized for an expected number of items and false-positive rate.
+// It never reports a false negative. Filter is not safe for concurrent use.
+type Filter struct {
+	bits  []uint64
+	m     uint64 // Number of bits
+	k     uint64 // Number of hash functions
+	count uint64
+}
+
+// New returns a filter holding n items at false-positive rate p (0 < p < 1).
+func New(n uint64, p float64) *Filter {
+	if n == 0 {
+		n = 1
+	}
+	if p <= 0 || p >= 1 {
+		p = 0.01
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	m = max(64, (m+63)/64*64)
+	k := uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+	k = max(1, k)
+	return &Filter{bits: make([]uint64, m/64), m: m, k: k}
+}
+
+// Add inserts data into the filter.
+func (f *Filter) Add(data []byte) {
+	h1, h2 := hashes(data)
+	for i := uint64(0); i < f.k; i++ {
+		bit := (h1 + i*h2) % f.m
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+	f.count++
+}
+
+// Test reports whether data may have been added. A false result is definite.
+func (f *Filter) Test(data []byte) bool {
+	h1, h2 := hashes(data)
+	for i := uint64(0); i < f.k; i++ {
+		bit := (h1 + i*h2) % f.m
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Count returns the number of items added.
+func (f *Filter) Count() uint64 {
+	return f.count
+}
+
+// SizeBytes returns the memory used by the bit array.
+func (f *Filter) SizeBytes() uint64 {
+	return f.m / 8
+}
+
+// FalsePositiveRate estimates the current false-positive rate from the number of items added.
+func (f *Filter) FalsePositiveRate() float64 {
+	return math.Pow(1-math.Exp(-float64(f.k)*float64(f.count)/float64(f.m)), float64(f.k))
+}
+
+// hashes derives the two base hashes for double hashing (Kirsch-Mitzenmacher).
+func hashes(data []byte) (uint64, uint64) {
+	sum := sha256.Sum256(data)
+	return binary.LittleEndian.Uint64(sum[:8]), binary.LittleEndian.Uint64(sum[8:16]) | 1
+}
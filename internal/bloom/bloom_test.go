@@ -0,0 +1,40 @@
+package bloom
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func item(i uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, i)
+}
+
+func TestFilterHasNoFalseNegatives(t *testing.T) {
+	f := New(10_000, 0.001)
+	for i := uint64(0); i < 10_000; i++ {
+		f.Add(item(i))
+	}
+	for i := uint64(0); i < 10_000; i++ {
+		assert.True(t, f.Test(item(i)))
+	}
+	assert.Equal(t, uint64(10_000), f.Count())
+}
+
+func TestFilterFalsePositiveRate(t *testing.T) {
+	f := New(10_000, 0.01)
+	for i := uint64(0); i < 10_000; i++ {
+		f.Add(item(i))
+	}
+
+	falsePositives := 0
+	for i := uint64(1 << 32); i < 1<<32+100_000; i++ {
+		if f.Test(item(i)) {
+			falsePositives++
+		}
+	}
+	// Allow some slack over the configured 1%
+	assert.Less(t, float64(falsePositives)/100_000, 0.015)
+	assert.InDelta(t, 0.01, f.FalsePositiveRate(), 0.002)
+}
@@ -0,0 +1,133 @@
+package chain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"log"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Backend is the subset of an Ethereum client needed to send transactions.
+// Both ethclient.Client and the simulated backend's client satisfy it.
+type Backend interface {
+	ethereum.ChainIDReader
+	ethereum.ContractCaller
+	ethereum.GasEstimator
+	ethereum.GasPricer1559
+	ethereum.TransactionSender
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// Sender signs and sends EIP-1559 transactions from one key, one at a time so nonces never collide.
+type Sender struct {
+	mu      sync.Mutex
+	backend Backend
+	key     *ecdsa.PrivateKey
+	chainID *big.Int
+}
+
+// NewSender returns a Sender for key on the chain the backend is connected to.
+func NewSender(ctx context.Context, backend Backend, key *ecdsa.PrivateKey) (*Sender, error) {
+	chainID, err := backend.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reading chain ID: %w", err)
+	}
+	log.Printf("Transaction sender %s on chain %s\n", crypto.PubkeyToAddress(key.PublicKey).Hex(), chainID)
+	return &Sender{backend: backend, key: key, chainID: chainID}, nil
+}
+
+// Address returns the account paying for the transactions.
+func (s *Sender) Address() common.Address {
+	return crypto.PubkeyToAddress(s.key.PublicKey)
+}
+
+// ChainID returns the chain the sender signs for.
+func (s *Sender) ChainID() *big.Int {
+	return new(big.Int).Set(s.chainID)
+}
+
+// Backend returns the client the sender uses.
+func (s *Sender) Backend() Backend {
+	return s.backend
+}
+
+// Send estimates gas for the call, signs it and submits it. The fee cap allows the base fee to double.
+func (s *Sender) Send(ctx context.Context, to common.Address, value *big.Int, data []byte) (*types.Transaction, error) {
+	return s.SendWithGasLimit(ctx, to, value, data, 0)
+}
+
+// SendWithGasLimit is Send with a fixed gas limit, for calls that depend on earlier transactions that
+// are still pending and so cannot be estimated yet. A zero limit is estimated.
+func (s *Sender) SendWithGasLimit(ctx context.Context, to common.Address, value *big.Int, data []byte, gas uint64) (*types.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	skeleton, err := Skeleton(ctx, s.backend, s.Address(), to, value, data, gas)
+	if err != nil {
+		return nil, err
+	}
+	skeleton.ChainID = s.chainID
+	tx, err := types.SignNewTx(s.key, types.LatestSignerForChainID(s.chainID), skeleton)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.backend.SendTransaction(ctx, tx); err != nil {
+		return nil, fmt.Errorf("sending transaction: %w", err)
+	}
+
+	log.Printf("Sent transaction %s (nonce %d, gas %d)\n", tx.Hash().Hex(), tx.Nonce(), tx.Gas())
+	return tx, nil
+}
+
+// Skeleton returns an unsigned EIP-1559 transaction from from, with the pending nonce and fees filled in.
+// The fee cap allows the base fee to double. A zero gas limit is estimated. ChainID is left for the signer.
+func Skeleton(ctx context.Context, backend Backend, from, to common.Address, value *big.Int, data []byte, gas uint64) (*types.DynamicFeeTx, error) {
+	if value == nil {
+		value = new(big.Int)
+	}
+	nonce, err := backend.PendingNonceAt(ctx, from)
+	if err != nil {
+		return nil, fmt.Errorf("reading nonce: %w", err)
+	}
+	tip, err := backend.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("suggesting gas tip: %w", err)
+	}
+	head, err := backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("reading latest header: %w", err)
+	}
+	feeCap := new(big.Int).Add(tip, new(big.Int).Mul(head.BaseFee, big.NewInt(2)))
+
+	if gas == 0 {
+		gas, err = backend.EstimateGas(ctx, ethereum.CallMsg{
+			From:      from,
+			To:        &to,
+			GasFeeCap: feeCap,
+			GasTipCap: tip,
+			Value:     value,
+			Data:      data,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("estimating gas: %w", err)
+		}
+	}
+
+	return &types.DynamicFeeTx{
+		Nonce:     nonce,
+		GasTipCap: tip,
+		GasFeeCap: feeCap,
+		Gas:       gas,
+		To:        &to,
+		Value:     value,
+		Data:      data,
+	}, nil
+}
@@ -0,0 +1,262 @@
+package consolidation
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Warning severities.
+const (
+	SeverityHigh   = "high"
+	SeverityMedium = "medium"
+)
+
+// Warning codes.
+const (
+	WarnSameBlockMerge    = "same_block_merge"
+	WarnMainDestination   = "main_address_destination"
+	WarnCommonDestination = "common_destination"
+)
+
+// DefaultBlockTime is the expected Ethereum slot time used to estimate sweep blocks.
+const DefaultBlockTime = 12 * time.Second
+
+var (
+	ErrNoBalances            = errors.New("no stealth balances to consolidate")
+	ErrNoDestinations        = errors.New("at least one destination that is not a main address is required")
+	ErrNotEnoughDestinations = errors.New("not enough destinations for the per-destination limit")
+	ErrInvalidDelay          = errors.New("invalid sweep delay range")
+)
+
+// StealthBalance is a balance the scanner found on one stealth address.
+type StealthBalance struct {
+	Address common.Address
+	Token   common.Address
+	Amount  *big.Int
+}
+
+// Options controls how sweeps are grouped and spaced.
+type Options struct {
+	Destinations  []common.Address // Fresh addresses the recipient controls
+	MainAddresses []common.Address // Addresses publicly linked to the recipient
+	MaxPerGroup   int              // Maximum stealth addresses swept into one destination (0 means no limit)
+	MinDelay      time.Duration    // Minimum spacing between consecutive sweeps
+	MaxDelay      time.Duration    // Maximum spacing between consecutive sweeps
+	Start         time.Time
+	StartBlock    uint64
+	BlockTime     time.Duration
+}
+
+// Sweep moves one token balance from a stealth address to a destination.
+type Sweep struct {
+	From   common.Address
+	To     common.Address
+	Token  common.Address
+	Amount *big.Int
+	At     time.Time
+	Block  uint64 // Estimated inclusion block
+}
+
+// Warning flags a linkage risk in a sweep plan.
+type Warning struct {
+	Severity  string
+	Code      string
+	Message   string
+	Addresses []common.Address
+}
+
+// Plan is a proposed sweep schedule with the linkage it would create.
+type Plan struct {
+	Sweeps []*Sweep
+	// LinkedPairs counts pairs of stealth addresses an observer could link through the plan.
+	LinkedPairs int
+	Warnings    []Warning
+}
+
+// PlanSweeps proposes a schedule that sweeps every balance while linking as few stealth addresses as possible:
+// addresses are spread evenly over the destinations in random order, all balances of one address move
+// together, and consecutive sweeps are separated by a random delay so no two share a block. Destinations
+// that are also main addresses are left out, since sweeping into them links the stealth address outright.
+func PlanSweeps(balances []StealthBalance, opts Options) (*Plan, error) {
+	if len(balances) == 0 {
+		return nil, ErrNoBalances
+	}
+	opts.Destinations = excludeMain(opts.Destinations, opts.MainAddresses)
+	if len(opts.Destinations) == 0 {
+		return nil, ErrNoDestinations
+	}
+	if opts.BlockTime == 0 {
+		opts.BlockTime = DefaultBlockTime
+	}
+	if opts.MinDelay < opts.BlockTime || opts.MaxDelay < opts.MinDelay {
+		return nil, fmt.Errorf("%w: need block time <= min delay <= max delay", ErrInvalidDelay)
+	}
+
+	// Group balances by stealth address
+	byAddress := make(map[common.Address][]StealthBalance)
+	var addresses []common.Address
+	for _, b := range balances {
+		if _, ok := byAddress[b.Address]; !ok {
+			addresses = append(addresses, b.Address)
+		}
+		byAddress[b.Address] = append(byAddress[b.Address], b)
+	}
+	if opts.MaxPerGroup > 0 && len(addresses) > opts.MaxPerGroup*len(opts.Destinations) {
+		return nil, ErrNotEnoughDestinations
+	}
+	log.Printf("Planning sweeps for %d stealth addresses into %d destinations\n", len(addresses), len(opts.Destinations))
+
+	if err := shuffle(addresses); err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{}
+	at := opts.Start
+	for i, addr := range addresses {
+		// Round-robin keeps the groups as even as possible, which minimises linked pairs
+		destination := opts.Destinations[i%len(opts.Destinations)]
+		if i > 0 {
+			delay, err := randomDuration(opts.MinDelay, opts.MaxDelay)
+			if err != nil {
+				return nil, err
+			}
+			at = at.Add(delay)
+		}
+		block := opts.StartBlock + uint64(at.Sub(opts.Start)/opts.BlockTime)
+		for _, b := range byAddress[addr] {
+			plan.Sweeps = append(plan.Sweeps, &Sweep{
+				From:   addr,
+				To:     destination,
+				Token:  b.Token,
+				Amount: new(big.Int).Set(b.Amount),
+				At:     at,
+				Block:  block,
+			})
+		}
+	}
+
+	plan.LinkedPairs, plan.Warnings = Evaluate(plan.Sweeps, opts.MainAddresses)
+	log.Printf("Sweep plan links %d address pairs with %d warnings\n", plan.LinkedPairs, len(plan.Warnings))
+	return plan, nil
+}
+
+// Evaluate reports the linkage a set of sweeps creates: stealth addresses merged into one destination,
+// merges landing in the same block, and sweeps into the recipient's known main addresses.
+func Evaluate(sweeps []*Sweep, mainAddresses []common.Address) (int, []Warning) {
+	main := make(map[common.Address]bool, len(mainAddresses))
+	for _, a := range mainAddresses {
+		main[a] = true
+	}
+
+	sourcesByDestination := make(map[common.Address][]common.Address)
+	sourcesByBlock := make(map[uint64][]common.Address)
+	var warnings []Warning
+	for _, s := range sweeps {
+		sourcesByDestination[s.To] = appendUnique(sourcesByDestination[s.To], s.From)
+		sourcesByBlock[s.Block] = appendUnique(sourcesByBlock[s.Block], s.From)
+	}
+
+	linkedPairs := 0
+	for _, destination := range sortedKeys(sourcesByDestination) {
+		sources := sourcesByDestination[destination]
+		linkedPairs += len(sources) * (len(sources) - 1) / 2
+		if main[destination] {
+			warnings = append(warnings, Warning{
+				Severity:  SeverityHigh,
+				Code:      WarnMainDestination,
+				Message:   fmt.Sprintf("%d stealth addresses are swept into known main address %s", len(sources), destination.Hex()),
+				Addresses: sources,
+			})
+		}
+		if len(sources) > 1 {
+			warnings = append(warnings, Warning{
+				Severity:  SeverityMedium,
+				Code:      WarnCommonDestination,
+				Message:   fmt.Sprintf("%d stealth addresses share destination %s", len(sources), destination.Hex()),
+				Addresses: sources,
+			})
+		}
+	}
+
+	blocks := make([]uint64, 0, len(sourcesByBlock))
+	for block := range sourcesByBlock {
+		blocks = append(blocks, block)
+	}
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i] < blocks[j] })
+	for _, block := range blocks {
+		if sources := sourcesByBlock[block]; len(sources) > 1 {
+			warnings = append(warnings, Warning{
+				Severity:  SeverityHigh,
+				Code:      WarnSameBlockMerge,
+				Message:   fmt.Sprintf("%d stealth addresses are swept in block %d", len(sources), block),
+				Addresses: sources,
+			})
+		}
+	}
+	return linkedPairs, warnings
+}
+
+// excludeMain returns the destinations that are not main addresses.
+func excludeMain(destinations, mainAddresses []common.Address) []common.Address {
+	main := make(map[common.Address]bool, len(mainAddresses))
+	for _, a := range mainAddresses {
+		main[a] = true
+	}
+	kept := make([]common.Address, 0, len(destinations))
+	for _, d := range destinations {
+		if main[d] {
+			log.Printf("Leaving main address %s out of the sweep destinations\n", d.Hex())
+			continue
+		}
+		kept = append(kept, d)
+	}
+	return kept
+}
+
+func appendUnique(list []common.Address, a common.Address) []common.Address {
+	for _, existing := range list {
+		if existing == a {
+			return list
+		}
+	}
+	return append(list, a)
+}
+
+func sortedKeys(m map[common.Address][]common.Address) []common.Address {
+	keys := make([]common.Address, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Cmp(keys[j]) < 0 })
+	return keys
+}
+
+// shuffle performs a Fisher-Yates shuffle using crypto/rand, so the order reveals nothing about discovery order.
+func shuffle(addresses []common.Address) error {
+	for i := len(addresses) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return err
+		}
+		addresses[i], addresses[j.Int64()] = addresses[j.Int64()], addresses[i]
+	}
+	return nil
+}
+
+func randomDuration(min, max time.Duration) (time.Duration, error) {
+	if max == min {
+		return min, nil
+	}
+	r, err := rand.Int(rand.Reader, big.NewInt(int64(max-min)))
+	if err != nil {
+		return 0, err
+	}
+	return min + time.Duration(r.Int64()), nil
+}
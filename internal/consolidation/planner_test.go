@@ -0,0 +1,137 @@
+package consolidation
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	eth  = common.HexToAddress("0xEeeeeeeeeeEeeeeEeeeeeeeEEeeeEeeeeeeeEEeE")
+	usdc = common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+)
+
+func testBalances(n int) []StealthBalance {
+	var balances []StealthBalance
+	for i := 1; i <= n; i++ {
+		addr := common.BigToAddress(big.NewInt(int64(i)))
+		balances = append(balances, StealthBalance{Address: addr, Token: eth, Amount: big.NewInt(int64(i) * 1e15)})
+	}
+	return balances
+}
+
+func TestPlanSweepsMinimisesLinkage(t *testing.T) {
+	balances := testBalances(4)
+	// The first address also holds a token; both balances must move together
+	balances = append(balances, StealthBalance{Address: balances[0].Address, Token: usdc, Amount: big.NewInt(5e6)})
+
+	destinations := []common.Address{{0xd1}, {0xd2}}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	plan, err := PlanSweeps(balances, Options{
+		Destinations: destinations,
+		MinDelay:     time.Hour,
+		MaxDelay:     3 * time.Hour,
+		Start:        start,
+		StartBlock:   1000,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, plan.Sweeps, 5)
+
+	// 4 addresses over 2 destinations: 2 + 2 is the least linkage possible
+	assert.Equal(t, 2, plan.LinkedPairs)
+	for _, w := range plan.Warnings {
+		assert.Equal(t, WarnCommonDestination, w.Code)
+	}
+
+	destinationOf := make(map[common.Address]common.Address)
+	blockOf := make(map[common.Address]uint64)
+	for _, s := range plan.Sweeps {
+		if d, ok := destinationOf[s.From]; ok {
+			assert.Equal(t, d, s.To)
+			assert.Equal(t, blockOf[s.From], s.Block)
+		}
+		destinationOf[s.From] = s.To
+		blockOf[s.From] = s.Block
+		assert.False(t, s.At.Before(start))
+	}
+	blocks := make(map[uint64]bool)
+	for _, b := range blockOf {
+		assert.False(t, blocks[b], "two addresses swept in the same block")
+		blocks[b] = true
+	}
+}
+
+func TestPlanSweepsOneToOne(t *testing.T) {
+	plan, err := PlanSweeps(testBalances(3), Options{
+		Destinations: []common.Address{{0xd1}, {0xd2}, {0xd3}},
+		MinDelay:     time.Minute,
+		MaxDelay:     time.Minute,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, plan.LinkedPairs)
+	assert.Empty(t, plan.Warnings)
+}
+
+func TestPlanSweepsAvoidsMainAddresses(t *testing.T) {
+	main := common.Address{0xaa}
+	opts := Options{
+		Destinations:  []common.Address{{0xd1}, main, {0xd2}},
+		MainAddresses: []common.Address{main},
+		MinDelay:      time.Minute,
+		MaxDelay:      time.Minute,
+	}
+	plan, err := PlanSweeps(testBalances(6), opts)
+	assert.NoError(t, err)
+	for _, s := range plan.Sweeps {
+		assert.NotEqual(t, main, s.To)
+	}
+	for _, w := range plan.Warnings {
+		assert.NotEqual(t, WarnMainDestination, w.Code)
+	}
+	assert.Equal(t, []common.Address{{0xd1}, main, {0xd2}}, opts.Destinations, "the caller's slice is left as is")
+
+	// Only main addresses leaves nowhere to sweep to
+	opts.Destinations = []common.Address{main}
+	_, err = PlanSweeps(testBalances(2), opts)
+	assert.ErrorIs(t, err, ErrNoDestinations)
+}
+
+func TestEvaluateFlagsRiskySweeps(t *testing.T) {
+	main := common.Address{0xaa}
+	a, b := common.Address{0x01}, common.Address{0x02}
+
+	linked, warnings := Evaluate([]*Sweep{
+		{From: a, To: main, Token: eth, Amount: big.NewInt(1), Block: 10},
+		{From: b, To: main, Token: eth, Amount: big.NewInt(1), Block: 10},
+	}, []common.Address{main})
+	assert.Equal(t, 1, linked)
+
+	codes := make(map[string]string)
+	for _, w := range warnings {
+		codes[w.Code] = w.Severity
+		assert.ElementsMatch(t, []common.Address{a, b}, w.Addresses)
+	}
+	assert.Equal(t, map[string]string{
+		WarnMainDestination:   SeverityHigh,
+		WarnCommonDestination: SeverityMedium,
+		WarnSameBlockMerge:    SeverityHigh,
+	}, codes)
+}
+
+func TestPlanSweepsRejectsBadOptions(t *testing.T) {
+	_, err := PlanSweeps(nil, Options{Destinations: []common.Address{{0xd1}}, MinDelay: time.Minute, MaxDelay: time.Minute})
+	assert.ErrorIs(t, err, ErrNoBalances)
+
+	_, err = PlanSweeps(testBalances(2), Options{MinDelay: time.Minute, MaxDelay: time.Minute})
+	assert.ErrorIs(t, err, ErrNoDestinations)
+
+	_, err = PlanSweeps(testBalances(3), Options{Destinations: []common.Address{{0xd1}}, MaxPerGroup: 2, MinDelay: time.Minute, MaxDelay: time.Minute})
+	assert.ErrorIs(t, err, ErrNotEnoughDestinations)
+
+	// Sweeps closer than a block apart could share a block
+	_, err = PlanSweeps(testBalances(2), Options{Destinations: []common.Address{{0xd1}}, MinDelay: time.Second, MaxDelay: time.Minute})
+	assert.ErrorIs(t, err, ErrInvalidDelay)
+}
@@ -0,0 +1,227 @@
+package ephemeral
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/prikshit/blockchain-privacy-module/internal/bloom"
+)
+
+// recordSize is the size of one stored key: a compressed secp256k1 public key.
+const recordSize = 33
+
+// Defaults used when Options leaves a field unset.
+const (
+	DefaultExpectedKeys      = 1_000_000
+	DefaultFalsePositiveRate = 1e-6
+)
+
+var ErrKeyReused = errors.New("ephemeral key already used")
+
+// Options sizes the in-memory Bloom filter.
+type Options struct {
+	ExpectedKeys      uint64
+	FalsePositiveRate float64
+}
+
+// Stats describes the store for operators.
+type Stats struct {
+	Keys              uint64  `json:"keys"`
+	Persistent        bool    `json:"persistent"`
+	FilterBytes       uint64  `json:"filter_bytes"`
+	FalsePositiveRate float64 `json:"false_positive_rate"`
+	ExactLookups      uint64  `json:"exact_lookups"` // Filter hits that needed the exact store
+}
+
+// KeyStore records every ephemeral public key ever used. A Bloom filter answers most lookups from memory;
+// only filter hits consult the exact store, an append-only file of compressed keys (or a map when in-memory).
+type KeyStore struct {
+	mu           sync.Mutex
+	filter       *bloom.Filter
+	file         *os.File            // nil for an in-memory store
+	memory       map[string]struct{} // Exact store of an in-memory store
+	count        uint64
+	exactLookups uint64
+}
+
+// NewMemoryStore returns a store that is lost on restart, for tests and single-run tools.
+func NewMemoryStore(opts Options) *KeyStore {
+	opts = withDefaults(opts)
+	return &KeyStore{
+		filter: bloom.New(opts.ExpectedKeys, opts.FalsePositiveRate),
+		memory: make(map[string]struct{}),
+	}
+}
+
+// Open loads the key log at path, creating it if needed, and rebuilds the Bloom filter from it.
+// A partial record left by an interrupted write is truncated.
+func Open(path string, opts Options) (*KeyStore, error) {
+	opts = withDefaults(opts)
+	log.Printf("Opening ephemeral key store at %s\n", path)
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	count := uint64(info.Size()) / recordSize
+	if rem := info.Size() % recordSize; rem != 0 {
+		log.Printf("Truncating %d bytes of a partial ephemeral key record\n", rem)
+		if err := file.Truncate(int64(count * recordSize)); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	s := &KeyStore{
+		filter: bloom.New(max(opts.ExpectedKeys, count), opts.FalsePositiveRate),
+		file:   file,
+		count:  count,
+	}
+	duplicates := 0
+	err = s.scan(func(key []byte) bool {
+		if s.filter.Test(key) {
+			duplicates++
+		}
+		s.filter.Add(key)
+		return true
+	})
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err := file.Seek(0, io.SeekEnd); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if duplicates > 0 {
+		// Filter hits while loading are only candidates; the audit endpoint confirms them
+		log.Printf("Ephemeral key store has %d possible duplicate keys; run an audit\n", duplicates)
+	}
+
+	log.Printf("Loaded %d ephemeral keys\n", count)
+	return s, nil
+}
+
+// Record stores pub, failing with ErrKeyReused if it was recorded before.
+func (s *KeyStore) Record(pub *ecdsa.PublicKey) error {
+	key := crypto.CompressPubkey(pub)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	found, err := s.contains(key)
+	if err != nil {
+		return err
+	}
+	if found {
+		log.Printf("Ephemeral key reuse detected: %x\n", key)
+		return ErrKeyReused
+	}
+
+	if s.file != nil {
+		// The key must be durable before it is handed out
+		if _, err := s.file.Write(key); err != nil {
+			return fmt.Errorf("recording ephemeral key: %w", err)
+		}
+		if err := s.file.Sync(); err != nil {
+			return fmt.Errorf("recording ephemeral key: %w", err)
+		}
+	} else {
+		s.memory[string(key)] = struct{}{}
+	}
+	s.filter.Add(key)
+	s.count++
+	return nil
+}
+
+// Contains reports whether pub has been recorded.
+func (s *KeyStore) Contains(pub *ecdsa.PublicKey) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.contains(crypto.CompressPubkey(pub))
+}
+
+// Stats returns the current size and filter accuracy of the store.
+func (s *KeyStore) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Stats{
+		Keys:              s.count,
+		Persistent:        s.file != nil,
+		FilterBytes:       s.filter.SizeBytes(),
+		FalsePositiveRate: s.filter.FalsePositiveRate(),
+		ExactLookups:      s.exactLookups,
+	}
+}
+
+// Close releases the key log.
+func (s *KeyStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	return s.file.Close()
+}
+
+func (s *KeyStore) contains(key []byte) (bool, error) {
+	if !s.filter.Test(key) {
+		return false, nil
+	}
+	s.exactLookups++
+	if s.file == nil {
+		_, ok := s.memory[string(key)]
+		return ok, nil
+	}
+
+	found := false
+	err := s.scan(func(record []byte) bool {
+		found = bytes.Equal(record, key)
+		return !found
+	})
+	return found, err
+}
+
+// scan calls fn for every stored record until fn returns false.
+func (s *KeyStore) scan(fn func(key []byte) bool) error {
+	buf := make([]byte, recordSize*4096)
+	size := int64(s.count * recordSize)
+	for offset := int64(0); offset < size; {
+		n, err := s.file.ReadAt(buf[:min(int64(len(buf)), size-offset)], offset)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		for i := 0; i+recordSize <= n; i += recordSize {
+			if !fn(buf[i : i+recordSize]) {
+				return nil
+			}
+		}
+		offset += int64(n)
+		if n == 0 {
+			return io.ErrUnexpectedEOF
+		}
+	}
+	return nil
+}
+
+func withDefaults(opts Options) Options {
+	if opts.ExpectedKeys == 0 {
+		opts.ExpectedKeys = DefaultExpectedKeys
+	}
+	if opts.FalsePositiveRate <= 0 || opts.FalsePositiveRate >= 1 {
+		opts.FalsePositiveRate = DefaultFalsePositiveRate
+	}
+	return opts
+}
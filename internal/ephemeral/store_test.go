@@ -0,0 +1,78 @@
+package ephemeral
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStoreRejectsReuse(t *testing.T) {
+	s := NewMemoryStore(Options{ExpectedKeys: 100})
+
+	key, _ := crypto.GenerateKey()
+	assert.NoError(t, s.Record(&key.PublicKey))
+	assert.ErrorIs(t, s.Record(&key.PublicKey), ErrKeyReused)
+
+	other, _ := crypto.GenerateKey()
+	found, err := s.Contains(&other.PublicKey)
+	assert.NoError(t, err)
+	assert.False(t, found)
+	assert.Equal(t, uint64(1), s.Stats().Keys)
+}
+
+func TestStorePersistsAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ephemeral.keys")
+
+	s, err := Open(path, Options{ExpectedKeys: 100})
+	assert.NoError(t, err)
+	first, _ := crypto.GenerateKey()
+	second, _ := crypto.GenerateKey()
+	assert.NoError(t, s.Record(&first.PublicKey))
+	assert.NoError(t, s.Record(&second.PublicKey))
+	assert.NoError(t, s.Close())
+
+	s, err = Open(path, Options{ExpectedKeys: 100})
+	assert.NoError(t, err)
+	defer s.Close()
+	assert.True(t, s.Stats().Persistent)
+	assert.Equal(t, uint64(2), s.Stats().Keys)
+	assert.ErrorIs(t, s.Record(&first.PublicKey), ErrKeyReused)
+	assert.ErrorIs(t, s.Record(&second.PublicKey), ErrKeyReused)
+
+	third, _ := crypto.GenerateKey()
+	assert.NoError(t, s.Record(&third.PublicKey))
+	found, err := s.Contains(&third.PublicKey)
+	assert.NoError(t, err)
+	assert.True(t, found)
+}
+
+func TestOpenTruncatesPartialRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ephemeral.keys")
+	key, _ := crypto.GenerateKey()
+	data := append(crypto.CompressPubkey(&key.PublicKey), 0x02, 0x11) // Interrupted second write
+	assert.NoError(t, os.WriteFile(path, data, 0o600))
+
+	s, err := Open(path, Options{})
+	assert.NoError(t, err)
+	defer s.Close()
+	assert.Equal(t, uint64(1), s.Stats().Keys)
+	assert.ErrorIs(t, s.Record(&key.PublicKey), ErrKeyReused)
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(recordSize), info.Size())
+}
+
+func TestExactStoreResolvesFilterFalsePositives(t *testing.T) {
+	// A tiny, saturated filter answers "maybe" for everything, so the exact store decides
+	s := NewMemoryStore(Options{ExpectedKeys: 1, FalsePositiveRate: 0.5})
+	for range 50 {
+		key, _ := crypto.GenerateKey()
+		assert.NoError(t, s.Record(&key.PublicKey))
+	}
+	assert.Equal(t, uint64(50), s.Stats().Keys)
+	assert.Positive(t, s.Stats().ExactLookups)
+}
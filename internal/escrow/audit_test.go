@@ -0,0 +1,29 @@
+package escrow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLogHashChain(t *testing.T) {
+	store := NewMemoryStore()
+	audit := &AuditLog{}
+	first, err := audit.appendWith("alice", "escrow_registered", "e1", "", "", store.AppendAudit)
+	require.NoError(t, err)
+	second, err := audit.appendWith("bob", "release_requested", "e1", "r1", "court order: CASE-1", store.AppendAudit)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(1), first.Seq)
+	assert.Equal(t, "", first.PrevHash)
+	assert.Equal(t, first.Hash, second.PrevHash)
+	assert.NoError(t, audit.Verify())
+	_, _, stored, err := store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, audit.Entries(), stored)
+
+	// Tampering with a stored entry breaks the chain
+	audit.entries[0].Actor = "mallory"
+	assert.ErrorIs(t, audit.Verify(), ErrAuditChainBroken)
+}
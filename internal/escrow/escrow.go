@@ -0,0 +1,427 @@
+package escrow
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"math/big"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/ecies"
+	"github.com/prikshit/blockchain-privacy-module/internal/privacy"
+	"github.com/prikshit/blockchain-privacy-module/internal/threshold"
+)
+
+// Release request states.
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusExecuted = "executed"
+)
+
+var (
+	ErrUnknownEscrow         = errors.New("unknown escrow record")
+	ErrUnknownRequest        = errors.New("unknown release request")
+	ErrUnknownMember         = errors.New("unknown committee member")
+	ErrViewingKeyMismatch    = errors.New("viewing key does not match the meta-address")
+	ErrHybridNotSupported    = errors.New("escrow of hybrid meta-addresses is not supported")
+	ErrInvalidShare          = errors.New("share does not match the escrow commitment")
+	ErrAlreadyApproved       = errors.New("member has already approved this request")
+	ErrRequestNotApproved    = errors.New("release request does not have enough approvals")
+	ErrRequestClosed         = errors.New("release request has already been executed")
+	ErrMissingCourtReference = errors.New("release request needs a court order reference")
+	ErrSharesNotHeld         = errors.New("approved shares are no longer held; approvers must submit them again")
+)
+
+// Member is an escrow committee member; shares are encrypted to PublicKey with ECIES.
+type Member struct {
+	ID        string           `json:"id"`
+	PublicKey *ecdsa.PublicKey `json:"-"`
+}
+
+// Committee is the set of members and the number of approvals needed to release a viewing key.
+type Committee struct {
+	Threshold int
+	Members   []Member
+}
+
+// EncryptedShare is a committee member's share of an escrowed viewing key.
+// PublicShare is share * G and lets the escrow check a submitted share without decrypting anything.
+type EncryptedShare struct {
+	MemberID    string           `json:"member_id"`
+	Index       int              `json:"index"`
+	Ciphertext  []byte           `json:"ciphertext"`
+	PublicShare *ecdsa.PublicKey `json:"-"`
+}
+
+// Record is a viewing key escrowed alongside the meta-address it belongs to.
+type Record struct {
+	ID          string
+	MetaAddress *privacy.StealthMetaAddress
+	Threshold   int
+	Shares      []EncryptedShare
+	CreatedAt   time.Time
+}
+
+// ReleaseRequest is a court-ordered request to reconstruct an escrowed viewing key.
+// Only the approving members are stored; their decrypted shares are held in memory until the request
+// is executed, so a copy of the store never holds enough to rebuild the key.
+type ReleaseRequest struct {
+	ID          string    `json:"id"`
+	EscrowID    string    `json:"escrow_id"`
+	CourtOrder  string    `json:"court_order"`
+	RequestedBy string    `json:"requested_by"`
+	Status      string    `json:"status"`
+	Approvals   []string  `json:"approvals"` // IDs of the approving members, in order
+	CreatedAt   time.Time `json:"created_at"`
+
+	shares map[string]*big.Int // member ID -> decrypted share, never stored
+}
+
+// ApprovedBy lists the members who approved the request.
+func (r *ReleaseRequest) ApprovedBy() []string {
+	return slices.Clone(r.Approvals)
+}
+
+// snapshot copies the request without its shares, for callers and the store.
+func (r *ReleaseRequest) snapshot() *ReleaseRequest {
+	c := *r
+	c.Approvals = slices.Clone(r.Approvals)
+	c.shares = nil
+	return &c
+}
+
+// clone copies the request with its shares, for a state change that may not be stored.
+func (r *ReleaseRequest) clone() *ReleaseRequest {
+	c := r.snapshot()
+	c.shares = maps.Clone(r.shares)
+	if c.shares == nil {
+		c.shares = make(map[string]*big.Int)
+	}
+	return c
+}
+
+// Escrow holds viewing keys encrypted to a committee and runs the k-of-n release workflow.
+// Every state change is recorded in the audit log, and written to the store with its audit entry
+// before it takes effect. mu serialises state changes.
+type Escrow struct {
+	committee Committee
+	records   map[string]*Record
+	requests  map[string]*ReleaseRequest
+	audit     *AuditLog
+	store     Store
+	mu        sync.Mutex
+}
+
+// NewEscrow creates an escrow for the given committee, kept in memory only.
+func NewEscrow(committee Committee) (*Escrow, error) {
+	return LoadEscrow(committee, NewMemoryStore())
+}
+
+// LoadEscrow creates an escrow for the given committee from the records, requests and audit log in store.
+// It refuses a store whose audit log fails verification.
+func LoadEscrow(committee Committee, store Store) (*Escrow, error) {
+	if committee.Threshold < 2 || committee.Threshold > len(committee.Members) {
+		return nil, threshold.ErrInvalidThreshold
+	}
+	records, requests, entries, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	e := &Escrow{
+		committee: committee,
+		records:   make(map[string]*Record, len(records)),
+		requests:  make(map[string]*ReleaseRequest, len(requests)),
+		audit:     &AuditLog{entries: entries},
+		store:     store,
+	}
+	for _, record := range records {
+		e.records[record.ID] = record
+	}
+	for _, req := range requests {
+		e.requests[req.ID] = req
+	}
+	if err := e.audit.Verify(); err != nil {
+		return nil, fmt.Errorf("loading escrow store: %w", err)
+	}
+	log.Printf("Initializing viewing key escrow with a %d-of-%d committee, %d records and %d audit entries\n",
+		committee.Threshold, len(committee.Members), len(records), len(entries))
+	return e, nil
+}
+
+// Close releases the store.
+func (e *Escrow) Close() error {
+	return e.store.Close()
+}
+
+// LoadCommitteeFile reads a committee from a JSON file of the form
+// {"threshold": 2, "members": [{"id": "alice", "public_key": "0x04..."}]}.
+func LoadCommitteeFile(path string) (Committee, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Committee{}, err
+	}
+
+	var file struct {
+		Threshold int `json:"threshold"`
+		Members   []struct {
+			ID        string `json:"id"`
+			PublicKey string `json:"public_key"`
+		} `json:"members"`
+	}
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return Committee{}, fmt.Errorf("parsing committee file: %w", err)
+	}
+
+	committee := Committee{Threshold: file.Threshold}
+	for _, m := range file.Members {
+		pub, err := crypto.UnmarshalPubkey(common.FromHex(m.PublicKey))
+		if err != nil {
+			return Committee{}, fmt.Errorf("member %s: invalid public key: %w", m.ID, err)
+		}
+		committee.Members = append(committee.Members, Member{ID: m.ID, PublicKey: pub})
+	}
+	return committee, nil
+}
+
+// Audit returns the escrow's audit log.
+func (e *Escrow) Audit() *AuditLog {
+	return e.audit
+}
+
+// Register splits viewingKey into committee shares, encrypts each to its member and stores the record.
+// The plaintext key is not retained.
+func (e *Escrow) Register(actor string, meta *privacy.StealthMetaAddress, viewingKey *ecdsa.PrivateKey) (*Record, error) {
+	if meta.IsHybrid() {
+		return nil, ErrHybridNotSupported
+	}
+	if viewingKey.PublicKey.X.Cmp(meta.ViewingPubKey.X) != 0 || viewingKey.PublicKey.Y.Cmp(meta.ViewingPubKey.Y) != 0 {
+		return nil, ErrViewingKeyMismatch
+	}
+
+	shares, err := threshold.SplitPrivateKey(viewingKey, e.committee.Threshold, len(e.committee.Members))
+	if err != nil {
+		return nil, err
+	}
+
+	record := &Record{
+		ID:          newID(),
+		MetaAddress: meta,
+		Threshold:   e.committee.Threshold,
+		CreatedAt:   time.Now().UTC(),
+	}
+	for i, member := range e.committee.Members {
+		ciphertext, err := ecies.Encrypt(rand.Reader, ecies.ImportECDSAPublic(member.PublicKey), shares[i].Value.FillBytes(make([]byte, 32)), nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("encrypting share for %s: %w", member.ID, err)
+		}
+		record.Shares = append(record.Shares, EncryptedShare{
+			MemberID:    member.ID,
+			Index:       shares[i].Index,
+			Ciphertext:  ciphertext,
+			PublicShare: shares[i].PublicShare(),
+		})
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err = e.audit.appendWith(actor, "escrow_registered", record.ID, "", meta.String(), func(entry AuditEntry) error {
+		return e.store.PutRecord(record, entry)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storing escrow record: %w", err)
+	}
+	e.records[record.ID] = record
+	return record, nil
+}
+
+// Record returns an escrow record by ID.
+func (e *Escrow) Record(id string) (*Record, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	record, ok := e.records[id]
+	if !ok {
+		return nil, ErrUnknownEscrow
+	}
+	return record, nil
+}
+
+// RequestRelease opens a release request for an escrow record under a court order.
+func (e *Escrow) RequestRelease(requestedBy, escrowID, courtOrder string) (*ReleaseRequest, error) {
+	if courtOrder == "" {
+		return nil, ErrMissingCourtReference
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.records[escrowID]; !ok {
+		return nil, ErrUnknownEscrow
+	}
+	req := &ReleaseRequest{
+		ID:          newID(),
+		EscrowID:    escrowID,
+		CourtOrder:  courtOrder,
+		RequestedBy: requestedBy,
+		Status:      StatusPending,
+		CreatedAt:   time.Now().UTC(),
+	}
+	_, err := e.audit.appendWith(requestedBy, "release_requested", escrowID, req.ID, "court order: "+courtOrder, func(entry AuditEntry) error {
+		return e.store.PutRequest(req, entry)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storing release request: %w", err)
+	}
+	e.requests[req.ID] = req
+	return req.snapshot(), nil
+}
+
+// Request returns a release request by ID.
+func (e *Escrow) Request(id string) (*ReleaseRequest, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	req, ok := e.requests[id]
+	if !ok {
+		return nil, ErrUnknownRequest
+	}
+	return req.snapshot(), nil
+}
+
+// Approve records a member's approval and holds their decrypted share until the request is executed.
+// The share is checked against the commitment stored at registration before it is accepted. A member whose
+// share was lost in a restart submits it again the same way.
+func (e *Escrow) Approve(requestID, memberID string, share *big.Int) (*ReleaseRequest, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	req, ok := e.requests[requestID]
+	if !ok {
+		return nil, ErrUnknownRequest
+	}
+	if req.Status == StatusExecuted {
+		return nil, ErrRequestClosed
+	}
+	if _, ok := req.shares[memberID]; ok {
+		return nil, ErrAlreadyApproved
+	}
+
+	encrypted, err := findShare(e.records[req.EscrowID], memberID)
+	if err != nil {
+		return nil, err
+	}
+	x, y := crypto.S256().ScalarBaseMult(share.FillBytes(make([]byte, 32)))
+	if x.Cmp(encrypted.PublicShare.X) != 0 || y.Cmp(encrypted.PublicShare.Y) != 0 {
+		_, err := e.audit.appendWith(memberID, "approval_rejected", req.EscrowID, requestID, "share does not match commitment", e.store.AppendAudit)
+		return nil, errors.Join(ErrInvalidShare, err)
+	}
+
+	approved := req.clone()
+	approved.shares[memberID] = share
+	if slices.Contains(req.Approvals, memberID) {
+		_, err = e.audit.appendWith(memberID, "share_resubmitted", req.EscrowID, requestID, "", e.store.AppendAudit)
+	} else {
+		approved.Approvals = append(approved.Approvals, memberID)
+		if len(approved.Approvals) >= e.records[req.EscrowID].Threshold {
+			approved.Status = StatusApproved
+		}
+		_, err = e.audit.appendWith(memberID, "release_approved", req.EscrowID, requestID, "status: "+approved.Status, func(entry AuditEntry) error {
+			return e.store.PutRequest(approved.snapshot(), entry)
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("storing approval: %w", err)
+	}
+	e.requests[requestID] = approved
+	return approved.snapshot(), nil
+}
+
+// Execute reconstructs the viewing key of an approved request and hands it, with the meta-address, to scan.
+// The request is closed once the key is reconstructed, so every further disclosure needs fresh approvals.
+// If reconstruction fails the request stays approved and can be executed again. After a restart the
+// approvers' shares must be submitted again first.
+func (e *Escrow) Execute(requestID, actor string, scan func(viewingKey *ecdsa.PrivateKey, meta *privacy.StealthMetaAddress) int) error {
+	e.mu.Lock()
+	req, ok := e.requests[requestID]
+	if !ok {
+		e.mu.Unlock()
+		return ErrUnknownRequest
+	}
+	switch req.Status {
+	case StatusExecuted:
+		e.mu.Unlock()
+		return ErrRequestClosed
+	case StatusPending:
+		e.mu.Unlock()
+		return ErrRequestNotApproved
+	}
+	record := e.records[req.EscrowID]
+	if len(req.shares) < record.Threshold {
+		e.mu.Unlock()
+		return fmt.Errorf("%w: %d of %d held", ErrSharesNotHeld, len(req.shares), record.Threshold)
+	}
+
+	shares := make([]*threshold.KeyShare, 0, len(req.shares))
+	for memberID, value := range req.shares {
+		encrypted, _ := findShare(record, memberID)
+		shares = append(shares, &threshold.KeyShare{
+			Index:     encrypted.Index,
+			Threshold: record.Threshold,
+			Value:     value,
+			PublicKey: record.MetaAddress.ViewingPubKey,
+		})
+	}
+	viewingKey, err := threshold.CombineShares(shares)
+	if err != nil {
+		_, auditErr := e.audit.appendWith(actor, "release_failed", record.ID, requestID, err.Error(), e.store.AppendAudit)
+		e.mu.Unlock()
+		return errors.Join(err, auditErr)
+	}
+
+	closed := req.snapshot() // Drops the decrypted shares
+	closed.Status = StatusExecuted
+	_, err = e.audit.appendWith(actor, "viewing_key_reconstructed", record.ID, requestID, "", func(entry AuditEntry) error {
+		return e.store.PutRequest(closed, entry)
+	})
+	if err != nil {
+		e.mu.Unlock()
+		return fmt.Errorf("closing release request: %w", err)
+	}
+	e.requests[requestID] = closed
+	e.mu.Unlock()
+
+	matches := scan(viewingKey, record.MetaAddress)
+	_, err = e.audit.appendWith(actor, "scan_completed", record.ID, requestID, fmt.Sprintf("%d payments disclosed", matches), e.store.AppendAudit)
+	return err
+}
+
+// DecryptShare is run offline by a committee member to recover their share of an escrowed viewing key.
+func DecryptShare(memberKey *ecdsa.PrivateKey, share EncryptedShare) (*big.Int, error) {
+	plaintext, err := ecies.ImportECDSA(memberKey).Decrypt(share.Ciphertext, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(plaintext), nil
+}
+
+func findShare(record *Record, memberID string) (*EncryptedShare, error) {
+	for i := range record.Shares {
+		if record.Shares[i].MemberID == memberID {
+			return &record.Shares[i], nil
+		}
+	}
+	return nil, ErrUnknownMember
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
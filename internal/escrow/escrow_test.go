@@ -0,0 +1,211 @@
+package escrow
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/prikshit/blockchain-privacy-module/internal/privacy"
+	"github.com/prikshit/blockchain-privacy-module/internal/sanctions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCommittee(t *testing.T, threshold int, ids ...string) (Committee, map[string]*ecdsa.PrivateKey) {
+	committee := Committee{Threshold: threshold}
+	keys := make(map[string]*ecdsa.PrivateKey)
+	for _, id := range ids {
+		key, err := ecdsa.GenerateKey(crypto.S256(), rand.Reader)
+		assert.NoError(t, err)
+		keys[id] = key
+		committee.Members = append(committee.Members, Member{ID: id, PublicKey: &key.PublicKey})
+	}
+	return committee, keys
+}
+
+func newTestStealthKeys(t *testing.T) *privacy.StealthKeys {
+	spendingKey, err := ecdsa.GenerateKey(crypto.S256(), rand.Reader)
+	assert.NoError(t, err)
+	viewingKey, err := ecdsa.GenerateKey(crypto.S256(), rand.Reader)
+	assert.NoError(t, err)
+	return &privacy.StealthKeys{SpendingKey: spendingKey, ViewingKey: viewingKey}
+}
+
+func TestEscrowReleaseWorkflow(t *testing.T) {
+	committee, memberKeys := newTestCommittee(t, 2, "alice", "bob", "carol")
+	esc, err := NewEscrow(committee)
+	assert.NoError(t, err)
+
+	keys := newTestStealthKeys(t)
+	record, err := esc.Register("customer", keys.MetaAddress(), keys.ViewingKey)
+	assert.NoError(t, err)
+	assert.Len(t, record.Shares, 3)
+
+	// A payment the regulator will later disclose
+	pm := privacy.NewPrivacyManager(sanctions.NewDetector(nil))
+	_, ann, err := pm.GenerateStealthPayment(keys.MetaAddress())
+	assert.NoError(t, err)
+
+	req, err := esc.RequestRelease("regulator", record.ID, "CASE-2026-001")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusPending, req.Status)
+
+	// Executing before enough approvals fails
+	err = esc.Execute(req.ID, "regulator", func(*ecdsa.PrivateKey, *privacy.StealthMetaAddress) int { return 0 })
+	assert.ErrorIs(t, err, ErrRequestNotApproved)
+
+	for _, member := range []string{"alice", "carol"} {
+		share, err := DecryptShare(memberKeys[member], *findTestShare(record, member))
+		assert.NoError(t, err)
+		req, err = esc.Approve(req.ID, member, share)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, StatusApproved, req.Status)
+
+	var disclosed []*privacy.ScanMatch
+	err = esc.Execute(req.ID, "regulator", func(viewingKey *ecdsa.PrivateKey, meta *privacy.StealthMetaAddress) int {
+		assert.Equal(t, keys.ViewingKey.D, viewingKey.D)
+		disclosed = pm.ScanAnnouncements(viewingKey, meta.SpendingPubKey, nil, []*privacy.Announcement{ann})
+		return len(disclosed)
+	})
+	assert.NoError(t, err)
+	assert.Len(t, disclosed, 1)
+
+	// The request is single use
+	err = esc.Execute(req.ID, "regulator", func(*ecdsa.PrivateKey, *privacy.StealthMetaAddress) int { return 0 })
+	assert.ErrorIs(t, err, ErrRequestClosed)
+
+	// Every step was audited in an intact chain
+	var actions []string
+	for _, e := range esc.Audit().Entries() {
+		actions = append(actions, e.Action)
+	}
+	assert.Equal(t, []string{
+		"escrow_registered", "release_requested", "release_approved", "release_approved",
+		"viewing_key_reconstructed", "scan_completed",
+	}, actions)
+	assert.NoError(t, esc.Audit().Verify())
+}
+
+func TestEscrowRejectsInvalidInput(t *testing.T) {
+	committee, memberKeys := newTestCommittee(t, 2, "alice", "bob", "carol")
+	esc, err := NewEscrow(committee)
+	assert.NoError(t, err)
+
+	keys := newTestStealthKeys(t)
+	other := newTestStealthKeys(t)
+	_, err = esc.Register("customer", keys.MetaAddress(), other.ViewingKey)
+	assert.ErrorIs(t, err, ErrViewingKeyMismatch)
+
+	record, err := esc.Register("customer", keys.MetaAddress(), keys.ViewingKey)
+	assert.NoError(t, err)
+
+	_, err = esc.RequestRelease("regulator", record.ID, "")
+	assert.ErrorIs(t, err, ErrMissingCourtReference)
+	_, err = esc.RequestRelease("regulator", "missing", "CASE-1")
+	assert.ErrorIs(t, err, ErrUnknownEscrow)
+
+	req, err := esc.RequestRelease("regulator", record.ID, "CASE-1")
+	assert.NoError(t, err)
+
+	// A forged share is rejected and audited
+	_, err = esc.Approve(req.ID, "alice", big.NewInt(42))
+	assert.ErrorIs(t, err, ErrInvalidShare)
+
+	// Bob cannot submit Alice's share as his own
+	aliceShare, err := DecryptShare(memberKeys["alice"], *findTestShare(record, "alice"))
+	assert.NoError(t, err)
+	_, err = esc.Approve(req.ID, "bob", aliceShare)
+	assert.ErrorIs(t, err, ErrInvalidShare)
+
+	_, err = esc.Approve(req.ID, "alice", aliceShare)
+	assert.NoError(t, err)
+	_, err = esc.Approve(req.ID, "alice", aliceShare)
+	assert.ErrorIs(t, err, ErrAlreadyApproved)
+	_, err = esc.Approve(req.ID, "mallory", aliceShare)
+	assert.ErrorIs(t, err, ErrUnknownMember)
+
+	// Members cannot decrypt each other's shares
+	_, err = DecryptShare(memberKeys["bob"], *findTestShare(record, "alice"))
+	assert.Error(t, err)
+
+	_, err = NewEscrow(Committee{Threshold: 4, Members: committee.Members})
+	assert.Error(t, err)
+}
+
+func findTestShare(record *Record, memberID string) *EncryptedShare {
+	share, _ := findShare(record, memberID)
+	return share
+}
+
+func TestEscrowSurvivesRestart(t *testing.T) {
+	committee, memberKeys := newTestCommittee(t, 2, "alice", "bob", "carol")
+	path := filepath.Join(t.TempDir(), "escrow.db")
+
+	store, err := OpenBoltStore(path)
+	require.NoError(t, err)
+	esc, err := LoadEscrow(committee, store)
+	require.NoError(t, err)
+	keys := newTestStealthKeys(t)
+	record, err := esc.Register("customer", keys.MetaAddress(), keys.ViewingKey)
+	require.NoError(t, err)
+	req, err := esc.RequestRelease("regulator", record.ID, "CASE-2026-002")
+	require.NoError(t, err)
+	share, err := DecryptShare(memberKeys["alice"], *findTestShare(record, "alice"))
+	require.NoError(t, err)
+	_, err = esc.Approve(req.ID, "alice", share)
+	require.NoError(t, err)
+	require.NoError(t, esc.Close())
+
+	// The decrypted share is held in memory only
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), share.String())
+
+	// The record, the pending approval and the audit trail are all still there after a restart
+	store, err = OpenBoltStore(path)
+	require.NoError(t, err)
+	esc, err = LoadEscrow(committee, store)
+	require.NoError(t, err)
+	defer esc.Close()
+
+	restored, err := esc.Record(record.ID)
+	require.NoError(t, err)
+	assert.Equal(t, keys.MetaAddress().String(), restored.MetaAddress.String())
+	req, err = esc.Request(req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice"}, req.ApprovedBy())
+
+	carolShare, err := DecryptShare(memberKeys["carol"], *findTestShare(restored, "carol"))
+	require.NoError(t, err)
+	req, err = esc.Approve(req.ID, "carol", carolShare)
+	require.NoError(t, err)
+	assert.Equal(t, StatusApproved, req.Status)
+	scan := func(viewingKey *ecdsa.PrivateKey, _ *privacy.StealthMetaAddress) int {
+		assert.Equal(t, keys.ViewingKey.D, viewingKey.D)
+		return 0
+	}
+	assert.ErrorIs(t, esc.Execute(req.ID, "regulator", scan), ErrSharesNotHeld)
+
+	// Alice submits her share again, without approving twice
+	req, err = esc.Approve(req.ID, "alice", share)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "carol"}, req.ApprovedBy())
+	_, err = esc.Approve(req.ID, "alice", share)
+	assert.ErrorIs(t, err, ErrAlreadyApproved)
+	require.NoError(t, esc.Execute(req.ID, "regulator", scan))
+
+	var actions []string
+	for _, e := range esc.Audit().Entries() {
+		actions = append(actions, e.Action)
+	}
+	assert.Equal(t, []string{
+		"escrow_registered", "release_requested", "release_approved", "release_approved",
+		"share_resubmitted", "viewing_key_reconstructed", "scan_completed",
+	}, actions)
+	assert.NoError(t, esc.Audit().Verify())
+}
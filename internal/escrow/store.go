@@ -0,0 +1,261 @@
+package escrow
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/prikshit/blockchain-privacy-module/internal/privacy"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Store persists escrow records, release requests and the audit log. Every state change is written in one
+// transaction with the audit entry that records it, so the trail never misses a change that took effect.
+// Release requests are stored with the IDs of their approvers only, never the decrypted shares.
+type Store interface {
+	// Load returns every record, every request and the audit log in order.
+	Load() ([]*Record, []*ReleaseRequest, []AuditEntry, error)
+	// PutRecord stores a new escrow record with the audit entry of its registration.
+	PutRecord(record *Record, entry AuditEntry) error
+	// PutRequest adds or replaces a release request with the audit entry of the change.
+	PutRequest(req *ReleaseRequest, entry AuditEntry) error
+	// AppendAudit stores an audit entry that changes no state.
+	AppendAudit(entry AuditEntry) error
+	Close() error
+}
+
+// MemoryStore keeps the escrow in memory only. It is lost on restart, for tests and single-run tools.
+type MemoryStore struct {
+	mu       sync.Mutex
+	records  []*Record
+	requests map[string]*ReleaseRequest
+	audit    []AuditEntry
+}
+
+// NewMemoryStore returns an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{requests: make(map[string]*ReleaseRequest)}
+}
+
+func (s *MemoryStore) Load() ([]*Record, []*ReleaseRequest, []AuditEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	requests := make([]*ReleaseRequest, 0, len(s.requests))
+	for _, req := range s.requests {
+		requests = append(requests, req.snapshot())
+	}
+	return slices.Clone(s.records), requests, slices.Clone(s.audit), nil
+}
+
+func (s *MemoryStore) PutRecord(record *Record, entry AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, record)
+	s.audit = append(s.audit, entry)
+	return nil
+}
+
+func (s *MemoryStore) PutRequest(req *ReleaseRequest, entry AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests[req.ID] = req.snapshot()
+	s.audit = append(s.audit, entry)
+	return nil
+}
+
+func (s *MemoryStore) AppendAudit(entry AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.audit = append(s.audit, entry)
+	return nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// Buckets of the bbolt store. recordsBucket and requestsBucket are keyed by ID with JSON values;
+// auditBucket is keyed by the big-endian sequence number of each entry.
+var (
+	recordsBucket  = []byte("records")
+	requestsBucket = []byte("requests")
+	auditBucket    = []byte("audit")
+)
+
+// BoltStore keeps the escrow in an embedded bbolt database file.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// OpenBoltStore opens the database at path, creating it if needed. It fails rather than waits if another
+// process holds the file.
+func OpenBoltStore(path string) (*BoltStore, error) {
+	log.Printf("Opening escrow store at %s\n", path)
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("opening escrow store: %w", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{recordsBucket, requestsBucket, auditBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltStore{db: db}, nil
+}
+
+func (s *BoltStore) Load() ([]*Record, []*ReleaseRequest, []AuditEntry, error) {
+	var records []*Record
+	var requests []*ReleaseRequest
+	var audit []AuditEntry
+	err := s.db.View(func(tx *bolt.Tx) error {
+		err := tx.Bucket(recordsBucket).ForEach(func(k, v []byte) error {
+			record, err := decodeRecord(v)
+			if err != nil {
+				return fmt.Errorf("record %s: %w", k, err)
+			}
+			records = append(records, record)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		err = tx.Bucket(requestsBucket).ForEach(func(k, v []byte) error {
+			var req ReleaseRequest
+			if err := json.Unmarshal(v, &req); err != nil {
+				return fmt.Errorf("request %s: %w", k, err)
+			}
+			requests = append(requests, &req)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(auditBucket).ForEach(func(k, v []byte) error {
+			var entry AuditEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return fmt.Errorf("audit entry %x: %w", k, err)
+			}
+			audit = append(audit, entry)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("loading escrow store: %w", err)
+	}
+	return records, requests, audit, nil
+}
+
+func (s *BoltStore) PutRecord(record *Record, entry AuditEntry) error {
+	value, err := encodeRecord(record)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(recordsBucket).Put([]byte(record.ID), value); err != nil {
+			return err
+		}
+		return putAudit(tx, entry)
+	})
+}
+
+func (s *BoltStore) PutRequest(req *ReleaseRequest, entry AuditEntry) error {
+	value, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(requestsBucket).Put([]byte(req.ID), value); err != nil {
+			return err
+		}
+		return putAudit(tx, entry)
+	})
+}
+
+func (s *BoltStore) AppendAudit(entry AuditEntry) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putAudit(tx, entry)
+	})
+}
+
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+func putAudit(tx *bolt.Tx, entry AuditEntry) error {
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(auditBucket).Put(binary.BigEndian.AppendUint64(nil, entry.Seq), value)
+}
+
+// storedRecord is the encoding of a Record. Keys are stored compressed, as in meta-addresses.
+type storedRecord struct {
+	ID          string        `json:"id"`
+	MetaAddress string        `json:"meta_address"`
+	Threshold   int           `json:"threshold"`
+	Shares      []storedShare `json:"shares"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+type storedShare struct {
+	MemberID    string `json:"member_id"`
+	Index       int    `json:"index"`
+	Ciphertext  []byte `json:"ciphertext"`
+	PublicShare []byte `json:"public_share"`
+}
+
+func encodeRecord(record *Record) ([]byte, error) {
+	stored := storedRecord{
+		ID:          record.ID,
+		MetaAddress: record.MetaAddress.String(),
+		Threshold:   record.Threshold,
+		CreatedAt:   record.CreatedAt,
+	}
+	for _, share := range record.Shares {
+		stored.Shares = append(stored.Shares, storedShare{
+			MemberID:    share.MemberID,
+			Index:       share.Index,
+			Ciphertext:  share.Ciphertext,
+			PublicShare: crypto.CompressPubkey(share.PublicShare),
+		})
+	}
+	return json.Marshal(stored)
+}
+
+func decodeRecord(value []byte) (*Record, error) {
+	var stored storedRecord
+	if err := json.Unmarshal(value, &stored); err != nil {
+		return nil, err
+	}
+	meta, err := privacy.ParseStealthMetaAddress(stored.MetaAddress)
+	if err != nil {
+		return nil, err
+	}
+	record := &Record{ID: stored.ID, MetaAddress: meta, Threshold: stored.Threshold, CreatedAt: stored.CreatedAt}
+	for _, share := range stored.Shares {
+		publicShare, err := crypto.DecompressPubkey(share.PublicShare)
+		if err != nil {
+			return nil, fmt.Errorf("share of %s: %w", share.MemberID, err)
+		}
+		record.Shares = append(record.Shares, EncryptedShare{
+			MemberID:    share.MemberID,
+			Index:       share.Index,
+			Ciphertext:  share.Ciphertext,
+			PublicShare: publicShare,
+		})
+	}
+	return record, nil
+}
@@ -0,0 +1,61 @@
+package evmtest
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/program"
+)
+
+// Assembler extends program.Program with named jump labels that may be used before they are defined.
+type Assembler struct {
+	*program.Program
+	labels map[string]uint64
+	fixups map[int]string // Offset of a PUSH2 immediate -> label
+}
+
+// NewAssembler returns an empty assembler.
+func NewAssembler() *Assembler {
+	return &Assembler{Program: program.New(), labels: make(map[string]uint64), fixups: make(map[int]string)}
+}
+
+// Label places a JUMPDEST named name at the current position.
+func (a *Assembler) Label(name string) {
+	if _, ok := a.labels[name]; ok {
+		panic(fmt.Sprintf("label %q defined twice", name))
+	}
+	_, pc := a.Jumpdest()
+	a.labels[name] = pc
+}
+
+// PushLabel pushes the position of label name.
+func (a *Assembler) PushLabel(name string) {
+	a.Op(vm.PUSH2)
+	a.fixups[a.Size()] = name
+	a.Append([]byte{0, 0})
+}
+
+// JumpTo jumps to label name.
+func (a *Assembler) JumpTo(name string) {
+	a.PushLabel(name)
+	a.Op(vm.JUMP)
+}
+
+// JumpToIf jumps to label name when the top of the stack is non-zero, consuming it.
+func (a *Assembler) JumpToIf(name string) {
+	a.PushLabel(name)
+	a.Op(vm.JUMPI)
+}
+
+// Code resolves all labels and returns the bytecode.
+func (a *Assembler) Code() []byte {
+	code := append([]byte(nil), a.Bytes()...)
+	for offset, name := range a.fixups {
+		pc, ok := a.labels[name]
+		if !ok {
+			panic(fmt.Sprintf("undefined label %q", name))
+		}
+		code[offset], code[offset+1] = byte(pc>>8), byte(pc)
+	}
+	return code
+}
@@ -0,0 +1,291 @@
+package evmtest
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// packedUserOpTuple is the ABI tuple of an ERC-4337 v0.7 PackedUserOperation.
+const packedUserOpTuple = "(address,uint256,bytes,bytes,bytes32,uint256,bytes32,bytes,bytes)"
+
+// Memory registers of the EntryPoint stand-in.
+const (
+	regIndex  = 0x00
+	regCount  = 0x20
+	regBase   = 0x40
+	regOp     = 0x60
+	regSender = 0x80
+	regHash   = 0xa0
+	memNonce  = 0x100 // Sender and key hashed into the nonce slot
+	memPacked = 0x200 // Eight words hashed into the packed user operation hash
+	memOuter  = 0x300 // keccak(packed), entry point, chain ID
+	memResult = 0x360 // Return data of validation calls
+	memCall   = 0x400 // Outgoing calldata
+)
+
+// EntryPoint returns a genesis account holding a hand-assembled stand-in for the ERC-4337 v0.7 EntryPoint.
+// handleOps processes each operation as the real EntryPoint does, in order: it checks the nonce, deploys
+// the account through its factory when initCode is set, computes the user operation hash, calls
+// validateUserOp on the account and validatePaymasterUserOp on the paymaster, and runs callData. Unlike
+// the real EntryPoint it does no gas accounting (missingAccountFunds and maxCost are always zero) and
+// reverts if any step fails. getNonce(sender, key) returns the sequence of key 0.
+func EntryPoint() types.Account {
+	a := NewAssembler()
+
+	// Field loaders for the current operation
+	opField := func(i int) { // calldataload(op + 32*i)
+		a.Push(regOp).Op(vm.MLOAD).Push(32*i).Op(vm.ADD, vm.CALLDATALOAD)
+	}
+	opBytes := func(i int) { // absolute position of the length word of bytes field i
+		a.Push(regOp).Op(vm.MLOAD, vm.DUP1).Push(32*i).Op(vm.ADD, vm.CALLDATALOAD, vm.ADD)
+	}
+	// hashBytes replaces a bytes position on the stack with keccak256 of its contents
+	hashBytes := func() {
+		a.Op(vm.DUP1, vm.CALLDATALOAD, vm.DUP1, vm.SWAP2).Push(32).Op(vm.ADD).Push(memCall).Op(vm.CALLDATACOPY) // [len]
+		a.Push(memCall).Op(vm.KECCAK256)
+	}
+	// validate calls target (on the stack) with selector(op, userOpHash, 0) and leaves the return data at memResult
+	validate := func(sel []byte) {
+		a.Push(new(big.Int).Lsh(new(big.Int).SetBytes(sel), 224)).Push(memCall).Op(vm.MSTORE)
+		a.Push(0x60).Push(memCall + 4).Op(vm.MSTORE)
+		a.Push(regHash).Op(vm.MLOAD).Push(memCall + 36).Op(vm.MSTORE)
+		a.Push0().Push(memCall + 68).Op(vm.MSTORE)
+		a.Push(regOp).Op(vm.MLOAD, vm.CALLDATASIZE, vm.SUB)                                // [len, target]
+		a.Op(vm.DUP1).Push(regOp).Op(vm.MLOAD).Push(memCall + 100).Op(vm.CALLDATACOPY)     // copy the op tuple and everything after it
+		a.Push(memResult).Push(64).Op(vm.SWAP2).Push(100).Op(vm.ADD).Push(memCall).Push0() // [0, memCall, len+100, memResult, 64, target]
+		a.Op(vm.DUP6, vm.GAS, vm.CALL, vm.ISZERO)
+		a.JumpToIf("fail")
+		a.Op(vm.POP)
+	}
+
+	a.Push0().Op(vm.CALLDATALOAD).Push(224).Op(vm.SHR)
+	a.Op(vm.DUP1).Push(selector("handleOps(" + packedUserOpTuple + "[],address)")).Op(vm.EQ)
+	a.JumpToIf("handleOps")
+	a.Op(vm.DUP1).Push(selector("getNonce(address,uint192)")).Op(vm.EQ)
+	a.JumpToIf("getNonce")
+	a.Label("fail")
+	a.Push0().Op(vm.DUP1, vm.REVERT)
+
+	a.Label("getNonce")
+	a.Push(4).Op(vm.CALLDATALOAD)
+	hashNonceSlot(a)
+	a.Op(vm.SLOAD).Push0().Op(vm.MSTORE).Push(32).Push0().Op(vm.RETURN)
+
+	a.Label("handleOps")
+	a.Push(4).Op(vm.CALLDATALOAD).Push(4).Op(vm.ADD)            // [arr]
+	a.Op(vm.DUP1, vm.CALLDATALOAD).Push(regCount).Op(vm.MSTORE) // count
+	a.Push(32).Op(vm.ADD).Push(regBase).Op(vm.MSTORE)           // base
+	a.Push0().Push(regIndex).Op(vm.MSTORE)                      // i = 0
+
+	a.Label("loop")
+	a.Push(regCount).Op(vm.MLOAD).Push(regIndex).Op(vm.MLOAD, vm.LT, vm.ISZERO)
+	a.JumpToIf("done")
+	// op = base + calldataload(base + 32*i)
+	a.Push(regBase).Op(vm.MLOAD, vm.DUP1).Push(regIndex).Op(vm.MLOAD).Push(32).Op(vm.MUL, vm.ADD, vm.CALLDATALOAD, vm.ADD)
+	a.Push(regOp).Op(vm.MSTORE)
+	opField(0)
+	a.Push(regSender).Op(vm.MSTORE)
+
+	// Nonce: op.nonce must equal the stored sequence, which is then incremented
+	a.Push(regSender).Op(vm.MLOAD)
+	hashNonceSlot(a)                 // [slot]
+	a.Op(vm.DUP1, vm.SLOAD, vm.DUP1) // [seq, seq, slot]
+	opField(1)
+	a.Op(vm.EQ, vm.ISZERO)
+	a.JumpToIf("fail")
+	a.Push(1).Op(vm.ADD, vm.SWAP1, vm.SSTORE)
+
+	// Deploy the account through the factory named in initCode
+	opBytes(2) // [ic]
+	a.Op(vm.DUP1, vm.CALLDATALOAD, vm.ISZERO)
+	a.JumpToIf("deployed")
+	a.Push(regSender).Op(vm.MLOAD, vm.EXTCODESIZE)
+	a.JumpToIf("deployed")
+	a.Op(vm.DUP1, vm.CALLDATALOAD).Push(20).Op(vm.SWAP1, vm.SUB) // [len-20, ic]
+	a.Op(vm.DUP1, vm.DUP3).Push(52).Op(vm.ADD).Push(memCall).Op(vm.CALLDATACOPY)
+	a.Push0().Op(vm.DUP1, vm.SWAP2).Push(memCall).Push0() // [0, memCall, len-20, 0, 0, ic]
+	a.Op(vm.DUP6).Push(32).Op(vm.ADD, vm.CALLDATALOAD).Push(96).Op(vm.SHR, vm.GAS, vm.CALL, vm.ISZERO)
+	a.JumpToIf("fail")
+	a.Push(regSender).Op(vm.MLOAD, vm.EXTCODESIZE, vm.ISZERO)
+	a.JumpToIf("fail")
+	a.Label("deployed")
+	a.Op(vm.POP)
+
+	// userOpHash = keccak256(abi.encode(keccak256(packed), entryPoint, chainid))
+	opField(0)
+	a.Push(memPacked).Op(vm.MSTORE)
+	opField(1)
+	a.Push(memPacked + 32).Op(vm.MSTORE)
+	opBytes(2)
+	hashBytes()
+	a.Push(memPacked + 64).Op(vm.MSTORE)
+	opBytes(3)
+	hashBytes()
+	a.Push(memPacked + 96).Op(vm.MSTORE)
+	opField(4)
+	a.Push(memPacked + 128).Op(vm.MSTORE)
+	opField(5)
+	a.Push(memPacked + 160).Op(vm.MSTORE)
+	opField(6)
+	a.Push(memPacked + 192).Op(vm.MSTORE)
+	opBytes(7)
+	hashBytes()
+	a.Push(memPacked + 224).Op(vm.MSTORE)
+	a.Push(256).Push(memPacked).Op(vm.KECCAK256).Push(memOuter).Op(vm.MSTORE)
+	a.Op(vm.ADDRESS).Push(memOuter + 32).Op(vm.MSTORE)
+	a.Op(vm.CHAINID).Push(memOuter + 64).Op(vm.MSTORE)
+	a.Push(96).Push(memOuter).Op(vm.KECCAK256).Push(regHash).Op(vm.MSTORE)
+
+	// The account must return validationData 0
+	a.Push(regSender).Op(vm.MLOAD)
+	validate(selector("validateUserOp(" + packedUserOpTuple + ",bytes32,uint256)"))
+	a.Push(memResult).Op(vm.MLOAD)
+	a.JumpToIf("fail")
+
+	// So must the paymaster, if any; it returns (bytes context, uint256 validationData)
+	opBytes(7)
+	a.Op(vm.DUP1, vm.CALLDATALOAD).Push(20).Op(vm.GT) // 20 > len
+	a.JumpToIf("paid")
+	a.Op(vm.DUP1).Push(32).Op(vm.ADD, vm.CALLDATALOAD).Push(96).Op(vm.SHR)
+	validate(selector("validatePaymasterUserOp(" + packedUserOpTuple + ",bytes32,uint256)"))
+	a.Push(memResult + 32).Op(vm.MLOAD)
+	a.JumpToIf("fail")
+	a.Label("paid")
+	a.Op(vm.POP)
+
+	// Execute callData on the account
+	opBytes(3)
+	a.Op(vm.DUP1, vm.CALLDATALOAD, vm.DUP1, vm.SWAP2).Push(32).Op(vm.ADD).Push(memCall).Op(vm.CALLDATACOPY) // [len]
+	a.Push0().Op(vm.DUP1, vm.SWAP2).Push(memCall).Push0()                                                   // [0, memCall, len, 0, 0]
+	a.Push(regSender).Op(vm.MLOAD, vm.GAS, vm.CALL, vm.ISZERO)
+	a.JumpToIf("fail")
+
+	a.Push(regIndex).Op(vm.MLOAD).Push(1).Op(vm.ADD).Push(regIndex).Op(vm.MSTORE)
+	a.JumpTo("loop")
+
+	a.Label("done")
+	a.Op(vm.STOP)
+
+	return types.Account{Code: a.Code()}
+}
+
+// hashNonceSlot replaces the sender on the stack with the storage slot of its nonce sequence.
+func hashNonceSlot(a *Assembler) {
+	a.Push(memNonce).Op(vm.MSTORE).Push0().Push(memNonce + 32).Op(vm.MSTORE).Push(64).Push(memNonce).Op(vm.KECCAK256)
+}
+
+// SmartAccountCreationCode returns the creation code of a minimal ERC-4337 account for entryPoint. Its only
+// constructor argument is the owner, appended as one ABI word. The account accepts ETH, validates user
+// operations signed by the owner over the EIP-191 hash of userOpHash, and lets the EntryPoint call
+// execute(dest, value, data).
+func SmartAccountCreationCode(entryPoint common.Address) []byte {
+	runtime := smartAccountRuntime(entryPoint)
+
+	constructor := func(offset int) []byte {
+		c := NewAssembler()
+		c.Push(32).Push(32).Op(vm.CODESIZE, vm.SUB).Push0().Op(vm.CODECOPY) // memory[0:32] = owner
+		c.Push0().Op(vm.MLOAD).Push0().Op(vm.SSTORE)
+		c.Op(vm.PUSH2).Append([]byte{byte(len(runtime) >> 8), byte(len(runtime))})
+		c.Op(vm.PUSH2).Append([]byte{byte(offset >> 8), byte(offset)})
+		c.Push0().Op(vm.CODECOPY)
+		c.Op(vm.PUSH2).Append([]byte{byte(len(runtime) >> 8), byte(len(runtime))})
+		c.Push0().Op(vm.RETURN)
+		return c.Code()
+	}
+	code := constructor(len(constructor(0)))
+	return append(code, runtime...)
+}
+
+func smartAccountRuntime(entryPoint common.Address) []byte {
+	a := NewAssembler()
+
+	a.Op(vm.CALLDATASIZE, vm.ISZERO)
+	a.JumpToIf("receive")
+	// Only the EntryPoint may call the account
+	a.Op(vm.CALLER).Push(entryPoint).Op(vm.EQ, vm.ISZERO)
+	a.JumpToIf("fail")
+	a.Push0().Op(vm.CALLDATALOAD).Push(224).Op(vm.SHR)
+	a.Op(vm.DUP1).Push(selector("validateUserOp(" + packedUserOpTuple + ",bytes32,uint256)")).Op(vm.EQ)
+	a.JumpToIf("validate")
+	a.Op(vm.DUP1).Push(selector("execute(address,uint256,bytes)")).Op(vm.EQ)
+	a.JumpToIf("execute")
+	a.Label("fail")
+	a.Push0().Op(vm.DUP1, vm.REVERT)
+
+	a.Label("receive")
+	a.Op(vm.STOP)
+
+	a.Label("validate")
+	// Pay the EntryPoint what it asks for
+	a.Push(68).Op(vm.CALLDATALOAD, vm.ISZERO)
+	a.JumpToIf("funded")
+	a.Push0().Op(vm.DUP1, vm.DUP1, vm.DUP1).Push(68).Op(vm.CALLDATALOAD, vm.CALLER, vm.GAS, vm.CALL, vm.POP)
+	a.Label("funded")
+	// sig = op + calldataload(op + 8*32), op = 4 + calldataload(4)
+	a.Push(4).Op(vm.CALLDATALOAD).Push(4).Op(vm.ADD)
+	a.Op(vm.DUP1).Push(256).Op(vm.ADD, vm.CALLDATALOAD, vm.ADD) // [sig]
+	a.Op(vm.DUP1, vm.CALLDATALOAD).Push(65).Op(vm.EQ, vm.ISZERO)
+	a.JumpToIf("invalid")
+	// digest = keccak256("\x19Ethereum Signed Message:\n32" || userOpHash)
+	a.Push([]byte("\x19Ethereum Signed Message:\n32")).Push(32).Op(vm.SHL).Push0().Op(vm.MSTORE)
+	a.Push(36).Op(vm.CALLDATALOAD).Push(28).Op(vm.MSTORE)
+	a.Push(60).Push0().Op(vm.KECCAK256).Push(128).Op(vm.MSTORE)
+	// ecrecover(digest, v, r, s)
+	a.Op(vm.DUP1).Push(96).Op(vm.ADD, vm.CALLDATALOAD).Push0().Op(vm.BYTE).Push(160).Op(vm.MSTORE)
+	a.Op(vm.DUP1).Push(32).Op(vm.ADD, vm.CALLDATALOAD).Push(192).Op(vm.MSTORE)
+	a.Op(vm.DUP1).Push(64).Op(vm.ADD, vm.CALLDATALOAD).Push(224).Op(vm.MSTORE)
+	a.Push0().Push(256).Op(vm.MSTORE)
+	a.Push(32).Push(256).Push(128).Push(128).Push(1).Op(vm.GAS, vm.STATICCALL, vm.POP)
+	a.Push(256).Op(vm.MLOAD, vm.DUP1, vm.ISZERO)
+	a.JumpToIf("invalid")
+	a.Push0().Op(vm.SLOAD, vm.EQ, vm.ISZERO)
+	a.JumpToIf("invalid")
+	a.Push0().Push0().Op(vm.MSTORE).Push(32).Push0().Op(vm.RETURN)
+	a.Label("invalid") // SIG_VALIDATION_FAILED
+	a.Push(1).Push0().Op(vm.MSTORE).Push(32).Push0().Op(vm.RETURN)
+
+	a.Label("execute")
+	a.Push(68).Op(vm.CALLDATALOAD).Push(4).Op(vm.ADD)                                                 // [data]
+	a.Op(vm.DUP1, vm.CALLDATALOAD, vm.DUP1, vm.SWAP2).Push(32).Op(vm.ADD).Push0().Op(vm.CALLDATACOPY) // [len]
+	a.Push0().Op(vm.DUP1, vm.SWAP2).Push0()                                                           // [0, len, 0, 0]
+	a.Push(36).Op(vm.CALLDATALOAD).Push(4).Op(vm.CALLDATALOAD, vm.GAS, vm.CALL, vm.ISZERO)
+	a.JumpToIf("fail")
+	a.Op(vm.STOP)
+
+	return a.Code()
+}
+
+// AccountFactory returns a genesis account holding a factory whose createAccount(owner, salt) deploys
+// creationCode with owner appended via CREATE2 and returns the account address.
+func AccountFactory(creationCode []byte) types.Account {
+	build := func(offset int) []byte {
+		a := NewAssembler()
+		size := len(creationCode)
+		a.Op(vm.PUSH2).Append([]byte{byte(size >> 8), byte(size)})
+		a.Op(vm.PUSH2).Append([]byte{byte(offset >> 8), byte(offset)})
+		a.Push0().Op(vm.CODECOPY)
+		a.Push(4).Op(vm.CALLDATALOAD).Push(size).Op(vm.MSTORE)
+		a.Push(36).Op(vm.CALLDATALOAD).Push(size + 32).Push0().Push0().Op(vm.CREATE2)
+		a.Op(vm.DUP1, vm.ISZERO)
+		a.JumpToIf("fail")
+		a.Push0().Op(vm.MSTORE).Push(32).Push0().Op(vm.RETURN)
+		a.Label("fail")
+		a.Push0().Op(vm.DUP1, vm.REVERT)
+		return a.Code()
+	}
+	code := build(len(build(0)))
+	return types.Account{Code: append(code, creationCode...)}
+}
+
+// SponsoringPaymaster returns a genesis account holding a paymaster that accepts every operation and
+// counts how many it validated in storage slot 0.
+func SponsoringPaymaster() types.Account {
+	a := NewAssembler()
+	a.Push0().Op(vm.SLOAD).Push(1).Op(vm.ADD).Push0().Op(vm.SSTORE)
+	a.Push(64).Push0().Op(vm.MSTORE) // context offset; context and validationData stay zero
+	a.Push(96).Push0().Op(vm.RETURN)
+	return types.Account{Code: a.Code()}
+}
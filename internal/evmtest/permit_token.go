@@ -0,0 +1,165 @@
+package evmtest
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Storage slots of the token's mappings, laid out as Solidity would.
+const (
+	balancesSlot   = 0
+	allowancesSlot = 1
+	noncesSlot     = 2
+)
+
+var permitTypeHash = crypto.Keccak256Hash([]byte("Permit(address owner,address spender,uint256 value,uint256 nonce,uint256 deadline)"))
+
+// TokenDomainSeparator is the EIP-712 domain separator of a token named name, version "1", at address.
+func TokenDomainSeparator(name string, chainID *big.Int, address common.Address) common.Hash {
+	return crypto.Keccak256Hash(
+		crypto.Keccak256([]byte("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)")),
+		crypto.Keccak256([]byte(name)),
+		crypto.Keccak256([]byte("1")),
+		common.BigToHash(chainID).Bytes(),
+		common.LeftPadBytes(address.Bytes(), 32),
+	)
+}
+
+// PermitToken returns a genesis account holding a minimal ERC-20 with EIP-2612 permit and the given
+// balances. It implements balanceOf, allowance, transferFrom, permit, nonces and DOMAIN_SEPARATOR;
+// it emits no events and has no transfer or approve.
+func PermitToken(domainSeparator common.Hash, balances map[common.Address]*big.Int) types.Account {
+	storage := make(map[common.Hash]common.Hash, len(balances))
+	for owner, balance := range balances {
+		storage[mappingSlot(owner, balancesSlot)] = common.BigToHash(balance)
+	}
+	return types.Account{Code: permitTokenCode(domainSeparator), Storage: storage}
+}
+
+func mappingSlot(key common.Address, slot int64) common.Hash {
+	return crypto.Keccak256Hash(common.LeftPadBytes(key.Bytes(), 32), common.BigToHash(big.NewInt(slot)).Bytes())
+}
+
+func selector(signature string) []byte {
+	return crypto.Keccak256([]byte(signature))[:4]
+}
+
+func permitTokenCode(domainSeparator common.Hash) []byte {
+	a := NewAssembler()
+
+	// hashSlot replaces the key on top of the stack with keccak256(key . slot)
+	hashSlot := func(slot int) {
+		a.Push0().Op(vm.MSTORE).Push(slot).Push(32).Op(vm.MSTORE).Push(64).Push0().Op(vm.KECCAK256)
+	}
+	// allowanceSlot pushes the slot of allowance[owner][spender] for the given calldata offsets (spender < 0 means CALLER)
+	allowanceSlot := func(owner, spender int) {
+		a.Push(owner).Op(vm.CALLDATALOAD)
+		hashSlot(allowancesSlot)
+		a.Push(32).Op(vm.MSTORE)
+		if spender < 0 {
+			a.Op(vm.CALLER)
+		} else {
+			a.Push(spender).Op(vm.CALLDATALOAD)
+		}
+		a.Push0().Op(vm.MSTORE).Push(64).Push0().Op(vm.KECCAK256)
+	}
+	returnWord := func() {
+		a.Push0().Op(vm.MSTORE).Push(32).Push0().Op(vm.RETURN)
+	}
+
+	// Dispatch on the selector
+	a.Push0().Op(vm.CALLDATALOAD).Push(224).Op(vm.SHR)
+	for _, fn := range []struct{ signature, label string }{
+		{"balanceOf(address)", "balanceOf"},
+		{"allowance(address,address)", "allowance"},
+		{"nonces(address)", "nonces"},
+		{"DOMAIN_SEPARATOR()", "domainSeparator"},
+		{"transferFrom(address,address,uint256)", "transferFrom"},
+		{"permit(address,address,uint256,uint256,uint8,bytes32,bytes32)", "permit"},
+	} {
+		a.Op(vm.DUP1).Push(selector(fn.signature)).Op(vm.EQ)
+		a.JumpToIf(fn.label)
+	}
+	a.Label("fail")
+	a.Push0().Op(vm.DUP1, vm.REVERT)
+
+	a.Label("balanceOf")
+	a.Push(4).Op(vm.CALLDATALOAD)
+	hashSlot(balancesSlot)
+	a.Op(vm.SLOAD)
+	returnWord()
+
+	a.Label("nonces")
+	a.Push(4).Op(vm.CALLDATALOAD)
+	hashSlot(noncesSlot)
+	a.Op(vm.SLOAD)
+	returnWord()
+
+	a.Label("allowance")
+	allowanceSlot(4, 36)
+	a.Op(vm.SLOAD)
+	returnWord()
+
+	a.Label("domainSeparator")
+	a.Push(domainSeparator)
+	returnWord()
+
+	// transferFrom(from, to, value): spend the caller's allowance, then move the balance
+	a.Label("transferFrom")
+	allowanceSlot(4, -1)                                 // [aslot]
+	a.Op(vm.DUP1, vm.SLOAD).Push(68).Op(vm.CALLDATALOAD) // [value, allowance, aslot]
+	a.Op(vm.DUP1, vm.DUP3, vm.LT)
+	a.JumpToIf("fail")
+	a.Op(vm.SWAP1, vm.SUB, vm.SWAP1, vm.SSTORE) // allowance -= value
+	a.Push(4).Op(vm.CALLDATALOAD)
+	hashSlot(balancesSlot)                               // [fslot]
+	a.Op(vm.DUP1, vm.SLOAD).Push(68).Op(vm.CALLDATALOAD) // [value, balance, fslot]
+	a.Op(vm.DUP1, vm.DUP3, vm.LT)
+	a.JumpToIf("fail")
+	a.Op(vm.SWAP1, vm.SUB, vm.SWAP1, vm.SSTORE) // balance[from] -= value
+	a.Push(36).Op(vm.CALLDATALOAD)
+	hashSlot(balancesSlot)
+	a.Op(vm.DUP1, vm.SLOAD).Push(68).Op(vm.CALLDATALOAD, vm.ADD, vm.SWAP1, vm.SSTORE) // balance[to] += value
+	a.Push(1)
+	returnWord()
+
+	// permit(owner, spender, value, deadline, v, r, s)
+	a.Label("permit")
+	a.Op(vm.TIMESTAMP).Push(100).Op(vm.CALLDATALOAD, vm.LT)
+	a.JumpToIf("fail")
+	// memory[384:450] = 0x1901 || domainSeparator || structHash
+	a.Push(new(big.Int).Lsh(big.NewInt(0x1901), 240)).Push(384).Op(vm.MSTORE)
+	a.Push(domainSeparator).Push(386).Op(vm.MSTORE)
+	// Read and increment the owner's nonce
+	a.Push(4).Op(vm.CALLDATALOAD)
+	hashSlot(noncesSlot)                                                    // [nslot]
+	a.Op(vm.DUP1, vm.SLOAD, vm.DUP1).Push(1).Op(vm.ADD, vm.DUP3, vm.SSTORE) // [nonce, nslot]
+	// structHash = keccak256(typehash, owner, spender, value, nonce, deadline) over memory[128:320]
+	a.Push(permitTypeHash).Push(128).Op(vm.MSTORE)
+	a.Push(4).Op(vm.CALLDATALOAD).Push(160).Op(vm.MSTORE)
+	a.Push(36).Op(vm.CALLDATALOAD).Push(192).Op(vm.MSTORE)
+	a.Push(68).Op(vm.CALLDATALOAD).Push(224).Op(vm.MSTORE)
+	a.Push(256).Op(vm.MSTORE, vm.POP)
+	a.Push(100).Op(vm.CALLDATALOAD).Push(288).Op(vm.MSTORE)
+	a.Push(192).Push(128).Op(vm.KECCAK256).Push(418).Op(vm.MSTORE)
+	// ecrecover(digest, v, r, s) into memory[448:480]
+	a.Push(66).Push(384).Op(vm.KECCAK256).Push0().Op(vm.MSTORE)
+	a.Push(132).Op(vm.CALLDATALOAD).Push(32).Op(vm.MSTORE)
+	a.Push(164).Op(vm.CALLDATALOAD).Push(64).Op(vm.MSTORE)
+	a.Push(196).Op(vm.CALLDATALOAD).Push(96).Op(vm.MSTORE)
+	a.Push0().Push(448).Op(vm.MSTORE)
+	a.Push(32).Push(448).Push(128).Push0().Push(1).Op(vm.GAS, vm.STATICCALL, vm.POP)
+	a.Push(448).Op(vm.MLOAD, vm.DUP1, vm.ISZERO)
+	a.JumpToIf("fail")
+	a.Push(4).Op(vm.CALLDATALOAD, vm.EQ, vm.ISZERO)
+	a.JumpToIf("fail")
+	// allowance[owner][spender] = value
+	allowanceSlot(4, 36)
+	a.Push(68).Op(vm.CALLDATALOAD, vm.SWAP1, vm.SSTORE, vm.STOP)
+
+	return a.Code()
+}
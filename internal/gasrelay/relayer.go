@@ -0,0 +1,194 @@
+package gasrelay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/prikshit/blockchain-privacy-module/internal/chain"
+	"github.com/prikshit/blockchain-privacy-module/internal/sanctions"
+)
+
+// transferGasLimit is the gas limit of each transferFrom. They are sent right after the permit,
+// before it is mined, so they cannot be estimated.
+const transferGasLimit = 120_000
+
+var (
+	ErrUnsupportedToken      = errors.New("token is not accepted by the relayer")
+	ErrFeeTooLow             = errors.New("fee is below the relayer's fee")
+	ErrAmountTooSmall        = errors.New("amount does not cover the fee")
+	ErrExpired               = errors.New("sweep deadline has passed")
+	ErrSanctionedDestination = errors.New("destination address is sanctioned")
+)
+
+var tokenABI = mustParseABI(`[
+	{"type":"function","name":"nonces","stateMutability":"view","inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"DOMAIN_SEPARATOR","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"bytes32"}]},
+	{"type":"function","name":"permit","stateMutability":"nonpayable","inputs":[{"name":"owner","type":"address"},{"name":"spender","type":"address"},
+		{"name":"value","type":"uint256"},{"name":"deadline","type":"uint256"},{"name":"v","type":"uint8"},{"name":"r","type":"bytes32"},{"name":"s","type":"bytes32"}],"outputs":[]},
+	{"type":"function","name":"transferFrom","stateMutability":"nonpayable","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},
+		{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]}
+]`)
+
+// Config lists the tokens the relayer accepts and the fee it charges in each, in token units.
+type Config struct {
+	Fees map[common.Address]*big.Int
+}
+
+// Result holds the transactions that carried out a sweep.
+type Result struct {
+	PermitTx   common.Hash
+	TransferTx common.Hash
+	FeeTx      common.Hash
+}
+
+// Relayer sweeps ERC-20 balances out of stealth addresses that hold no ETH. The stealth key signs an
+// EIP-2612 permit to the relayer; the relayer pays gas for the permit and the transfers and keeps
+// its fee in the token. The permit trusts the relayer with the allowance, so the relayer only
+// moves funds as the signed sweep specifies.
+type Relayer struct {
+	sender   *chain.Sender
+	detector *sanctions.Detector
+	cfg      Config
+	now      func() time.Time
+}
+
+// NewRelayer returns a relayer paying gas from the sender's key.
+func NewRelayer(sender *chain.Sender, detector *sanctions.Detector, cfg Config) *Relayer {
+	log.Printf("Gas relayer %s accepting %d tokens\n", sender.Address().Hex(), len(cfg.Fees))
+	return &Relayer{sender: sender, detector: detector, cfg: cfg, now: time.Now}
+}
+
+// Address returns the relayer account that becomes the permit spender.
+func (r *Relayer) Address() common.Address {
+	return r.sender.Address()
+}
+
+// Quote returns the fee and the token's permit parameters for sweeping token out of owner.
+func (r *Relayer) Quote(ctx context.Context, token, owner common.Address) (*Quote, error) {
+	fee, ok := r.cfg.Fees[token]
+	if !ok {
+		return nil, ErrUnsupportedToken
+	}
+
+	nonce, err := r.call(ctx, token, "nonces", owner)
+	if err != nil {
+		return nil, err
+	}
+	domainSeparator, err := r.call(ctx, token, "DOMAIN_SEPARATOR")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Quote{
+		Relayer:         r.Address(),
+		Token:           token,
+		Owner:           owner,
+		Fee:             new(big.Int).Set(fee),
+		Nonce:           nonce.(*big.Int),
+		DomainSeparator: domainSeparator.([32]byte),
+		ChainID:         r.sender.ChainID(),
+	}, nil
+}
+
+// Relay validates a signed sweep, screens its destination and submits the permit and both transfers.
+func (r *Relayer) Relay(ctx context.Context, req *SweepRequest) (*Result, error) {
+	log.Printf("Relaying sweep of %s from %s to %s\n", req.Token.Hex(), req.Owner.Hex(), req.Destination.Hex())
+
+	minFee, ok := r.cfg.Fees[req.Token]
+	if !ok {
+		return nil, ErrUnsupportedToken
+	}
+	if req.Fee.Cmp(minFee) < 0 {
+		return nil, ErrFeeTooLow
+	}
+	if req.Amount.Cmp(req.Fee) <= 0 {
+		return nil, ErrAmountTooSmall
+	}
+	if req.Deadline.Cmp(big.NewInt(r.now().Unix())) < 0 {
+		return nil, ErrExpired
+	}
+	if err := verifySweep(r.sender.ChainID(), req); err != nil {
+		return nil, err
+	}
+	if r.detector.IsSanctioned(req.Destination) {
+		log.Printf("Refusing sweep to sanctioned destination %s\n", req.Destination.Hex())
+		return nil, ErrSanctionedDestination
+	}
+	domainSeparator, err := r.call(ctx, req.Token, "DOMAIN_SEPARATOR")
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyPermit(domainSeparator.([32]byte), r.Address(), req); err != nil {
+		return nil, err
+	}
+
+	// The permit is estimated, so a stale nonce fails here before any gas is spent
+	permit, _ := normalizeSignature(req.Permit)
+	var sigR, sigS [32]byte
+	copy(sigR[:], permit[:32])
+	copy(sigS[:], permit[32:64])
+	permitData, err := tokenABI.Pack("permit", req.Owner, r.Address(), req.Amount, req.Deadline, permit[crypto.RecoveryIDOffset]+27, sigR, sigS)
+	if err != nil {
+		return nil, err
+	}
+	permitTx, err := r.sender.Send(ctx, req.Token, nil, permitData)
+	if err != nil {
+		return nil, fmt.Errorf("submitting permit: %w", err)
+	}
+
+	net := new(big.Int).Sub(req.Amount, req.Fee)
+	transferData, err := tokenABI.Pack("transferFrom", req.Owner, req.Destination, net)
+	if err != nil {
+		return nil, err
+	}
+	transferTx, err := r.sender.SendWithGasLimit(ctx, req.Token, nil, transferData, transferGasLimit)
+	if err != nil {
+		return nil, fmt.Errorf("submitting transfer: %w", err)
+	}
+
+	feeData, err := tokenABI.Pack("transferFrom", req.Owner, r.Address(), req.Fee)
+	if err != nil {
+		return nil, err
+	}
+	feeTx, err := r.sender.SendWithGasLimit(ctx, req.Token, nil, feeData, transferGasLimit)
+	if err != nil {
+		return nil, fmt.Errorf("submitting fee transfer: %w", err)
+	}
+
+	log.Printf("Relayed sweep of %s to %s (fee %s)\n", net, req.Destination.Hex(), req.Fee)
+	return &Result{PermitTx: permitTx.Hash(), TransferTx: transferTx.Hash(), FeeTx: feeTx.Hash()}, nil
+}
+
+// call runs a view method on token and returns its single result.
+func (r *Relayer) call(ctx context.Context, token common.Address, method string, args ...any) (any, error) {
+	data, err := tokenABI.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	result, err := r.sender.Backend().CallContract(ctx, ethereum.CallMsg{To: &token, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("calling %s: %w", method, err)
+	}
+	values, err := tokenABI.Unpack(method, result)
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", method, err)
+	}
+	return values[0], nil
+}
+
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
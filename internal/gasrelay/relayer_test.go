@@ -0,0 +1,164 @@
+package gasrelay
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/prikshit/blockchain-privacy-module/internal/chain"
+	"github.com/prikshit/blockchain-privacy-module/internal/evmtest"
+	"github.com/prikshit/blockchain-privacy-module/internal/sanctions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	token       = common.HexToAddress("0x000000000000000000000000000000000000700c")
+	destination = common.HexToAddress("0x000000000000000000000000000000000000de57")
+	fee         = big.NewInt(2_000_000)
+	balance     = big.NewInt(1_000_000_000)
+)
+
+type testRelay struct {
+	backend    *simulated.Backend
+	relayer    *Relayer
+	detector   *sanctions.Detector
+	stealthKey *ecdsa.PrivateKey
+}
+
+// newTestRelay funds the relayer with ETH and a stealth address with tokens only.
+func newTestRelay(t *testing.T) *testRelay {
+	relayerKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	stealthKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	domainSeparator := evmtest.TokenDomainSeparator("USD Coin", params.AllDevChainProtocolChanges.ChainID, token)
+	backend := simulated.NewBackend(types.GenesisAlloc{
+		crypto.PubkeyToAddress(relayerKey.PublicKey): {Balance: big.NewInt(params.Ether)},
+		token: evmtest.PermitToken(domainSeparator, map[common.Address]*big.Int{
+			crypto.PubkeyToAddress(stealthKey.PublicKey): balance,
+		}),
+	})
+	t.Cleanup(func() { backend.Close() })
+
+	sender, err := chain.NewSender(context.Background(), backend.Client(), relayerKey)
+	require.NoError(t, err)
+	detector := sanctions.NewDetector(nil)
+	relayer := NewRelayer(sender, detector, Config{Fees: map[common.Address]*big.Int{token: fee}})
+	return &testRelay{backend: backend, relayer: relayer, detector: detector, stealthKey: stealthKey}
+}
+
+func (tr *testRelay) signSweep(t *testing.T, amount *big.Int) *SweepRequest {
+	quote, err := tr.relayer.Quote(context.Background(), token, crypto.PubkeyToAddress(tr.stealthKey.PublicKey))
+	require.NoError(t, err)
+	deadline := big.NewInt(time.Now().Add(time.Hour).Unix())
+	req, err := SignSweep(tr.stealthKey, quote, destination, amount, deadline)
+	require.NoError(t, err)
+	return req
+}
+
+func (tr *testRelay) balanceOf(t *testing.T, owner common.Address) *big.Int {
+	data, err := tokenABI.Pack("nonces", owner) // Same argument layout as balanceOf
+	require.NoError(t, err)
+	copy(data, crypto.Keccak256([]byte("balanceOf(address)"))[:4])
+	result, err := tr.backend.Client().CallContract(context.Background(), ethereum.CallMsg{To: &token, Data: data}, nil)
+	require.NoError(t, err)
+	return new(big.Int).SetBytes(result)
+}
+
+func TestRelaySweepsUnfundedStealthAddress(t *testing.T) {
+	tr := newTestRelay(t)
+	owner := crypto.PubkeyToAddress(tr.stealthKey.PublicKey)
+
+	quote, err := tr.relayer.Quote(context.Background(), token, owner)
+	require.NoError(t, err)
+	assert.Equal(t, tr.relayer.Address(), quote.Relayer)
+	assert.Equal(t, fee, quote.Fee)
+	assert.Equal(t, int64(0), quote.Nonce.Int64())
+
+	// Wallets sign permits with the Ethereum (27/28) recovery id
+	req := tr.signSweep(t, balance)
+	req.Permit[crypto.RecoveryIDOffset] += 27
+	result, err := tr.relayer.Relay(context.Background(), req)
+	require.NoError(t, err)
+	tr.backend.Commit()
+
+	client := tr.backend.Client()
+	for _, hash := range []common.Hash{result.PermitTx, result.TransferTx, result.FeeTx} {
+		receipt, err := client.TransactionReceipt(context.Background(), hash)
+		require.NoError(t, err)
+		assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	}
+
+	assert.Equal(t, new(big.Int).Sub(balance, fee), tr.balanceOf(t, destination))
+	assert.Equal(t, fee, tr.balanceOf(t, tr.relayer.Address()))
+	assert.Zero(t, tr.balanceOf(t, owner).Sign())
+
+	// The stealth address never needed ETH
+	ethBalance, err := client.BalanceAt(context.Background(), owner, nil)
+	require.NoError(t, err)
+	assert.Zero(t, ethBalance.Sign())
+
+	// The permit nonce was consumed, so the same request cannot be replayed
+	_, err = tr.relayer.Relay(context.Background(), req)
+	assert.Error(t, err)
+}
+
+func TestRelayRejectsInvalidSweeps(t *testing.T) {
+	tr := newTestRelay(t)
+
+	lowFee := tr.signSweep(t, balance)
+	lowFee.Fee = big.NewInt(1)
+	_, err := tr.relayer.Relay(context.Background(), lowFee)
+	assert.ErrorIs(t, err, ErrFeeTooLow)
+
+	// Redirecting the sweep invalidates the owner's signature
+	redirected := tr.signSweep(t, balance)
+	redirected.Destination = common.HexToAddress("0x000000000000000000000000000000000000bad0")
+	_, err = tr.relayer.Relay(context.Background(), redirected)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	// A permit signed by another key is rejected before any gas is spent
+	badPermit := tr.signSweep(t, balance)
+	otherKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	badPermit.Permit, err = crypto.Sign(crypto.Keccak256([]byte("not a permit")), otherKey)
+	require.NoError(t, err)
+	_, err = tr.relayer.Relay(context.Background(), badPermit)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	badV := tr.signSweep(t, balance)
+	badV.Permit[crypto.RecoveryIDOffset] = 29
+	_, err = tr.relayer.Relay(context.Background(), badV)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	tooSmall := tr.signSweep(t, fee)
+	_, err = tr.relayer.Relay(context.Background(), tooSmall)
+	assert.ErrorIs(t, err, ErrAmountTooSmall)
+
+	expired := tr.signSweep(t, balance)
+	tr.relayer.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	_, err = tr.relayer.Relay(context.Background(), expired)
+	assert.ErrorIs(t, err, ErrExpired)
+	tr.relayer.now = time.Now
+
+	_, err = tr.relayer.Quote(context.Background(), common.HexToAddress("0x1234"), destination)
+	assert.ErrorIs(t, err, ErrUnsupportedToken)
+}
+
+func TestRelayScreensDestination(t *testing.T) {
+	tr := newTestRelay(t)
+	tr.detector.AddAddress(destination)
+
+	_, err := tr.relayer.Relay(context.Background(), tr.signSweep(t, balance))
+	assert.ErrorIs(t, err, ErrSanctionedDestination)
+}
@@ -0,0 +1,155 @@
+package gasrelay
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// DomainName is the EIP-712 domain name under which stealth keys authorise sweeps.
+const DomainName = "StealthGasRelayer"
+
+var (
+	permitTypeHash = crypto.Keccak256Hash([]byte("Permit(address owner,address spender,uint256 value,uint256 nonce,uint256 deadline)"))
+	sweepTypeHash  = crypto.Keccak256Hash([]byte("Sweep(address token,address owner,address destination,uint256 amount,uint256 fee,uint256 nonce,uint256 deadline)"))
+	domainTypeHash = crypto.Keccak256Hash([]byte("EIP712Domain(string name,string version,uint256 chainId)"))
+)
+
+var ErrInvalidSignature = errors.New("invalid sweep signature")
+
+// Quote is what the stealth key owner needs to sign a sweep: the relayer becomes the permit spender
+// and takes Fee out of the swept amount.
+type Quote struct {
+	Relayer         common.Address
+	Token           common.Address
+	Owner           common.Address
+	Fee             *big.Int
+	Nonce           *big.Int // The owner's current permit nonce on the token
+	DomainSeparator common.Hash
+	ChainID         *big.Int
+}
+
+// SweepRequest moves Amount of Token from the stealth address Owner: Amount - Fee to Destination and Fee
+// to the relayer. Permit lets the relayer pull Amount; Signature binds the destination and fee.
+type SweepRequest struct {
+	Token       common.Address
+	Owner       common.Address
+	Destination common.Address
+	Amount      *big.Int
+	Fee         *big.Int
+	Nonce       *big.Int
+	Deadline    *big.Int
+	Permit      []byte // 65-byte EIP-2612 permit signature for (relayer, Amount, Nonce, Deadline)
+	Signature   []byte // 65-byte EIP-712 signature over the sweep
+}
+
+// SignSweep builds a sweep request for quote, signed with the recovered stealth private key.
+func SignSweep(stealthKey *ecdsa.PrivateKey, quote *Quote, destination common.Address, amount, deadline *big.Int) (*SweepRequest, error) {
+	req := &SweepRequest{
+		Token:       quote.Token,
+		Owner:       crypto.PubkeyToAddress(stealthKey.PublicKey),
+		Destination: destination,
+		Amount:      new(big.Int).Set(amount),
+		Fee:         new(big.Int).Set(quote.Fee),
+		Nonce:       new(big.Int).Set(quote.Nonce),
+		Deadline:    new(big.Int).Set(deadline),
+	}
+
+	permit, err := crypto.Sign(PermitDigest(quote.DomainSeparator, req.Owner, quote.Relayer, req.Amount, req.Nonce, req.Deadline).Bytes(), stealthKey)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := crypto.Sign(SweepDigest(quote.ChainID, req).Bytes(), stealthKey)
+	if err != nil {
+		return nil, err
+	}
+	req.Permit, req.Signature = permit, signature
+	return req, nil
+}
+
+// PermitDigest is the EIP-2612 digest the token verifies in permit.
+func PermitDigest(domainSeparator common.Hash, owner, spender common.Address, value, nonce, deadline *big.Int) common.Hash {
+	structHash := crypto.Keccak256(
+		permitTypeHash.Bytes(),
+		common.LeftPadBytes(owner.Bytes(), 32),
+		common.LeftPadBytes(spender.Bytes(), 32),
+		math.U256Bytes(new(big.Int).Set(value)),
+		math.U256Bytes(new(big.Int).Set(nonce)),
+		math.U256Bytes(new(big.Int).Set(deadline)),
+	)
+	return typedDataHash(domainSeparator, structHash)
+}
+
+// SweepDigest is the EIP-712 digest of the sweep authorisation.
+func SweepDigest(chainID *big.Int, req *SweepRequest) common.Hash {
+	domainSeparator := crypto.Keccak256Hash(
+		domainTypeHash.Bytes(),
+		crypto.Keccak256([]byte(DomainName)),
+		crypto.Keccak256([]byte("1")),
+		math.U256Bytes(new(big.Int).Set(chainID)),
+	)
+	structHash := crypto.Keccak256(
+		sweepTypeHash.Bytes(),
+		common.LeftPadBytes(req.Token.Bytes(), 32),
+		common.LeftPadBytes(req.Owner.Bytes(), 32),
+		common.LeftPadBytes(req.Destination.Bytes(), 32),
+		math.U256Bytes(new(big.Int).Set(req.Amount)),
+		math.U256Bytes(new(big.Int).Set(req.Fee)),
+		math.U256Bytes(new(big.Int).Set(req.Nonce)),
+		math.U256Bytes(new(big.Int).Set(req.Deadline)),
+	)
+	return typedDataHash(domainSeparator, structHash)
+}
+
+// verifySweep checks that the sweep was signed by its owner.
+func verifySweep(chainID *big.Int, req *SweepRequest) error {
+	return verifySigner(SweepDigest(chainID, req), req.Signature, req.Owner)
+}
+
+// verifyPermit checks that the permit to spender was signed by the owner, so a bad permit is refused
+// before the relayer pays gas for it.
+func verifyPermit(domainSeparator common.Hash, spender common.Address, req *SweepRequest) error {
+	digest := PermitDigest(domainSeparator, req.Owner, spender, req.Amount, req.Nonce, req.Deadline)
+	if err := verifySigner(digest, req.Permit, req.Owner); err != nil {
+		return fmt.Errorf("%w: permit is not signed by the owner", err)
+	}
+	return nil
+}
+
+// verifySigner checks that sig over digest recovers to signer.
+func verifySigner(digest common.Hash, sig []byte, signer common.Address) error {
+	normalized, err := normalizeSignature(sig)
+	if err != nil {
+		return err
+	}
+	pub, err := crypto.SigToPub(digest.Bytes(), normalized)
+	if err != nil || crypto.PubkeyToAddress(*pub) != signer {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// normalizeSignature returns a copy of sig with the raw (0/1) recovery id, accepting both the Ethereum
+// (27/28) and raw ids.
+func normalizeSignature(sig []byte) ([]byte, error) {
+	if len(sig) != crypto.SignatureLength {
+		return nil, ErrInvalidSignature
+	}
+	normalized := common.CopyBytes(sig)
+	if normalized[crypto.RecoveryIDOffset] >= 27 {
+		normalized[crypto.RecoveryIDOffset] -= 27
+	}
+	if normalized[crypto.RecoveryIDOffset] > 1 {
+		return nil, ErrInvalidSignature
+	}
+	return normalized, nil
+}
+
+func typedDataHash(domainSeparator common.Hash, structHash []byte) common.Hash {
+	return crypto.Keccak256Hash([]byte{0x19, 0x01}, domainSeparator.Bytes(), structHash)
+}
@@ -0,0 +1,43 @@
+package listwatch
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/prikshit/blockchain-privacy-module/internal/sanctions"
+)
+
+// OpenIndexFile opens the index of a plain list file too large to hold as entries, such as an exposure
+// list. The index is kept next to the list file with an .idx suffix and rebuilt whenever the list file is
+// newer. Reasons on the lines are not kept.
+func OpenIndexFile(path string, opts sanctions.IndexOptions) (*sanctions.Index, error) {
+	indexPath := path + ".idx"
+	source, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if index, err := os.Stat(indexPath); err != nil || index.ModTime().Before(source.ModTime()) {
+		log.Printf("Building sanctions index for %s\n", path)
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		var addresses []common.Address
+		err = scanPlain(f, func(address common.Address, _ string) {
+			addresses = append(addresses, address)
+		})
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+		if len(addresses) == 0 {
+			return nil, ErrEmptyList
+		}
+		if err := sanctions.BuildIndex(indexPath, addresses); err != nil {
+			return nil, err
+		}
+	}
+	return sanctions.OpenIndex(indexPath, opts)
+}
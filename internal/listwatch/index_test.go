@@ -0,0 +1,37 @@
+package listwatch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prikshit/blockchain-privacy-module/internal/sanctions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenIndexFileRebuildsStaleIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "exposure.txt")
+	writeFile(t, path, "# Exposure list\n"+tornadoRouter.Hex()+", mixer deposit\n")
+
+	idx, err := OpenIndexFile(path, sanctions.IndexOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), idx.Len())
+	require.NoError(t, idx.Close())
+
+	writeFile(t, path, tornadoRouter.Hex()+"\n"+otherAddress.Hex()+"\n")
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, later, later))
+	idx, err = OpenIndexFile(path, sanctions.IndexOptions{})
+	require.NoError(t, err)
+	defer idx.Close()
+	found, err := idx.Contains(otherAddress)
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	writeFile(t, path, "0xAbc123\n")
+	require.NoError(t, os.Chtimes(path, later.Add(time.Minute), later.Add(time.Minute)))
+	_, err = OpenIndexFile(path, sanctions.IndexOptions{})
+	assert.ErrorIs(t, err, sanctions.ErrInvalidAddress)
+}
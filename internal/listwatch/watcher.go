@@ -0,0 +1,314 @@
+package listwatch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/prikshit/blockchain-privacy-module/internal/ofac"
+	"github.com/prikshit/blockchain-privacy-module/internal/sanctions"
+)
+
+// maxEvents is how many reload events the watcher keeps for the endpoint.
+const maxEvents = 100
+
+// DefaultMaxShrink is the largest fraction of a list a new version may drop when Source leaves it unset.
+// A file truncated at a line boundary while it is copied parses cleanly, so a version that shrinks the
+// list by more is refused until it is forced.
+const DefaultMaxShrink = 0.1
+
+var (
+	ErrEmptyList     = errors.New("list file has no addresses")
+	ErrListShrank    = errors.New("list file drops too much of the list")
+	ErrUnknownSource = errors.New("no watched file for this list")
+	ErrAlreadyLoaded = errors.New("list file version is already loaded")
+)
+
+// Reload outcomes.
+const (
+	StatusLoaded = "loaded"
+	StatusFailed = "failed"
+)
+
+// ParseFunc turns the content of a list file into entries of the list. Warnings report parts of the file
+// that were left out, such as malformed addresses of a list that is loaded regardless.
+type ParseFunc func(name string, data []byte) (entries map[common.Address]sanctions.Entry, warnings []string, err error)
+
+// Source is a list file the watcher keeps loaded into one list.
+type Source struct {
+	List      string
+	Path      string
+	Parse     ParseFunc
+	MaxShrink float64 // Largest fraction of the list a version may drop; DefaultMaxShrink when zero, no limit from 1
+}
+
+// OFACFile watches an SDN Advanced XML or legacy CSV file for the OFAC list.
+func OFACFile(path string) Source {
+	return Source{List: sanctions.ListOFAC, Path: path, Parse: parseOFAC}
+}
+
+// PlainFile watches a plain list file: one address per line, optionally followed by a comma and the reason.
+// Blank lines and lines starting with # are ignored.
+func PlainFile(list, path string) Source {
+	return Source{List: list, Path: path, Parse: parsePlain}
+}
+
+// Event records one attempt to load a new version of a list file.
+type Event struct {
+	Time     time.Time `json:"time"`
+	List     string    `json:"list"`
+	Path     string    `json:"path"`
+	Checksum string    `json:"checksum,omitempty"` // SHA-256 of the file version
+	Status   string    `json:"status"`
+	Entries  int       `json:"entries"`  // Entries of the new version
+	Previous int       `json:"previous"` // Entries of the list before the reload
+	Error    string    `json:"error,omitempty"`
+	Warnings []string  `json:"warnings,omitempty"` // Parts of the file left out of a loaded version
+}
+
+// SourceStatus describes the version of a file currently loaded.
+type SourceStatus struct {
+	List     string    `json:"list"`
+	Path     string    `json:"path"`
+	Checksum string    `json:"checksum,omitempty"`
+	LoadedAt time.Time `json:"loaded_at,omitempty"`
+	Failing  bool      `json:"failing"` // The file holds a version that failed to load
+}
+
+type sourceState struct {
+	Source
+	loaded   string // Checksum of the loaded version
+	loadedAt time.Time
+	seen     string // Checksum, or error, of the last version tried, so a bad version is reported once
+	force    bool   // Load the next version even if it shrinks the list
+}
+
+// Watcher polls list files and swaps in each new version once it parses and validates. Parsing happens
+// on the watcher's goroutine; the detector only takes its lock for the swap, so checks are not held up
+// by a large file. A version that fails keeps the previous one in place.
+type Watcher struct {
+	detector *sanctions.Detector
+	mu       sync.Mutex
+	sources  []*sourceState
+	events   []Event
+	now      func() time.Time
+}
+
+// NewWatcher returns a watcher loading sources into detector. Nothing is read until Check or Run.
+func NewWatcher(detector *sanctions.Detector, sources ...Source) (*Watcher, error) {
+	w := &Watcher{detector: detector, now: time.Now}
+	for _, src := range sources {
+		if err := sanctions.ValidateListName(src.List); err != nil {
+			return nil, err
+		}
+		w.sources = append(w.sources, &sourceState{Source: src})
+	}
+	return w, nil
+}
+
+// Check loads every source whose file changed since the last check and returns the events it produced.
+func (w *Watcher) Check() []Event {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var events []Event
+	for _, src := range w.sources {
+		if event, ok := w.check(src); ok {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// Force loads the current version of the file of list even if it drops more of the list than its
+// MaxShrink allows, for a version that really does delist that much. It returns the event of the attempt.
+func (w *Watcher) Force(list string) (Event, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, src := range w.sources {
+		if src.List != list {
+			continue
+		}
+		src.force, src.seen = true, "" // Try the version again even if it was refused
+		event, ok := w.check(src)
+		src.force = false
+		if !ok {
+			return Event{}, ErrAlreadyLoaded
+		}
+		return event, nil
+	}
+	return Event{}, ErrUnknownSource
+}
+
+// Run checks the sources every interval until ctx is cancelled.
+func (w *Watcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.Check()
+		}
+	}
+}
+
+// Events returns the recent reload events, newest first.
+func (w *Watcher) Events() []Event {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	events := make([]Event, len(w.events))
+	for i, event := range w.events {
+		events[len(w.events)-1-i] = event
+	}
+	return events
+}
+
+// Sources returns the watched files and the version loaded from each.
+func (w *Watcher) Sources() []SourceStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	statuses := make([]SourceStatus, 0, len(w.sources))
+	for _, src := range w.sources {
+		statuses = append(statuses, SourceStatus{
+			List:     src.List,
+			Path:     src.Path,
+			Checksum: src.loaded,
+			LoadedAt: src.loadedAt,
+			Failing:  src.seen != src.loaded,
+		})
+	}
+	return statuses
+}
+
+// check reloads src if its file changed, reporting whether anything happened.
+func (w *Watcher) check(src *sourceState) (Event, bool) {
+	event := Event{List: src.List, Path: src.Path}
+
+	data, err := os.ReadFile(src.Path)
+	if err != nil {
+		return w.fail(src, event, err.Error(), err)
+	}
+	sum := sha256.Sum256(data)
+	event.Checksum = hex.EncodeToString(sum[:])
+	if event.Checksum == src.seen {
+		return Event{}, false
+	}
+	if event.Checksum == src.loaded {
+		// Back to the loaded version after a failed one
+		src.seen = src.loaded
+		return Event{}, false
+	}
+	event.Previous = w.detector.Lists()[src.List]
+
+	entries, warnings, err := src.Parse(filepath.Base(src.Path), data)
+	if err == nil && len(entries) == 0 {
+		err = ErrEmptyList
+	}
+	if err == nil && !src.force && shrinks(event.Previous, len(entries), src.MaxShrink) {
+		err = fmt.Errorf("%w: %d entries, previously %d; force the reload if this is intended", ErrListShrank, len(entries), event.Previous)
+	}
+	if err != nil {
+		return w.fail(src, event, event.Checksum, err)
+	}
+	if err := w.detector.ReplaceList(src.List, entries); err != nil {
+		return w.fail(src, event, event.Checksum, err)
+	}
+
+	src.seen, src.loaded, src.loadedAt = event.Checksum, event.Checksum, w.now().UTC()
+	event.Status = StatusLoaded
+	event.Entries = len(entries)
+	event.Warnings = warnings
+	log.Printf("Reloaded sanctions list %s from %s: %d entries, previously %d\n", src.List, src.Path, event.Entries, event.Previous)
+	for _, warning := range warnings {
+		log.Printf("Sanctions list %s from %s: %s\n", src.List, src.Path, warning)
+	}
+	return w.record(event), true
+}
+
+// shrinks reports whether going from previous to entries drops more than maxShrink of the list.
+func shrinks(previous, entries int, maxShrink float64) bool {
+	if maxShrink <= 0 {
+		maxShrink = DefaultMaxShrink
+	}
+	return previous > 0 && float64(previous-entries) > maxShrink*float64(previous)
+}
+
+// fail records a version that could not be loaded, unless the same version already failed.
+func (w *Watcher) fail(src *sourceState, event Event, version string, err error) (Event, bool) {
+	if version == src.seen {
+		return Event{}, false
+	}
+	src.seen = version
+	event.Previous = w.detector.Lists()[src.List]
+	event.Status = StatusFailed
+	event.Error = err.Error()
+	log.Printf("Keeping the current %s list; reloading %s failed: %v\n", src.List, src.Path, err)
+	return w.record(event), true
+}
+
+func (w *Watcher) record(event Event) Event {
+	event.Time = w.now().UTC()
+	w.events = append(w.events, event)
+	if len(w.events) > maxEvents {
+		w.events = w.events[len(w.events)-maxEvents:]
+	}
+	return event
+}
+
+// parseOFAC loads the list even if some addresses are malformed, since refusing it would also hold back every
+// new designation; each malformed address is reported as a warning instead.
+func parseOFAC(name string, data []byte) (map[common.Address]sanctions.Entry, []string, error) {
+	list, err := ofac.Parse(name, bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	entries, stats := list.Entries()
+	var warnings []string
+	for _, a := range stats.Rejected {
+		warnings = append(warnings, fmt.Sprintf("malformed %s address %q of SDN entity %s left out", a.Currency, a.Address, a.EntityID))
+	}
+	return entries, warnings, nil
+}
+
+// parsePlain refuses the whole file if any line is malformed, since a half-read list must not replace a good one.
+func parsePlain(name string, data []byte) (map[common.Address]sanctions.Entry, []string, error) {
+	entries := make(map[common.Address]sanctions.Entry)
+	err := scanPlain(bytes.NewReader(data), func(address common.Address, reason string) {
+		entries[address] = sanctions.Entry{Reference: name, Reason: reason}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return entries, nil, nil
+}
+
+// scanPlain calls fn for every address of a plain list file, failing on the first malformed line.
+func scanPlain(r io.Reader, fn func(address common.Address, reason string)) error {
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		field, reason, _ := strings.Cut(text, ",")
+		address, err := sanctions.ParseAddress(field)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		fn(address, strings.TrimSpace(reason))
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,148 @@
+package listwatch
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/prikshit/blockchain-privacy-module/internal/sanctions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	tornadoRouter = common.HexToAddress("0x8589427373D6D84E98730D7795D8f6f8731FDA16")
+	otherAddress  = common.HexToAddress("0x722122dF12D4e14e13Ac3b6895a86e84145b6967")
+)
+
+func TestReloadKeepsLastGoodVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "eu.txt")
+	detector := sanctions.NewDetector(nil)
+	w, err := NewWatcher(detector, PlainFile(sanctions.ListEU, path))
+	require.NoError(t, err)
+
+	// A missing file is reported once
+	events := w.Check()
+	require.Len(t, events, 1)
+	assert.Equal(t, StatusFailed, events[0].Status)
+	assert.Empty(t, w.Check())
+
+	writeFile(t, path, "# EU Regulation 2022/1269\n"+tornadoRouter.Hex()+", Mixer\n")
+	events = w.Check()
+	require.Len(t, events, 1)
+	assert.Equal(t, StatusLoaded, events[0].Status)
+	assert.Equal(t, 1, events[0].Entries)
+	assert.Equal(t, "Mixer", detector.Matches(tornadoRouter)[0].Reason)
+	assert.Empty(t, w.Check(), "an unchanged file is not reloaded")
+
+	// A version with a malformed line is refused as a whole
+	writeFile(t, path, tornadoRouter.Hex()+"\n"+otherAddress.Hex()+"\n0x722122dF12D4e14e13Ac3b\n")
+	events = w.Check()
+	require.Len(t, events, 1)
+	assert.Equal(t, StatusFailed, events[0].Status)
+	assert.Contains(t, events[0].Error, "line 3")
+	assert.Equal(t, 1, events[0].Previous)
+	assert.False(t, detector.IsSanctioned(otherAddress))
+	assert.True(t, w.Sources()[0].Failing)
+	assert.Empty(t, w.Check(), "a failed version is reported once")
+
+	writeFile(t, path, "")
+	events = w.Check()
+	require.Len(t, events, 1)
+	assert.Equal(t, ErrEmptyList.Error(), events[0].Error)
+	assert.True(t, detector.IsSanctioned(tornadoRouter))
+
+	writeFile(t, path, otherAddress.Hex()+"\n")
+	events = w.Check()
+	require.Len(t, events, 1)
+	assert.Equal(t, StatusLoaded, events[0].Status)
+	assert.True(t, detector.IsSanctioned(otherAddress))
+	assert.False(t, detector.IsSanctioned(tornadoRouter))
+	assert.False(t, w.Sources()[0].Failing)
+
+	history := w.Events()
+	require.Len(t, history, 5)
+	assert.Equal(t, events[0], history[0], "events are listed newest first")
+}
+
+func TestReloadOFACFile(t *testing.T) {
+	data, err := os.ReadFile("../ofac/testdata/sdn_advanced.xml")
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "sdn_advanced.xml")
+	writeFile(t, path, string(data))
+
+	detector := sanctions.NewDetector(nil)
+	w, err := NewWatcher(detector, OFACFile(path))
+	require.NoError(t, err)
+	events := w.Check()
+	require.Len(t, events, 1)
+	assert.Equal(t, StatusLoaded, events[0].Status)
+	assert.Equal(t, 6, events[0].Entries)
+	assert.Equal(t, "TORNADO CASH", detector.Matches(tornadoRouter)[0].Entity)
+	// The malformed ETH address of the fixture is reported rather than silently dropped
+	require.Len(t, events[0].Warnings, 1)
+	assert.Contains(t, events[0].Warnings[0], "0x3Cffd56B47B7b41c56258D9C7731ABaDc360E07")
+
+	// A file cut off mid-copy fails to parse and leaves the list in place
+	writeFile(t, path, string(data[:len(data)/2]))
+	events = w.Check()
+	require.Len(t, events, 1)
+	assert.Equal(t, StatusFailed, events[0].Status)
+	assert.Equal(t, map[string]int{sanctions.ListOFAC: 6}, detector.Lists())
+}
+
+func TestReloadRefusesTruncatedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "eu.txt")
+	var full strings.Builder
+	for i := range 10 {
+		fmt.Fprintf(&full, "%s, Entity %d\n", common.BigToAddress(big.NewInt(int64(i+1))).Hex(), i+1)
+	}
+	writeFile(t, path, full.String())
+
+	detector := sanctions.NewDetector(nil)
+	w, err := NewWatcher(detector, PlainFile(sanctions.ListEU, path))
+	require.NoError(t, err)
+	require.Equal(t, StatusLoaded, w.Check()[0].Status)
+
+	// Cut off at a line boundary mid-copy, the file parses cleanly but drops most of the list
+	lines := strings.SplitAfter(full.String(), "\n")
+	writeFile(t, path, strings.Join(lines[:3], ""))
+	events := w.Check()
+	require.Len(t, events, 1)
+	assert.Equal(t, StatusFailed, events[0].Status)
+	assert.Contains(t, events[0].Error, ErrListShrank.Error())
+	assert.Equal(t, map[string]int{sanctions.ListEU: 10}, detector.Lists())
+	assert.Empty(t, w.Check(), "a refused version is reported once")
+
+	// Dropping one entry of ten is within the default limit
+	writeFile(t, path, strings.Join(lines[:9], ""))
+	require.Equal(t, StatusLoaded, w.Check()[0].Status)
+
+	// An intended delisting of that size is loaded when forced
+	writeFile(t, path, strings.Join(lines[:3], ""))
+	require.Equal(t, StatusFailed, w.Check()[0].Status)
+	event, err := w.Force(sanctions.ListEU)
+	require.NoError(t, err)
+	assert.Equal(t, StatusLoaded, event.Status)
+	assert.Equal(t, 9, event.Previous)
+	assert.Equal(t, map[string]int{sanctions.ListEU: 3}, detector.Lists())
+
+	_, err = w.Force(sanctions.ListEU)
+	assert.ErrorIs(t, err, ErrAlreadyLoaded)
+	_, err = w.Force(sanctions.ListUN)
+	assert.ErrorIs(t, err, ErrUnknownSource)
+}
+
+func TestNewWatcherRejectsBadListName(t *testing.T) {
+	_, err := NewWatcher(sanctions.NewDetector(nil), PlainFile("EU list", "eu.txt"))
+	assert.ErrorIs(t, err, sanctions.ErrInvalidListName)
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
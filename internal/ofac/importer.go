@@ -0,0 +1,307 @@
+package ofac
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/prikshit/blockchain-privacy-module/internal/sanctions"
+)
+
+// digitalCurrencyPrefix starts the name of every digital currency address feature type, e.g.
+// "Digital Currency Address - ETH".
+const digitalCurrencyPrefix = "Digital Currency Address - "
+
+var ErrUnknownFormat = errors.New("unknown SDN file format; expected .xml (SDN Advanced) or .csv (legacy)")
+
+// csvAddressPattern finds digital currency addresses in the remarks column of the legacy CSV,
+// e.g. "Digital Currency Address - ETH 0x8589...; alt. Digital Currency Address - ETH 0x7221...".
+var csvAddressPattern = regexp.MustCompile(`Digital Currency Address - ([A-Za-z0-9]+) ([^\s;]+)`)
+
+// Address is a digital currency address listed for an SDN entity.
+type Address struct {
+	Currency   string `json:"currency"` // Ticker from the feature type, e.g. ETH, XBT, USDT
+	Address    string `json:"address"`
+	EntityID   string `json:"entity_id"` // SDN entity number (FixedRef / ent_num)
+	EntityName string `json:"entity_name"`
+}
+
+// List is the set of digital currency addresses extracted from one SDN file.
+type List struct {
+	Reference string // File name and, for the XML, the date of issue
+	Addresses []Address
+}
+
+// evmCurrencies are the tickers whose addresses are always Ethereum-style. Tokens such as USDT are also
+// issued on other chains, so their addresses are only expected to be Ethereum-style when they start with 0x.
+var evmCurrencies = map[string]bool{"ETH": true, "ETC": true, "ARB": true, "BSC": true}
+
+// Stats summarises what loading a list into a detector did.
+type Stats struct {
+	Entities   int            `json:"entities"`
+	Addresses  int            `json:"addresses"`
+	Loaded     int            `json:"loaded"`
+	NonEVM     int            `json:"non_evm"` // Addresses that are not Ethereum-style, e.g. XBT
+	ByCurrency map[string]int `json:"by_currency"`
+	Rejected   []Address      `json:"rejected,omitempty"` // Ethereum-style addresses too malformed to load
+}
+
+// LoadFile parses an SDN Advanced XML or legacy CSV file, chosen by extension.
+func LoadFile(path string) (*List, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	list, err := Parse(filepath.Base(path), f)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	log.Printf("Extracted %d digital currency addresses from %s\n", len(list.Addresses), path)
+	return list, nil
+}
+
+// Parse reads an SDN file whose format is chosen by the extension of name, which also becomes the list reference.
+func Parse(name string, r io.Reader) (*List, error) {
+	var list *List
+	var err error
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".xml":
+		list, err = ParseAdvancedXML(r)
+	case ".csv":
+		list, err = ParseCSV(r)
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	if list.Reference == "" {
+		list.Reference = name
+	} else {
+		list.Reference = name + " " + list.Reference
+	}
+	return list, nil
+}
+
+// Entries returns the OFAC list entry of every Ethereum-style address of the list, tagged with its SDN
+// entity. Addresses are loaded whatever their casing, since OFAC does not publish EIP-55 checksums.
+// Addresses of other chains are counted but left out, and malformed addresses of Ethereum-style
+// currencies are returned in Stats.Rejected so the caller can report them.
+func (l *List) Entries() (map[common.Address]sanctions.Entry, Stats) {
+	stats := Stats{ByCurrency: make(map[string]int)}
+	entities := make(map[string]bool)
+	entries := make(map[common.Address]sanctions.Entry)
+	for _, a := range l.Addresses {
+		stats.Addresses++
+		stats.ByCurrency[a.Currency]++
+		entities[a.EntityID] = true
+
+		if !common.IsHexAddress(a.Address) {
+			if evmCurrencies[a.Currency] || strings.HasPrefix(strings.ToLower(a.Address), "0x") {
+				stats.Rejected = append(stats.Rejected, a)
+			} else {
+				stats.NonEVM++
+			}
+			continue
+		}
+		entries[common.HexToAddress(a.Address)] = sanctions.Entry{
+			Reference: l.Reference,
+			Reason:    "Digital currency address of an SDN entity",
+			EntityID:  a.EntityID,
+			Entity:    a.EntityName,
+			Currency:  a.Currency,
+		}
+	}
+	stats.Entities = len(entities)
+	stats.Loaded = len(entries)
+	return entries, stats
+}
+
+// Load replaces the detector's OFAC list with the entries of the list, so addresses delisted since the
+// previous import are dropped.
+func (l *List) Load(d *sanctions.Detector) (Stats, error) {
+	entries, stats := l.Entries()
+	if err := d.ReplaceList(sanctions.ListOFAC, entries); err != nil {
+		stats.Loaded = 0
+		return stats, err
+	}
+	log.Printf("Loaded %d of %d SDN addresses from %d entities\n", stats.Loaded, stats.Addresses, stats.Entities)
+	for _, a := range stats.Rejected {
+		log.Printf("Rejected malformed SDN %s address %q of entity %s\n", a.Currency, a.Address, a.EntityID)
+	}
+	return stats, nil
+}
+
+// Elements of the SDN Advanced XML that the importer reads. Element names are matched without
+// their namespace, which changes between publications.
+type featureType struct {
+	ID   string `xml:"ID,attr"`
+	Name string `xml:",chardata"`
+}
+
+type distinctParty struct {
+	FixedRef string `xml:"FixedRef,attr"`
+	Profiles []struct {
+		Identities []struct {
+			Aliases []struct {
+				Primary bool `xml:"Primary,attr"`
+				Names   []struct {
+					Parts []string `xml:"DocumentedNamePart>NamePartValue"`
+				} `xml:"DocumentedName"`
+			} `xml:"Alias"`
+		} `xml:"Identity"`
+		Features []struct {
+			TypeID  string   `xml:"FeatureTypeID,attr"`
+			Details []string `xml:"FeatureVersion>VersionDetail"`
+		} `xml:"Feature"`
+	} `xml:"Profile"`
+}
+
+type dateOfIssue struct {
+	Year  int `xml:"Year"`
+	Month int `xml:"Month"`
+	Day   int `xml:"Day"`
+}
+
+// ParseAdvancedXML extracts digital currency addresses from the SDN Advanced XML. The file is
+// streamed one party at a time, since the full list is too large to hold as a document tree.
+func ParseAdvancedXML(r io.Reader) (*List, error) {
+	decoder := xml.NewDecoder(r)
+	list := &List{}
+	currencies := make(map[string]string) // Feature type ID -> ticker
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "DateOfIssue":
+			var date dateOfIssue
+			if err := decoder.DecodeElement(&date, &start); err != nil {
+				return nil, err
+			}
+			list.Reference = fmt.Sprintf("issued %04d-%02d-%02d", date.Year, date.Month, date.Day)
+		case "FeatureType":
+			var ft featureType
+			if err := decoder.DecodeElement(&ft, &start); err != nil {
+				return nil, err
+			}
+			if ticker, ok := strings.CutPrefix(strings.TrimSpace(ft.Name), digitalCurrencyPrefix); ok {
+				currencies[ft.ID] = ticker
+			}
+		case "DistinctParty":
+			var party distinctParty
+			if err := decoder.DecodeElement(&party, &start); err != nil {
+				return nil, err
+			}
+			list.Addresses = append(list.Addresses, party.addresses(currencies)...)
+		}
+	}
+	return list, nil
+}
+
+func (p *distinctParty) addresses(currencies map[string]string) []Address {
+	var addresses []Address
+	name := p.primaryName()
+	for _, profile := range p.Profiles {
+		for _, feature := range profile.Features {
+			currency, ok := currencies[feature.TypeID]
+			if !ok {
+				continue
+			}
+			for _, detail := range feature.Details {
+				if detail = strings.TrimSpace(detail); detail != "" {
+					addresses = append(addresses, Address{Currency: currency, Address: detail, EntityID: p.FixedRef, EntityName: name})
+				}
+			}
+		}
+	}
+	return addresses
+}
+
+// primaryName joins the name parts of the party's primary alias, e.g. "LAZARUS GROUP".
+func (p *distinctParty) primaryName() string {
+	for _, profile := range p.Profiles {
+		for _, identity := range profile.Identities {
+			for _, alias := range identity.Aliases {
+				if !alias.Primary || len(alias.Names) == 0 {
+					continue
+				}
+				parts := make([]string, 0, len(alias.Names[0].Parts))
+				for _, part := range alias.Names[0].Parts {
+					parts = append(parts, strings.TrimSpace(part))
+				}
+				return strings.Join(parts, " ")
+			}
+		}
+	}
+	return ""
+}
+
+// ParseCSV extracts digital currency addresses from the remarks of the legacy sdn.csv. Its columns
+// are ent_num, SDN_Name, SDN_Type, Program, Title, Call_Sign, Vess_type, Tonnage, GRT, Vess_flag,
+// Vess_owner and Remarks, with "-0-" for empty values and no header row.
+func ParseCSV(r io.Reader) (*List, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	list := &List{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		// The file ends with a stray EOF control character on its own line
+		if len(record) < 12 {
+			continue
+		}
+		entityID, name, remarks := strings.TrimSpace(record[0]), strings.TrimSpace(record[1]), record[11]
+		for _, match := range csvAddressPattern.FindAllStringSubmatch(remarks, -1) {
+			list.Addresses = append(list.Addresses, Address{
+				Currency:   match[1],
+				Address:    strings.TrimRight(match[2], "."),
+				EntityID:   entityID,
+				EntityName: name,
+			})
+		}
+	}
+	return list, nil
+}
+
+// Currencies returns the tickers present in the list, sorted.
+func (l *List) Currencies() []string {
+	seen := make(map[string]bool)
+	var currencies []string
+	for _, a := range l.Addresses {
+		if !seen[a.Currency] {
+			seen[a.Currency] = true
+			currencies = append(currencies, a.Currency)
+		}
+	}
+	sort.Strings(currencies)
+	return currencies
+}
@@ -0,0 +1,117 @@
+package ofac
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/prikshit/blockchain-privacy-module/internal/sanctions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	roninExploiter = common.HexToAddress("0x098B716B8Aaf21512996dC57EB0615e2383E2f96")
+	tornadoRouter  = common.HexToAddress("0x8589427373D6D84E98730D7795D8f6f8731FDA16")
+	semenov        = common.HexToAddress("0xdcbEfFBECcE100cCE9E4b153C4e15cB885643193")
+	tornadoETC     = common.HexToAddress("0x5f48C2A71B2CC96e3F0CCae4E39318Ff0dc375b2")
+	lazarusBadCase = common.HexToAddress("0x3Cffd56B47B7b41c56258D9C7731ABaDc360E073") // Published with a casing that fails EIP-55
+)
+
+func TestParseAdvancedXML(t *testing.T) {
+	list, err := LoadFile("testdata/sdn_advanced.xml")
+	require.NoError(t, err)
+	assert.Equal(t, "sdn_advanced.xml issued 2024-05-14", list.Reference)
+	assert.Equal(t, []string{"ETC", "ETH", "USDT", "XBT"}, list.Currencies())
+	require.Len(t, list.Addresses, 9)
+
+	assert.Contains(t, list.Addresses, Address{Currency: "XBT", Address: "12QtD5BFwRsdNsAZY76UVE1xyCGNTojH9h", EntityID: "23036", EntityName: "LAZARUS GROUP"})
+	assert.Contains(t, list.Addresses, Address{Currency: "ETH", Address: "0xdcbeffbecce100cce9e4b153c4e15cb885643193", EntityID: "32929", EntityName: "SEMENOV Roman"})
+	for _, a := range list.Addresses {
+		assert.NotEqual(t, "7160", a.EntityID, "party without digital currency addresses")
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	list, err := LoadFile("testdata/sdn.csv")
+	require.NoError(t, err)
+	assert.Equal(t, "sdn.csv", list.Reference)
+	assert.Equal(t, []string{"ETC", "ETH", "USDT", "XBT"}, list.Currencies())
+	require.Len(t, list.Addresses, 9)
+
+	assert.Contains(t, list.Addresses, Address{Currency: "USDT", Address: "TNhEBw2GC1QgBWE8wu1Ueg3bFkyRb5CE4Z", EntityID: "40115", EntityName: "TORNADO CASH"})
+	assert.Contains(t, list.Addresses, Address{Currency: "ETH", Address: "0xdcbeffbecce100cce9e4b153c4e15cb885643193", EntityID: "32929", EntityName: "SEMENOV, Roman"})
+}
+
+func TestFormatsAgree(t *testing.T) {
+	advanced, err := LoadFile("testdata/sdn_advanced.xml")
+	require.NoError(t, err)
+	legacy, err := LoadFile("testdata/sdn.csv")
+	require.NoError(t, err)
+
+	key := func(a Address) string { return a.EntityID + " " + a.Currency + " " + a.Address }
+	var fromXML, fromCSV []string
+	for _, a := range advanced.Addresses {
+		fromXML = append(fromXML, key(a))
+	}
+	for _, a := range legacy.Addresses {
+		fromCSV = append(fromCSV, key(a))
+	}
+	assert.ElementsMatch(t, fromXML, fromCSV)
+}
+
+func TestLoadIntoDetector(t *testing.T) {
+	list, err := LoadFile("testdata/sdn_advanced.xml")
+	require.NoError(t, err)
+
+	detector := sanctions.NewDetector(nil)
+	stats, err := list.Load(detector)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{
+		Entities:   3,
+		Addresses:  9,
+		Loaded:     6,
+		NonEVM:     2,
+		ByCurrency: map[string]int{"ETH": 6, "ETC": 1, "XBT": 1, "USDT": 1},
+		Rejected: []Address{
+			{Currency: "ETH", Address: "0x3Cffd56B47B7b41c56258D9C7731ABaDc360E07", EntityID: "23036", EntityName: "LAZARUS GROUP"},
+		},
+	}, stats)
+
+	for _, address := range []common.Address{roninExploiter, tornadoRouter, semenov, tornadoETC, lazarusBadCase} {
+		assert.True(t, detector.IsSanctioned(address), address.Hex())
+	}
+	matches := detector.Matches(tornadoETC)
+	require.Len(t, matches, 1)
+	assert.Equal(t, sanctions.ListOFAC, matches[0].List)
+	assert.Equal(t, "sdn_advanced.xml issued 2024-05-14", matches[0].Reference)
+	assert.Equal(t, "40115", matches[0].EntityID)
+	assert.Equal(t, "TORNADO CASH", matches[0].Entity)
+	assert.Equal(t, "ETC", matches[0].Currency)
+	assert.False(t, matches[0].Added.IsZero())
+
+	// Loading the same list again does not duplicate entries
+	_, err = list.Load(detector)
+	require.NoError(t, err)
+	assert.Len(t, detector.Matches(tornadoRouter), 1)
+	assert.Equal(t, map[string]int{sanctions.ListOFAC: 6}, detector.Lists())
+
+	// A newer publication drops delisted addresses and keeps the date the others were first listed
+	added := detector.Matches(tornadoRouter)[0].Added
+	var kept []Address
+	for _, a := range list.Addresses {
+		if !strings.EqualFold(a.Address, roninExploiter.Hex()) {
+			kept = append(kept, a)
+		}
+	}
+	list.Addresses = kept
+	_, err = list.Load(detector)
+	require.NoError(t, err)
+	assert.False(t, detector.IsSanctioned(roninExploiter))
+	assert.Equal(t, added, detector.Matches(tornadoRouter)[0].Added)
+}
+
+func TestLoadFileRejectsUnknownFormat(t *testing.T) {
+	_, err := LoadFile("importer.go")
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
@@ -0,0 +1,76 @@
+package privacy
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
+	"log"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/prikshit/blockchain-privacy-module/internal/ephemeral"
+)
+
+var ErrEphemeralKeyReused = errors.New("ephemeral key reused")
+
+// generateEphemeralKey is the source of ephemeral keys; tests replace it to simulate a faulty RNG.
+var generateEphemeralKey = func() (*ecdsa.PrivateKey, error) {
+	return ecdsa.GenerateKey(crypto.S256(), rand.Reader)
+}
+
+// EphemeralKeyReuse is an ephemeral public key that appears in more than one announcement.
+type EphemeralKeyReuse struct {
+	EphemeralPubKey  *ecdsa.PublicKey
+	StealthAddresses []common.Address
+}
+
+// newEphemeralKey generates an ephemeral keypair and, when a key store is configured, records it,
+// refusing any key that was used before. A repeated key would let observers link the payments.
+func (pm *PrivacyManager) newEphemeralKey() (*ecdsa.PrivateKey, error) {
+	ephemeralPrivKey, err := generateEphemeralKey()
+	if err != nil {
+		log.Printf("Error generating ephemeral key: %v\n", err)
+		return nil, err
+	}
+	if pm.EphemeralKeys == nil {
+		return ephemeralPrivKey, nil
+	}
+
+	if err := pm.EphemeralKeys.Record(&ephemeralPrivKey.PublicKey); err != nil {
+		if errors.Is(err, ephemeral.ErrKeyReused) {
+			log.Println("Refusing to reuse an ephemeral key; check the random number generator")
+			return nil, ErrEphemeralKeyReused
+		}
+		log.Printf("Error recording ephemeral key: %v\n", err)
+		return nil, err
+	}
+	return ephemeralPrivKey, nil
+}
+
+// AuditEphemeralKeys returns every ephemeral key used by more than one of the given announcements,
+// in order of first appearance.
+func AuditEphemeralKeys(announcements []*Announcement) []*EphemeralKeyReuse {
+	log.Printf("Auditing %d announcements for ephemeral key reuse\n", len(announcements))
+
+	byKey := make(map[string]*EphemeralKeyReuse)
+	var order []string
+	for _, ann := range announcements {
+		key := string(crypto.CompressPubkey(ann.EphemeralPubKey))
+		reuse, ok := byKey[key]
+		if !ok {
+			reuse = &EphemeralKeyReuse{EphemeralPubKey: ann.EphemeralPubKey}
+			byKey[key] = reuse
+			order = append(order, key)
+		}
+		reuse.StealthAddresses = append(reuse.StealthAddresses, ann.StealthAddress)
+	}
+
+	var reused []*EphemeralKeyReuse
+	for _, key := range order {
+		if len(byKey[key].StealthAddresses) > 1 {
+			reused = append(reused, byKey[key])
+		}
+	}
+	log.Printf("Found %d reused ephemeral keys\n", len(reused))
+	return reused
+}
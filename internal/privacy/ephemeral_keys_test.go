@@ -0,0 +1,71 @@
+package privacy
+
+import (
+	"crypto/ecdsa"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/prikshit/blockchain-privacy-module/internal/ephemeral"
+	"github.com/prikshit/blockchain-privacy-module/internal/sanctions"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerationRecordsEphemeralKeys(t *testing.T) {
+	pm := NewPrivacyManager(sanctions.NewDetector(nil))
+	pm.EphemeralKeys = ephemeral.NewMemoryStore(ephemeral.Options{ExpectedKeys: 100})
+	keys := generateStealthKeys(t)
+
+	_, ann, err := pm.GenerateStealthPayment(keys.MetaAddress())
+	assert.NoError(t, err)
+	found, err := pm.EphemeralKeys.Contains(ann.EphemeralPubKey)
+	assert.NoError(t, err)
+	assert.True(t, found)
+
+	_, ephemeralPriv, err := pm.GenerateStealthAddress(&keys.SpendingKey.PublicKey)
+	assert.NoError(t, err)
+	found, err = pm.EphemeralKeys.Contains(&ephemeralPriv.PublicKey)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, uint64(2), pm.EphemeralKeys.Stats().Keys)
+}
+
+func TestGenerationRejectsRepeatedEphemeralKey(t *testing.T) {
+	// Simulate an RNG that keeps returning the same key
+	stuck, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	original := generateEphemeralKey
+	generateEphemeralKey = func() (*ecdsa.PrivateKey, error) { return stuck, nil }
+	defer func() { generateEphemeralKey = original }()
+
+	pm := NewPrivacyManager(sanctions.NewDetector(nil))
+	pm.EphemeralKeys = ephemeral.NewMemoryStore(ephemeral.Options{ExpectedKeys: 100})
+	keys := generateStealthKeys(t)
+
+	_, _, err = pm.GenerateStealthPayment(keys.MetaAddress())
+	assert.NoError(t, err)
+	_, _, err = pm.GenerateStealthPayment(keys.MetaAddress())
+	assert.ErrorIs(t, err, ErrEphemeralKeyReused)
+	_, _, err = pm.GenerateStealthAddress(&keys.SpendingKey.PublicKey)
+	assert.ErrorIs(t, err, ErrEphemeralKeyReused)
+}
+
+func TestAuditEphemeralKeys(t *testing.T) {
+	pm := NewPrivacyManager(sanctions.NewDetector(nil))
+	keys := generateStealthKeys(t)
+
+	var announcements []*Announcement
+	for range 3 {
+		_, ann, err := pm.GenerateStealthPayment(keys.MetaAddress())
+		assert.NoError(t, err)
+		announcements = append(announcements, ann)
+	}
+	assert.Empty(t, AuditEphemeralKeys(announcements))
+
+	// A historical announcement that repeated the first key
+	repeated := &Announcement{StealthAddress: announcements[2].StealthAddress, EphemeralPubKey: announcements[0].EphemeralPubKey}
+	reused := AuditEphemeralKeys(append(announcements, repeated))
+	assert.Len(t, reused, 1)
+	assert.Equal(t, announcements[0].EphemeralPubKey, reused[0].EphemeralPubKey)
+	assert.Equal(t, []common.Address{announcements[0].StealthAddress, announcements[2].StealthAddress}, reused[0].StealthAddresses)
+}
@@ -0,0 +1,193 @@
+package privacy
+
+import (
+	"crypto/ecdsa"
+	"crypto/mlkem"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// HybridMetadataSize is the size of the announcement metadata for hybrid payments: view tag || ML-KEM ciphertext.
+// Token metadata, when present, follows the ciphertext.
+const HybridMetadataSize = 1 + mlkem.CiphertextSize768
+
+var (
+	ErrNotHybridMetaAddress = errors.New("meta-address has no ML-KEM key")
+	ErrInvalidMetadata      = errors.New("invalid announcement metadata")
+	ErrAnnouncementMismatch = errors.New("announcement does not belong to these keys")
+)
+
+// HybridKeys is the recipient's key material for the hybrid secp256k1 ECDH + ML-KEM-768 stealth scheme.
+type HybridKeys struct {
+	SpendingKey *ecdsa.PrivateKey
+	ViewingKey  *ecdsa.PrivateKey
+	KEMKey      *mlkem.DecapsulationKey768
+}
+
+// Announcement is the data a payer publishes so the recipient can find a stealth payment (EIP-5564).
+type Announcement struct {
+	StealthAddress  common.Address
+	EphemeralPubKey *ecdsa.PublicKey
+	Metadata        []byte // The first byte is always the view tag.
+}
+
+// GenerateHybridKeys creates fresh spending, viewing and ML-KEM-768 keys for a recipient.
+func GenerateHybridKeys() (*HybridKeys, error) {
+	log.Println("Generating hybrid stealth keys")
+
+	spendingKey, err := ecdsa.GenerateKey(crypto.S256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	viewingKey, err := ecdsa.GenerateKey(crypto.S256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	kemKey, err := mlkem.GenerateKey768()
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("Hybrid stealth keys generated successfully")
+	return &HybridKeys{SpendingKey: spendingKey, ViewingKey: viewingKey, KEMKey: kemKey}, nil
+}
+
+// MetaAddress returns the hybrid meta-address the recipient publishes for these keys.
+func (k *HybridKeys) MetaAddress() *StealthMetaAddress {
+	return &StealthMetaAddress{
+		SpendingPubKey: &k.SpendingKey.PublicKey,
+		ViewingPubKey:  &k.ViewingKey.PublicKey,
+		KEMPubKey:      k.KEMKey.EncapsulationKey(),
+	}
+}
+
+// GenerateHybridStealthAddress derives a stealth public key for a hybrid meta-address.
+// The shared secret is s = H(d_e * P_view || ss_kem), so an attacker must break both ECDH and ML-KEM to link the payment.
+func (pm *PrivacyManager) GenerateHybridStealthAddress(meta *StealthMetaAddress) (*ecdsa.PublicKey, *Announcement, error) {
+	if !meta.IsHybrid() {
+		return nil, nil, ErrNotHybridMetaAddress
+	}
+
+	// Check if the spending key is sanctioned
+	address := crypto.PubkeyToAddress(*meta.SpendingPubKey)
+	log.Printf("Attempting to generate hybrid stealth address for: %s\n", address.Hex())
+
+	if pm.Detector.IsSanctioned(address) {
+		log.Printf("Sanctioned address detected: %s\n", address.Hex())
+		return nil, nil, ErrSanctionedAddress
+	}
+
+	// Generate ephemeral keypair
+	ephemeralPrivKey, err := pm.newEphemeralKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Encapsulate to the recipient's ML-KEM key and combine with the ECDH secret
+	kemShared, ciphertext := meta.KEMPubKey.Encapsulate()
+	sharedX, _ := crypto.S256().ScalarMult(meta.ViewingPubKey.X, meta.ViewingPubKey.Y, ephemeralPrivKey.D.Bytes())
+	sharedSecret := hybridSharedSecret(sharedX, kemShared)
+
+	// Compute stealth public key: P_s = P_spend + s * G
+	stealthPub := addScalarBaseMult(meta.SpendingPubKey, sharedSecret)
+
+	announcement := &Announcement{
+		StealthAddress:  crypto.PubkeyToAddress(*stealthPub),
+		EphemeralPubKey: &ephemeralPrivKey.PublicKey,
+		Metadata:        append([]byte{sharedSecret[0]}, ciphertext...),
+	}
+
+	log.Println("Hybrid stealth public key generated successfully")
+	return stealthPub, announcement, nil
+}
+
+// ScanHybridAnnouncements returns the announcements addressed to the recipient owning the viewing and ML-KEM keys.
+// Only the spending public key is needed, so scanning can be delegated without exposing spending authority.
+func (pm *PrivacyManager) ScanHybridAnnouncements(viewingKey *ecdsa.PrivateKey, kemKey *mlkem.DecapsulationKey768, spendingPub *ecdsa.PublicKey, announcements []*Announcement) []*Announcement {
+	log.Printf("Scanning %d announcements for hybrid stealth payments\n", len(announcements))
+
+	var matches []*Announcement
+	for _, ann := range announcements {
+		sharedSecret, err := hybridSecretFromAnnouncement(viewingKey, kemKey, ann)
+		if err != nil {
+			continue
+		}
+		// View tag lets us skip most foreign announcements before the point addition
+		if sharedSecret[0] != ann.Metadata[0] {
+			continue
+		}
+		if crypto.PubkeyToAddress(*addScalarBaseMult(spendingPub, sharedSecret)) == ann.StealthAddress {
+			matches = append(matches, ann)
+		}
+	}
+
+	log.Printf("Found %d matching hybrid announcements\n", len(matches))
+	return matches
+}
+
+// RecoverHybridStealthPrivateKey recovers the stealth private key for a hybrid announcement: d_s = (d_spend + s) mod n.
+func (pm *PrivacyManager) RecoverHybridStealthPrivateKey(keys *HybridKeys, ann *Announcement) (*ecdsa.PrivateKey, error) {
+	log.Println("Recovering hybrid stealth private key")
+
+	sharedSecret, err := hybridSecretFromAnnouncement(keys.ViewingKey, keys.KEMKey, ann)
+	if err != nil {
+		log.Printf("Error computing hybrid shared secret: %v\n", err)
+		return nil, err
+	}
+
+	stealthPrivKey, err := addScalar(keys.SpendingKey, sharedSecret)
+	if err != nil {
+		return nil, err
+	}
+	if crypto.PubkeyToAddress(stealthPrivKey.PublicKey) != ann.StealthAddress {
+		log.Println("Recovered key does not match the announced stealth address")
+		return nil, ErrAnnouncementMismatch
+	}
+
+	log.Println("Hybrid stealth private key recovered successfully")
+	return stealthPrivKey, nil
+}
+
+// hybridSecretFromAnnouncement decapsulates the KEM ciphertext and recomputes the combined shared secret.
+func hybridSecretFromAnnouncement(viewingKey *ecdsa.PrivateKey, kemKey *mlkem.DecapsulationKey768, ann *Announcement) ([]byte, error) {
+	if len(ann.Metadata) < HybridMetadataSize {
+		return nil, fmt.Errorf("%w: expected at least %d bytes, got %d", ErrInvalidMetadata, HybridMetadataSize, len(ann.Metadata))
+	}
+	kemShared, err := kemKey.Decapsulate(ann.Metadata[1:HybridMetadataSize])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMetadata, err)
+	}
+	sharedX, _ := crypto.S256().ScalarMult(ann.EphemeralPubKey.X, ann.EphemeralPubKey.Y, viewingKey.D.Bytes())
+	return hybridSharedSecret(sharedX, kemShared), nil
+}
+
+// hybridSharedSecret combines the ECDH x-coordinate and the ML-KEM shared key into one 32-byte secret.
+func hybridSharedSecret(sharedX *big.Int, kemShared []byte) []byte {
+	return crypto.Keccak256(common.LeftPadBytes(sharedX.Bytes(), 32), kemShared)
+}
+
+// addScalarBaseMult returns P + (s mod n) * G.
+func addScalarBaseMult(pub *ecdsa.PublicKey, secret []byte) *ecdsa.PublicKey {
+	curve := crypto.S256()
+	s := new(big.Int).SetBytes(secret)
+	s.Mod(s, curve.Params().N)
+
+	sGx, sGy := curve.ScalarBaseMult(s.Bytes())
+	x, y := curve.Add(pub.X, pub.Y, sGx, sGy)
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+}
+
+// addScalar returns the private key (d + s) mod n with its matching public key.
+func addScalar(priv *ecdsa.PrivateKey, secret []byte) (*ecdsa.PrivateKey, error) {
+	curve := crypto.S256()
+	d := new(big.Int).SetBytes(secret)
+	d.Add(d, priv.D)
+	d.Mod(d, curve.Params().N)
+	return crypto.ToECDSA(common.LeftPadBytes(d.Bytes(), 32))
+}
@@ -0,0 +1,110 @@
+package privacy
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/prikshit/blockchain-privacy-module/internal/sanctions"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStealthMetaAddressEncoding(t *testing.T) {
+	keys, err := GenerateHybridKeys()
+	assert.NoError(t, err)
+
+	// Hybrid meta-address round trip
+	meta := keys.MetaAddress()
+	parsed, err := ParseStealthMetaAddress(meta.String())
+	assert.NoError(t, err)
+	assert.True(t, parsed.IsHybrid())
+	assert.Equal(t, meta.String(), parsed.String())
+
+	// Classic meta-address round trip
+	classic := &StealthMetaAddress{SpendingPubKey: meta.SpendingPubKey, ViewingPubKey: meta.ViewingPubKey}
+	parsed, err = ParseStealthMetaAddress(classic.String())
+	assert.NoError(t, err)
+	assert.False(t, parsed.IsHybrid())
+	assert.Equal(t, classic.String(), parsed.String())
+
+	// Malformed inputs
+	_, err = ParseStealthMetaAddress("0x1234")
+	assert.ErrorIs(t, err, ErrInvalidMetaAddress)
+	_, err = ParseStealthMetaAddress(MetaAddressPrefix + "1234")
+	assert.ErrorIs(t, err, ErrInvalidMetaAddress)
+}
+
+func TestHybridStealthRoundTrip(t *testing.T) {
+	pm := NewPrivacyManager(sanctions.NewDetector(nil))
+
+	keys, err := GenerateHybridKeys()
+	assert.NoError(t, err)
+
+	stealthPub, ann, err := pm.GenerateHybridStealthAddress(keys.MetaAddress())
+	assert.NoError(t, err)
+	assert.Len(t, ann.Metadata, HybridMetadataSize)
+	assert.Equal(t, crypto.PubkeyToAddress(*stealthPub), ann.StealthAddress)
+
+	// Recipient finds the payment among unrelated announcements
+	otherKeys, err := GenerateHybridKeys()
+	assert.NoError(t, err)
+	_, otherAnn, err := pm.GenerateHybridStealthAddress(otherKeys.MetaAddress())
+	assert.NoError(t, err)
+
+	matches := pm.ScanHybridAnnouncements(keys.ViewingKey, keys.KEMKey, &keys.SpendingKey.PublicKey, []*Announcement{otherAnn, ann})
+	assert.Equal(t, []*Announcement{ann}, matches)
+
+	// Recipient recovers the stealth private key
+	recovered, err := pm.RecoverHybridStealthPrivateKey(keys, ann)
+	assert.NoError(t, err)
+	assert.Equal(t, stealthPub.X, recovered.PublicKey.X)
+	assert.Equal(t, stealthPub.Y, recovered.PublicKey.Y)
+
+	// Someone else's keys must not recover it
+	_, err = pm.RecoverHybridStealthPrivateKey(otherKeys, ann)
+	assert.ErrorIs(t, err, ErrAnnouncementMismatch)
+}
+
+func TestHybridStealthRequiresBothSecrets(t *testing.T) {
+	pm := NewPrivacyManager(sanctions.NewDetector(nil))
+
+	keys, err := GenerateHybridKeys()
+	assert.NoError(t, err)
+	_, ann, err := pm.GenerateHybridStealthAddress(keys.MetaAddress())
+	assert.NoError(t, err)
+
+	// Correct viewing key but wrong ML-KEM key
+	otherKeys, err := GenerateHybridKeys()
+	assert.NoError(t, err)
+	matches := pm.ScanHybridAnnouncements(keys.ViewingKey, otherKeys.KEMKey, &keys.SpendingKey.PublicKey, []*Announcement{ann})
+	assert.Empty(t, matches)
+
+	// Correct ML-KEM key but wrong viewing key
+	wrongViewing, err := ecdsa.GenerateKey(crypto.S256(), rand.Reader)
+	assert.NoError(t, err)
+	matches = pm.ScanHybridAnnouncements(wrongViewing, keys.KEMKey, &keys.SpendingKey.PublicKey, []*Announcement{ann})
+	assert.Empty(t, matches)
+
+	// Truncated metadata is rejected
+	truncated := *ann
+	truncated.Metadata = ann.Metadata[:10]
+	_, err = pm.RecoverHybridStealthPrivateKey(keys, &truncated)
+	assert.ErrorIs(t, err, ErrInvalidMetadata)
+}
+
+func TestHybridStealthRejectsClassicAndSanctioned(t *testing.T) {
+	keys, err := GenerateHybridKeys()
+	assert.NoError(t, err)
+	meta := keys.MetaAddress()
+
+	pm := NewPrivacyManager(sanctions.NewDetector(nil))
+	_, _, err = pm.GenerateHybridStealthAddress(&StealthMetaAddress{SpendingPubKey: meta.SpendingPubKey, ViewingPubKey: meta.ViewingPubKey})
+	assert.ErrorIs(t, err, ErrNotHybridMetaAddress)
+
+	spendingAddress := crypto.PubkeyToAddress(*meta.SpendingPubKey)
+	pm = NewPrivacyManager(sanctions.NewDetector([]common.Address{spendingAddress}))
+	_, _, err = pm.GenerateHybridStealthAddress(meta)
+	assert.ErrorIs(t, err, ErrSanctionedAddress)
+}
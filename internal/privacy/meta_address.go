@@ -0,0 +1,86 @@
+package privacy
+
+import (
+	"crypto/ecdsa"
+	"crypto/mlkem"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// MetaAddressPrefix is the EIP-5564 prefix for secp256k1 stealth meta-addresses on Ethereum.
+const MetaAddressPrefix = "st:eth:0x"
+
+const (
+	compressedPubKeySize   = 33
+	classicMetaAddressSize = 2 * compressedPubKeySize
+	hybridMetaAddressSize  = classicMetaAddressSize + mlkem.EncapsulationKeySize768
+)
+
+var ErrInvalidMetaAddress = errors.New("invalid stealth meta-address")
+
+// StealthMetaAddress holds the public keys a payer needs to derive stealth addresses for a recipient.
+// KEMPubKey is only set for hybrid (post-quantum) meta-addresses.
+type StealthMetaAddress struct {
+	SpendingPubKey *ecdsa.PublicKey
+	ViewingPubKey  *ecdsa.PublicKey
+	KEMPubKey      *mlkem.EncapsulationKey768
+}
+
+// IsHybrid reports whether the meta-address carries an ML-KEM-768 encapsulation key.
+func (m *StealthMetaAddress) IsHybrid() bool {
+	return m.KEMPubKey != nil
+}
+
+// String encodes the meta-address as "st:eth:0x<spending><viewing>[<kem>]" using compressed secp256k1 keys.
+func (m *StealthMetaAddress) String() string {
+	buf := make([]byte, 0, hybridMetaAddressSize)
+	buf = append(buf, crypto.CompressPubkey(m.SpendingPubKey)...)
+	buf = append(buf, crypto.CompressPubkey(m.ViewingPubKey)...)
+	if m.IsHybrid() {
+		buf = append(buf, m.KEMPubKey.Bytes()...)
+	}
+	return MetaAddressPrefix + common.Bytes2Hex(buf)
+}
+
+// ParseStealthMetaAddress decodes a classic or hybrid meta-address produced by StealthMetaAddress.String.
+func ParseStealthMetaAddress(encoded string) (*StealthMetaAddress, error) {
+	if !strings.HasPrefix(encoded, MetaAddressPrefix) {
+		log.Printf("Meta-address is missing the %q prefix\n", MetaAddressPrefix)
+		return nil, fmt.Errorf("%w: missing %q prefix", ErrInvalidMetaAddress, MetaAddressPrefix)
+	}
+
+	raw, err := hexutil.Decode("0x" + strings.TrimPrefix(encoded, MetaAddressPrefix))
+	if err != nil {
+		log.Printf("Failed to decode meta-address hex: %v\n", err)
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMetaAddress, err)
+	}
+	if len(raw) != classicMetaAddressSize && len(raw) != hybridMetaAddressSize {
+		return nil, fmt.Errorf("%w: unexpected length %d", ErrInvalidMetaAddress, len(raw))
+	}
+
+	spendingPub, err := crypto.DecompressPubkey(raw[:compressedPubKeySize])
+	if err != nil {
+		return nil, fmt.Errorf("%w: spending key: %v", ErrInvalidMetaAddress, err)
+	}
+	viewingPub, err := crypto.DecompressPubkey(raw[compressedPubKeySize:classicMetaAddressSize])
+	if err != nil {
+		return nil, fmt.Errorf("%w: viewing key: %v", ErrInvalidMetaAddress, err)
+	}
+
+	meta := &StealthMetaAddress{SpendingPubKey: spendingPub, ViewingPubKey: viewingPub}
+	if len(raw) == hybridMetaAddressSize {
+		meta.KEMPubKey, err = mlkem.NewEncapsulationKey768(raw[classicMetaAddressSize:])
+		if err != nil {
+			return nil, fmt.Errorf("%w: ML-KEM key: %v", ErrInvalidMetaAddress, err)
+		}
+	}
+
+	log.Printf("Parsed stealth meta-address (hybrid: %t)\n", meta.IsHybrid())
+	return meta, nil
+}
@@ -0,0 +1,136 @@
+package privacy
+
+import (
+	"crypto/ecdsa"
+	"log"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ScanMatch is an announcement found by the scanner together with the label of the meta-address it was paid to.
+// Label is empty for payments to the unlabelled meta-address.
+type ScanMatch struct {
+	Announcement *Announcement
+	Label        string
+}
+
+// LabelTweak returns m = H(d_view || label), the scalar added to the spending key for a labelled meta-address.
+// Only the holder of the viewing key can derive labels, so labelled meta-addresses cannot be linked by outsiders.
+func LabelTweak(viewingKey *ecdsa.PrivateKey, label string) []byte {
+	return crypto.Keccak256(crypto.FromECDSA(viewingKey), []byte(label))
+}
+
+// LabelledSpendingPubKey returns B_m = B_spend + m * G, or B_spend itself for the empty label.
+func LabelledSpendingPubKey(spendingPub *ecdsa.PublicKey, viewingKey *ecdsa.PrivateKey, label string) *ecdsa.PublicKey {
+	if label == "" {
+		return spendingPub
+	}
+	return addScalarBaseMult(spendingPub, LabelTweak(viewingKey, label))
+}
+
+// LabelledMetaAddress returns a sub-meta-address for label that shares the recipient's viewing key,
+// so one scan covers every label (BIP-352 style labels).
+func (k *StealthKeys) LabelledMetaAddress(label string) *StealthMetaAddress {
+	log.Printf("Deriving labelled meta-address for label %q\n", label)
+	return &StealthMetaAddress{
+		SpendingPubKey: LabelledSpendingPubKey(&k.SpendingKey.PublicKey, k.ViewingKey, label),
+		ViewingPubKey:  &k.ViewingKey.PublicKey,
+	}
+}
+
+// GenerateStealthPayment derives a stealth address and announcement for a classic or hybrid meta-address.
+// For classic meta-addresses s = H(d_e * P_view) and P_s = P_spend + s * G.
+func (pm *PrivacyManager) GenerateStealthPayment(meta *StealthMetaAddress) (*ecdsa.PublicKey, *Announcement, error) {
+	if meta.IsHybrid() {
+		return pm.GenerateHybridStealthAddress(meta)
+	}
+
+	// Check if the spending key is sanctioned
+	address := crypto.PubkeyToAddress(*meta.SpendingPubKey)
+	log.Printf("Attempting to generate stealth payment for: %s\n", address.Hex())
+
+	if pm.Detector.IsSanctioned(address) {
+		log.Printf("Sanctioned address detected: %s\n", address.Hex())
+		return nil, nil, ErrSanctionedAddress
+	}
+
+	ephemeralPrivKey, err := pm.newEphemeralKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sharedSecret := classicSharedSecret(ephemeralPrivKey, meta.ViewingPubKey)
+	stealthPub := addScalarBaseMult(meta.SpendingPubKey, sharedSecret)
+
+	log.Println("Stealth payment generated successfully")
+	return stealthPub, &Announcement{
+		StealthAddress:  crypto.PubkeyToAddress(*stealthPub),
+		EphemeralPubKey: &ephemeralPrivKey.PublicKey,
+		Metadata:        []byte{sharedSecret[0]},
+	}, nil
+}
+
+// ScanAnnouncements returns the classic announcements paid to the recipient's meta-address or any of the given labels.
+func (pm *PrivacyManager) ScanAnnouncements(viewingKey *ecdsa.PrivateKey, spendingPub *ecdsa.PublicKey, labels []string, announcements []*Announcement) []*ScanMatch {
+	log.Printf("Scanning %d announcements for %d labels\n", len(announcements), len(labels))
+
+	// Precompute the spending key of every label, including the unlabelled one
+	candidates := map[string]*ecdsa.PublicKey{"": spendingPub}
+	for _, label := range labels {
+		candidates[label] = LabelledSpendingPubKey(spendingPub, viewingKey, label)
+	}
+
+	var matches []*ScanMatch
+	for _, ann := range announcements {
+		if len(ann.Metadata) == 0 {
+			continue
+		}
+		sharedSecret := classicSharedSecret(viewingKey, ann.EphemeralPubKey)
+		if sharedSecret[0] != ann.Metadata[0] {
+			continue
+		}
+		for label, candidate := range candidates {
+			if crypto.PubkeyToAddress(*addScalarBaseMult(candidate, sharedSecret)) == ann.StealthAddress {
+				matches = append(matches, &ScanMatch{Announcement: ann, Label: label})
+				break
+			}
+		}
+	}
+
+	log.Printf("Found %d matching announcements\n", len(matches))
+	return matches
+}
+
+// RecoverLabelledStealthPrivateKey recovers the stealth private key for a classic announcement paid to label:
+// d_s = (d_spend + m + s) mod n, with m = 0 for the unlabelled meta-address.
+func (pm *PrivacyManager) RecoverLabelledStealthPrivateKey(keys *StealthKeys, label string, ann *Announcement) (*ecdsa.PrivateKey, error) {
+	log.Printf("Recovering stealth private key for label %q\n", label)
+
+	spendingKey := keys.SpendingKey
+	if label != "" {
+		var err error
+		spendingKey, err = addScalar(keys.SpendingKey, LabelTweak(keys.ViewingKey, label))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	stealthPrivKey, err := addScalar(spendingKey, classicSharedSecret(keys.ViewingKey, ann.EphemeralPubKey))
+	if err != nil {
+		return nil, err
+	}
+	if crypto.PubkeyToAddress(stealthPrivKey.PublicKey) != ann.StealthAddress {
+		log.Println("Recovered key does not match the announced stealth address")
+		return nil, ErrAnnouncementMismatch
+	}
+
+	log.Println("Stealth private key recovered successfully")
+	return stealthPrivKey, nil
+}
+
+// classicSharedSecret computes s = H(d * P) for the classic dual-key scheme.
+func classicSharedSecret(priv *ecdsa.PrivateKey, pub *ecdsa.PublicKey) []byte {
+	sharedX, _ := crypto.S256().ScalarMult(pub.X, pub.Y, priv.D.Bytes())
+	return crypto.Keccak256(common.LeftPadBytes(sharedX.Bytes(), 32))
+}
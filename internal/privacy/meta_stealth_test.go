@@ -0,0 +1,78 @@
+package privacy
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/prikshit/blockchain-privacy-module/internal/sanctions"
+	"github.com/stretchr/testify/assert"
+)
+
+func generateStealthKeys(t *testing.T) *StealthKeys {
+	spendingKey, err := ecdsa.GenerateKey(crypto.S256(), rand.Reader)
+	assert.NoError(t, err)
+	viewingKey, err := ecdsa.GenerateKey(crypto.S256(), rand.Reader)
+	assert.NoError(t, err)
+	return &StealthKeys{SpendingKey: spendingKey, ViewingKey: viewingKey}
+}
+
+func TestClassicStealthPayment(t *testing.T) {
+	pm := NewPrivacyManager(sanctions.NewDetector(nil))
+	keys := generateStealthKeys(t)
+
+	stealthPub, ann, err := pm.GenerateStealthPayment(keys.MetaAddress())
+	assert.NoError(t, err)
+	assert.Len(t, ann.Metadata, 1)
+
+	matches := pm.ScanAnnouncements(keys.ViewingKey, &keys.SpendingKey.PublicKey, nil, []*Announcement{ann})
+	assert.Len(t, matches, 1)
+	assert.Equal(t, "", matches[0].Label)
+
+	recovered, err := pm.RecoverLabelledStealthPrivateKey(keys, "", ann)
+	assert.NoError(t, err)
+	assert.Equal(t, stealthPub.X, recovered.PublicKey.X)
+	assert.Equal(t, stealthPub.Y, recovered.PublicKey.Y)
+}
+
+func TestLabelledMetaAddresses(t *testing.T) {
+	pm := NewPrivacyManager(sanctions.NewDetector(nil))
+	keys := generateStealthKeys(t)
+
+	euMeta := keys.LabelledMetaAddress("storefront-eu")
+	usMeta := keys.LabelledMetaAddress("storefront-us")
+
+	// Labels share the viewing key but have unlinkable spending keys
+	assert.Equal(t, keys.MetaAddress().ViewingPubKey, euMeta.ViewingPubKey)
+	assert.NotEqual(t, keys.MetaAddress().String(), euMeta.String())
+	assert.NotEqual(t, euMeta.String(), usMeta.String())
+
+	_, euAnn, err := pm.GenerateStealthPayment(euMeta)
+	assert.NoError(t, err)
+	_, usAnn, err := pm.GenerateStealthPayment(usMeta)
+	assert.NoError(t, err)
+	_, baseAnn, err := pm.GenerateStealthPayment(keys.MetaAddress())
+	assert.NoError(t, err)
+	_, foreignAnn, err := pm.GenerateStealthPayment(generateStealthKeys(t).MetaAddress())
+	assert.NoError(t, err)
+
+	labels := []string{"storefront-eu", "storefront-us"}
+	matches := pm.ScanAnnouncements(keys.ViewingKey, &keys.SpendingKey.PublicKey, labels, []*Announcement{euAnn, foreignAnn, usAnn, baseAnn})
+	assert.Len(t, matches, 3)
+	assert.Equal(t, &ScanMatch{Announcement: euAnn, Label: "storefront-eu"}, matches[0])
+	assert.Equal(t, &ScanMatch{Announcement: usAnn, Label: "storefront-us"}, matches[1])
+	assert.Equal(t, &ScanMatch{Announcement: baseAnn, Label: ""}, matches[2])
+
+	// A scan that does not know a label misses its payments
+	matches = pm.ScanAnnouncements(keys.ViewingKey, &keys.SpendingKey.PublicKey, []string{"storefront-us"}, []*Announcement{euAnn})
+	assert.Empty(t, matches)
+
+	// The recovered key controls the labelled stealth address, and only with the right label
+	recovered, err := pm.RecoverLabelledStealthPrivateKey(keys, "storefront-eu", euAnn)
+	assert.NoError(t, err)
+	assert.Equal(t, euAnn.StealthAddress, crypto.PubkeyToAddress(recovered.PublicKey))
+
+	_, err = pm.RecoverLabelledStealthPrivateKey(keys, "storefront-us", euAnn)
+	assert.ErrorIs(t, err, ErrAnnouncementMismatch)
+}
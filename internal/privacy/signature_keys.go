@@ -0,0 +1,99 @@
+package privacy
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// StealthKeysMessage is the fixed, domain-separated message a wallet signs (EIP-191) to derive its stealth keys.
+// Changing it changes every derived key, so it is versioned rather than edited.
+const StealthKeysMessage = "Sign this message to access your stealth keys.\n\n" +
+	"Only sign this message for a trusted client!\n\n" +
+	"Domain: blockchain-privacy-module\n" +
+	"Version: 1"
+
+var (
+	ErrInvalidSignature          = errors.New("invalid signature")
+	ErrNonCanonicalSignature     = errors.New("signature is not in canonical low-s form")
+	ErrSignerMismatch            = errors.New("signature was not produced by the given address")
+	ErrNonDeterministicSignature = errors.New("signer is not deterministic: repeated signatures differ")
+)
+
+// StealthKeys is a recipient's spending and viewing key pair behind a classic stealth meta-address.
+type StealthKeys struct {
+	SpendingKey *ecdsa.PrivateKey
+	ViewingKey  *ecdsa.PrivateKey
+}
+
+// MetaAddress returns the classic meta-address the recipient publishes for these keys.
+func (k *StealthKeys) MetaAddress() *StealthMetaAddress {
+	return &StealthMetaAddress{
+		SpendingPubKey: &k.SpendingKey.PublicKey,
+		ViewingPubKey:  &k.ViewingKey.PublicKey,
+	}
+}
+
+// DeriveStealthKeysFromSignature derives stealth keys from two EIP-191 signatures over StealthKeysMessage.
+// As in Umbra, d_spend = H(r) and d_view = H(s). The wallet must sign twice and produce identical
+// low-s signatures; a signer using random nonces would otherwise yield keys that can never be re-derived.
+func DeriveStealthKeysFromSignature(signer common.Address, signature, confirmation []byte) (*StealthKeys, error) {
+	log.Printf("Deriving stealth keys from signature by %s\n", signer.Hex())
+
+	if err := verifyStealthKeysSignature(signer, signature); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(signature, confirmation) {
+		log.Println("Repeated signatures differ, rejecting non-deterministic signer")
+		return nil, ErrNonDeterministicSignature
+	}
+
+	spendingKey, err := crypto.ToECDSA(crypto.Keccak256(signature[:32]))
+	if err != nil {
+		return nil, fmt.Errorf("deriving spending key: %w", err)
+	}
+	viewingKey, err := crypto.ToECDSA(crypto.Keccak256(signature[32:64]))
+	if err != nil {
+		return nil, fmt.Errorf("deriving viewing key: %w", err)
+	}
+
+	log.Println("Stealth keys derived from signature successfully")
+	return &StealthKeys{SpendingKey: spendingKey, ViewingKey: viewingKey}, nil
+}
+
+// verifyStealthKeysSignature checks that sig is a canonical signature by signer over StealthKeysMessage.
+func verifyStealthKeysSignature(signer common.Address, sig []byte) error {
+	if len(sig) != crypto.SignatureLength {
+		return fmt.Errorf("%w: expected %d bytes, got %d", ErrInvalidSignature, crypto.SignatureLength, len(sig))
+	}
+
+	// Accept both the Ethereum (27/28) and raw (0/1) recovery ids
+	normalized := common.CopyBytes(sig)
+	if normalized[crypto.RecoveryIDOffset] >= 27 {
+		normalized[crypto.RecoveryIDOffset] -= 27
+	}
+
+	r := new(big.Int).SetBytes(normalized[:32])
+	s := new(big.Int).SetBytes(normalized[32:64])
+	if !crypto.ValidateSignatureValues(normalized[crypto.RecoveryIDOffset], r, s, true) {
+		log.Println("Signature values are out of range or use high s")
+		return ErrNonCanonicalSignature
+	}
+
+	pubKey, err := crypto.SigToPub(accounts.TextHash([]byte(StealthKeysMessage)), normalized)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	if crypto.PubkeyToAddress(*pubKey) != signer {
+		log.Println("Recovered signer does not match the given address")
+		return ErrSignerMismatch
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package privacy
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func signStealthKeysMessage(t *testing.T, key *ecdsa.PrivateKey) []byte {
+	sig, err := crypto.Sign(accounts.TextHash([]byte(StealthKeysMessage)), key)
+	assert.NoError(t, err)
+	sig[crypto.RecoveryIDOffset] += 27 // Wallets return Ethereum-style recovery ids
+	return sig
+}
+
+// signWithRandomNonce mimics a wallet that does not use RFC 6979 deterministic nonces.
+func signWithRandomNonce(t *testing.T, key *ecdsa.PrivateKey) []byte {
+	hash := accounts.TextHash([]byte(StealthKeysMessage))
+	r, s, err := ecdsa.Sign(rand.Reader, key, hash)
+	assert.NoError(t, err)
+
+	halfN := new(big.Int).Rsh(crypto.S256().Params().N, 1)
+	if s.Cmp(halfN) > 0 {
+		s.Sub(crypto.S256().Params().N, s)
+	}
+	sig := append(common.LeftPadBytes(r.Bytes(), 32), common.LeftPadBytes(s.Bytes(), 32)...)
+	for v := byte(0); v < 2; v++ {
+		pub, err := crypto.SigToPub(hash, append(common.CopyBytes(sig), v))
+		if err == nil && crypto.PubkeyToAddress(*pub) == crypto.PubkeyToAddress(key.PublicKey) {
+			return append(sig, v)
+		}
+	}
+	t.Fatal("could not compute recovery id")
+	return nil
+}
+
+func TestDeriveStealthKeysFromSignature(t *testing.T) {
+	walletKey, err := ecdsa.GenerateKey(crypto.S256(), rand.Reader)
+	assert.NoError(t, err)
+	signer := crypto.PubkeyToAddress(walletKey.PublicKey)
+
+	sig := signStealthKeysMessage(t, walletKey)
+	keys, err := DeriveStealthKeysFromSignature(signer, sig, signStealthKeysMessage(t, walletKey))
+	assert.NoError(t, err)
+	assert.Equal(t, crypto.Keccak256(sig[:32]), crypto.FromECDSA(keys.SpendingKey))
+	assert.Equal(t, crypto.Keccak256(sig[32:64]), crypto.FromECDSA(keys.ViewingKey))
+
+	// Deriving again yields the same meta-address
+	again, err := DeriveStealthKeysFromSignature(signer, sig, sig)
+	assert.NoError(t, err)
+	assert.Equal(t, keys.MetaAddress().String(), again.MetaAddress().String())
+}
+
+func TestDeriveStealthKeysRejectsBadSignatures(t *testing.T) {
+	walletKey, err := ecdsa.GenerateKey(crypto.S256(), rand.Reader)
+	assert.NoError(t, err)
+	signer := crypto.PubkeyToAddress(walletKey.PublicKey)
+	sig := signStealthKeysMessage(t, walletKey)
+
+	// Wrong length
+	_, err = DeriveStealthKeysFromSignature(signer, sig[:64], sig[:64])
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	// Signed by someone else
+	otherKey, err := ecdsa.GenerateKey(crypto.S256(), rand.Reader)
+	assert.NoError(t, err)
+	_, err = DeriveStealthKeysFromSignature(crypto.PubkeyToAddress(otherKey.PublicKey), sig, sig)
+	assert.ErrorIs(t, err, ErrSignerMismatch)
+
+	// Malleated high-s form of a valid signature
+	n := crypto.S256().Params().N
+	highS := common.CopyBytes(sig)
+	copy(highS[32:64], common.LeftPadBytes(new(big.Int).Sub(n, new(big.Int).SetBytes(sig[32:64])).Bytes(), 32))
+	highS[crypto.RecoveryIDOffset] ^= 1
+	_, err = DeriveStealthKeysFromSignature(signer, highS, highS)
+	assert.ErrorIs(t, err, ErrNonCanonicalSignature)
+
+	// Wallet using random nonces
+	first := signWithRandomNonce(t, walletKey)
+	second := signWithRandomNonce(t, walletKey)
+	_, err = DeriveStealthKeysFromSignature(signer, first, second)
+	assert.ErrorIs(t, err, ErrNonDeterministicSignature)
+}
@@ -0,0 +1,192 @@
+package privacy
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
+	"log"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// NativeToken is the EIP-5564 placeholder token address for ETH transfers.
+var NativeToken = common.HexToAddress("0xEeeeeeeeeeEeeeeEeeeeeeeEEeeeEeeeeeeeEEeE")
+
+// erc20TransferSelector is the selector of transfer(address,uint256); ETH payments use 0xeeeeeeee.
+var (
+	erc20TransferSelector  = []byte{0xa9, 0x05, 0x9c, 0xbb}
+	nativeTransferSelector = []byte{0xee, 0xee, 0xee, 0xee}
+)
+
+var (
+	ErrInvalidSplit   = errors.New("invalid split options")
+	ErrAmountTooSmall = errors.New("amount is too small to split into the requested outputs")
+	ErrAmountTooLarge = errors.New("amount does not fit in 256 bits")
+)
+
+// SplitOptions bounds how many stealth outputs a payment is spread over.
+type SplitOptions struct {
+	MinOutputs int
+	MaxOutputs int
+}
+
+// Transfer is a payment the payer must execute: ETH value to To, or an ERC-20 transfer call on Token.
+type Transfer struct {
+	Token  common.Address
+	To     common.Address
+	Amount *big.Int
+	Data   []byte // Calldata for ERC-20 transfers, empty for ETH
+}
+
+// PaymentOutput is one stealth output of a split payment.
+type PaymentOutput struct {
+	StealthPubKey *ecdsa.PublicKey
+	Announcement  *Announcement
+	Transfer      *Transfer
+}
+
+// PaymentPlan is the full set of announcements and transfers for a split payment.
+type PaymentPlan struct {
+	Token   common.Address
+	Total   *big.Int
+	Outputs []*PaymentOutput
+}
+
+// PlanSplitPayment spreads total over several stealth addresses of the same meta-address so that no
+// single transfer reveals the payment amount. Each output gets its own ephemeral key and a randomized,
+// rounded denomination; one output at a random position takes the remainder.
+func (pm *PrivacyManager) PlanSplitPayment(meta *StealthMetaAddress, token common.Address, total *big.Int, opts SplitOptions) (*PaymentPlan, error) {
+	if opts.MinOutputs < 1 || opts.MaxOutputs < opts.MinOutputs {
+		return nil, ErrInvalidSplit
+	}
+	if total.Sign() <= 0 || total.Cmp(big.NewInt(int64(opts.MinOutputs))) < 0 {
+		return nil, ErrAmountTooSmall
+	}
+	if total.BitLen() > 256 {
+		return nil, ErrAmountTooLarge
+	}
+	log.Printf("Planning split payment of %s over %d-%d outputs\n", total, opts.MinOutputs, opts.MaxOutputs)
+
+	count, err := randomInt(opts.MinOutputs, opts.MaxOutputs)
+	if err != nil {
+		return nil, err
+	}
+	if total.Cmp(big.NewInt(int64(count))) < 0 {
+		count = int(total.Int64())
+	}
+	amounts, err := randomDenominations(total, count)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &PaymentPlan{Token: token, Total: new(big.Int).Set(total)}
+	seen := make(map[string]bool, len(amounts))
+	for _, amount := range amounts {
+		stealthPub, ann, err := pm.GenerateStealthPayment(meta)
+		if err != nil {
+			return nil, err
+		}
+		key := string(crypto.FromECDSAPub(ann.EphemeralPubKey))
+		if seen[key] {
+			log.Println("Ephemeral key reused within a split payment")
+			return nil, ErrEphemeralKeyReused
+		}
+		seen[key] = true
+
+		ann.Metadata = append(ann.Metadata, transferMetadata(token, amount)...)
+		plan.Outputs = append(plan.Outputs, &PaymentOutput{
+			StealthPubKey: stealthPub,
+			Announcement:  ann,
+			Transfer:      newTransfer(token, ann.StealthAddress, amount),
+		})
+	}
+
+	log.Printf("Planned split payment with %d outputs\n", len(plan.Outputs))
+	return plan, nil
+}
+
+// randomDenominations splits total into count positive parts in random order. All but one part are random
+// shares of what remains, rounded down to two significant digits so they look like ordinary payment amounts.
+// The unrounded remainder is placed at a random position, so the order of the outputs does not single it out.
+func randomDenominations(total *big.Int, count int) ([]*big.Int, error) {
+	amounts := make([]*big.Int, 0, count)
+	remaining := new(big.Int).Set(total)
+	for i := count; i > 1; i-- {
+		// Leave at least one unit for each later output
+		maxPart := new(big.Int).Sub(remaining, big.NewInt(int64(i-1)))
+		// Draw between 1/(2i) and 3/(2i) of the remainder so parts stay comparable
+		low := new(big.Int).Div(remaining, big.NewInt(int64(2*i)))
+		span := new(big.Int).Div(remaining, big.NewInt(int64(i)))
+		part := new(big.Int).Set(low)
+		if span.Sign() > 0 {
+			r, err := rand.Int(rand.Reader, span)
+			if err != nil {
+				return nil, err
+			}
+			part.Add(part, r)
+		}
+		part = roundSignificant(part, 2)
+		if part.Sign() == 0 {
+			part.SetInt64(1)
+		}
+		if part.Cmp(maxPart) > 0 {
+			part.Set(maxPart)
+		}
+		amounts = append(amounts, part)
+		remaining.Sub(remaining, part)
+	}
+	amounts = append(amounts, remaining)
+
+	// Fisher-Yates shuffle
+	for i := len(amounts) - 1; i > 0; i-- {
+		j, err := randomInt(0, i)
+		if err != nil {
+			return nil, err
+		}
+		amounts[i], amounts[j] = amounts[j], amounts[i]
+	}
+	return amounts, nil
+}
+
+// roundSignificant rounds x down to the given number of significant decimal digits.
+func roundSignificant(x *big.Int, digits int) *big.Int {
+	length := len(x.String())
+	if length <= digits {
+		return new(big.Int).Set(x)
+	}
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(length-digits)), nil)
+	rounded := new(big.Int).Div(x, unit)
+	return rounded.Mul(rounded, unit)
+}
+
+// transferMetadata encodes the EIP-5564 token metadata that follows the view tag: selector, token, amount.
+func transferMetadata(token common.Address, amount *big.Int) []byte {
+	selector := erc20TransferSelector
+	if token == NativeToken {
+		selector = nativeTransferSelector
+	}
+	metadata := append([]byte{}, selector...)
+	metadata = append(metadata, token.Bytes()...)
+	return append(metadata, common.LeftPadBytes(amount.Bytes(), 32)...)
+}
+
+func newTransfer(token, to common.Address, amount *big.Int) *Transfer {
+	transfer := &Transfer{Token: token, To: to, Amount: amount}
+	if token != NativeToken {
+		data := append([]byte{}, erc20TransferSelector...)
+		data = append(data, common.LeftPadBytes(to.Bytes(), 32)...)
+		transfer.Data = append(data, common.LeftPadBytes(amount.Bytes(), 32)...)
+	}
+	return transfer
+}
+
+// randomInt returns a uniform integer in [min, max].
+func randomInt(min, max int) (int, error) {
+	r, err := rand.Int(rand.Reader, big.NewInt(int64(max-min+1)))
+	if err != nil {
+		return 0, err
+	}
+	return min + int(r.Int64()), nil
+}
@@ -0,0 +1,102 @@
+package privacy
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/prikshit/blockchain-privacy-module/internal/sanctions"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanSplitPayment(t *testing.T) {
+	pm := NewPrivacyManager(sanctions.NewDetector(nil))
+	keys := generateStealthKeys(t)
+	usdc := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	total := big.NewInt(1_000_000_000) // 1,000 USDC with 6 decimals
+
+	plan, err := pm.PlanSplitPayment(keys.MetaAddress(), usdc, total, SplitOptions{MinOutputs: 3, MaxOutputs: 5})
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, len(plan.Outputs), 3)
+	assert.LessOrEqual(t, len(plan.Outputs), 5)
+
+	sum := new(big.Int)
+	var announcements []*Announcement
+	ephemeralKeys := make(map[string]bool)
+	for _, out := range plan.Outputs {
+		assert.Positive(t, out.Transfer.Amount.Sign())
+		sum.Add(sum, out.Transfer.Amount)
+
+		// The transfer goes to the announced stealth address and the metadata carries the token details
+		assert.Equal(t, out.Announcement.StealthAddress, out.Transfer.To)
+		assert.Equal(t, erc20TransferSelector, out.Transfer.Data[:4])
+		assert.Equal(t, usdc.Bytes(), out.Announcement.Metadata[5:25])
+		assert.Equal(t, out.Transfer.Amount, new(big.Int).SetBytes(out.Announcement.Metadata[25:57]))
+
+		ephemeralKeys[string(crypto.FromECDSAPub(out.Announcement.EphemeralPubKey))] = true
+		announcements = append(announcements, out.Announcement)
+	}
+	assert.Equal(t, total, sum)
+	assert.Len(t, ephemeralKeys, len(plan.Outputs))
+
+	// The recipient finds every output
+	matches := pm.ScanAnnouncements(keys.ViewingKey, &keys.SpendingKey.PublicKey, nil, announcements)
+	assert.Len(t, matches, len(plan.Outputs))
+}
+
+func TestPlanSplitPaymentHybridAndNative(t *testing.T) {
+	pm := NewPrivacyManager(sanctions.NewDetector(nil))
+	keys, err := GenerateHybridKeys()
+	assert.NoError(t, err)
+
+	plan, err := pm.PlanSplitPayment(keys.MetaAddress(), NativeToken, big.NewInt(5e18), SplitOptions{MinOutputs: 2, MaxOutputs: 2})
+	assert.NoError(t, err)
+	assert.Len(t, plan.Outputs, 2)
+
+	var announcements []*Announcement
+	for _, out := range plan.Outputs {
+		assert.Empty(t, out.Transfer.Data)
+		announcements = append(announcements, out.Announcement)
+	}
+	matches := pm.ScanHybridAnnouncements(keys.ViewingKey, keys.KEMKey, &keys.SpendingKey.PublicKey, announcements)
+	assert.Len(t, matches, 2)
+}
+
+func TestRandomDenominations(t *testing.T) {
+	total := big.NewInt(123_456_789)
+	remainderAt := make(map[int]bool)
+	for i := 0; i < 50; i++ {
+		amounts, err := randomDenominations(total, 4)
+		assert.NoError(t, err)
+		assert.Len(t, amounts, 4)
+
+		sum := new(big.Int)
+		unrounded := 0
+		for j, a := range amounts {
+			assert.Positive(t, a.Sign())
+			if a.Cmp(roundSignificant(a, 2)) != 0 {
+				unrounded++
+				remainderAt[j] = true
+			}
+			sum.Add(sum, a)
+		}
+		assert.Equal(t, total, sum)
+		assert.Equal(t, 1, unrounded, "only the remainder is unrounded")
+	}
+	// The remainder does not always come last
+	assert.Greater(t, len(remainderAt), 1)
+
+	// Tiny amounts still produce positive parts
+	amounts, err := randomDenominations(big.NewInt(3), 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []*big.Int{big.NewInt(1), big.NewInt(1), big.NewInt(1)}, amounts)
+
+	pm := NewPrivacyManager(sanctions.NewDetector(nil))
+	_, err = pm.PlanSplitPayment(generateStealthKeys(t).MetaAddress(), NativeToken, big.NewInt(2), SplitOptions{MinOutputs: 3, MaxOutputs: 4})
+	assert.ErrorIs(t, err, ErrAmountTooSmall)
+	_, err = pm.PlanSplitPayment(generateStealthKeys(t).MetaAddress(), NativeToken, big.NewInt(2), SplitOptions{MinOutputs: 3, MaxOutputs: 2})
+	assert.ErrorIs(t, err, ErrInvalidSplit)
+	_, err = pm.PlanSplitPayment(generateStealthKeys(t).MetaAddress(), NativeToken, new(big.Int).Lsh(big.NewInt(1), 256), SplitOptions{MinOutputs: 2, MaxOutputs: 2})
+	assert.ErrorIs(t, err, ErrAmountTooLarge)
+}
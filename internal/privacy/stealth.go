@@ -2,11 +2,11 @@ package privacy
 
 import (
 	"crypto/ecdsa"
-	"crypto/rand"
 	"errors"
 	"log"
 	"math/big"
 
+	"github.com/prikshit/blockchain-privacy-module/internal/ephemeral"
 	"github.com/prikshit/blockchain-privacy-module/internal/sanctions"
 
 	"github.com/ethereum/go-ethereum/crypto"
@@ -16,7 +16,8 @@ var ErrSanctionedAddress = errors.New("address is sanctioned")
 
 // PrivacyManager manages stealth address generation and sanction detection.
 type PrivacyManager struct {
-	Detector *sanctions.Detector
+	Detector      *sanctions.Detector
+	EphemeralKeys *ephemeral.KeyStore // nil disables ephemeral key reuse detection
 }
 
 // NewPrivacyManager creates a new PrivacyManager instance.
@@ -28,19 +29,18 @@ func NewPrivacyManager(detector *sanctions.Detector) *PrivacyManager {
 // GenerateStealthAddress generates a stealth address using the recipient's public key.
 func (pm *PrivacyManager) GenerateStealthAddress(pubKey *ecdsa.PublicKey) (*ecdsa.PublicKey, *ecdsa.PrivateKey, error) {
 	// Check if the public key is sanctioned
-	address := crypto.PubkeyToAddress(*pubKey).Hex()
-	log.Printf("Attempting to generate stealth address for: %s\n", address)
+	address := crypto.PubkeyToAddress(*pubKey)
+	log.Printf("Attempting to generate stealth address for: %s\n", address.Hex())
 
 	if pm.Detector.IsSanctioned(address) {
-		log.Printf("Sanctioned address detected: %s\n", address)
+		log.Printf("Sanctioned address detected: %s\n", address.Hex())
 		return nil, nil, ErrSanctionedAddress
 	}
 
 	// Generate ephemeral keypair
 	log.Println("Generating ephemeral keypair")
-	ephemeralPrivKey, err := ecdsa.GenerateKey(crypto.S256(), rand.Reader)
+	ephemeralPrivKey, err := pm.newEphemeralKey()
 	if err != nil {
-		log.Printf("Error generating ephemeral key: %v\n", err)
 		return nil, nil, err
 	}
 	log.Println("Ephemeral keypair generated successfully")
@@ -76,13 +76,18 @@ func (pm *PrivacyManager) GenerateSharedSecret(privKey *ecdsa.PrivateKey, epheme
 
 	// Perform ECDH: Shared secret = ephemeralPub * privKey
 	sharedX, _ := privKey.Curve.ScalarMult(ephemeralPub.X, ephemeralPub.Y, privKey.D.Bytes())
-	sharedSecret := crypto.Keccak256(sharedX.Bytes()) // Hash for randomness
+	sharedSecret := SharedSecretFromPoint(sharedX)
 
 	log.Printf("Shared secret generated: %x\n", sharedSecret)
 
 	return sharedSecret, nil
 }
 
+// SharedSecretFromPoint hashes the x-coordinate of an ECDH shared point into the shared secret s.
+func SharedSecretFromPoint(sharedX *big.Int) []byte {
+	return crypto.Keccak256(sharedX.Bytes()) // Hash for randomness
+}
+
 // RecoverStealthPrivateKey recovers the recipient's stealth private key using their original private key and the ephemeral public key.
 func (pm *PrivacyManager) RecoverStealthPrivateKey(recipientPriv *ecdsa.PrivateKey, ephemeralPub *ecdsa.PublicKey) (*ecdsa.PrivateKey, error) {
 	log.Println("Recovering stealth private key")
@@ -8,6 +8,7 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/prikshit/blockchain-privacy-module/internal/sanctions"
 	"github.com/stretchr/testify/assert"
@@ -131,7 +132,7 @@ func TestRecoverStealthPrivateKey(t *testing.T) {
 
 func TestSanctionedAddress(t *testing.T) {
 	// Create a detector with one sanctioned address
-	detector := sanctions.NewDetector([]string{"0x1234567890abcdef1234567890abcdef12345678"})
+	detector := sanctions.NewDetector([]common.Address{common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678")})
 	pm := NewPrivacyManager(detector)
 
 	// Generate recipient's key pair
@@ -139,8 +140,8 @@ func TestSanctionedAddress(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Mark the recipient address as sanctioned
-	address := crypto.PubkeyToAddress(recipientPrivKey.PublicKey).Hex()
-	detector.SanctionedAddresses[address] = struct{}{}
+	address := crypto.PubkeyToAddress(recipientPrivKey.PublicKey)
+	detector.AddAddress(address)
 
 	// Attempt to generate a stealth address (should fail)
 	_, _, err = pm.GenerateStealthAddress(&recipientPrivKey.PublicKey)
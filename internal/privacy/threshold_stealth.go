@@ -0,0 +1,28 @@
+package privacy
+
+import (
+	"log"
+	"math/big"
+
+	"github.com/prikshit/blockchain-privacy-module/internal/threshold"
+)
+
+// RecoverStealthKeyShare is the distributed form of RecoverStealthPrivateKey for a recipient key split with
+// threshold.SplitPrivateKey. Every party broadcasts share.PartialECDH(ephemeralPub); from at least threshold
+// of them each party computes s itself and returns its share of d_s = (d_r + s) mod n.
+// Neither d_r nor d_s is ever reconstructed; spending uses threshold.SigningParty with the returned shares.
+func (pm *PrivacyManager) RecoverStealthKeyShare(share *threshold.KeyShare, partials []*threshold.PartialECDH) (*threshold.KeyShare, error) {
+	log.Printf("Party %d recovering its stealth key share\n", share.Index)
+
+	sharedPoint, err := threshold.CombinePartialECDH(partials, share.Threshold)
+	if err != nil {
+		log.Printf("Error combining partial ECDH results: %v\n", err)
+		return nil, err
+	}
+
+	s := new(big.Int).SetBytes(SharedSecretFromPoint(sharedPoint.X))
+	stealthShare := share.Tweak(s)
+
+	log.Printf("Party %d recovered its stealth key share\n", share.Index)
+	return stealthShare, nil
+}
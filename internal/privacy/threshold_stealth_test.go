@@ -0,0 +1,99 @@
+package privacy
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/prikshit/blockchain-privacy-module/internal/sanctions"
+	"github.com/prikshit/blockchain-privacy-module/internal/threshold"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThresholdStealthRecoveryAndSpend(t *testing.T) {
+	pm := NewPrivacyManager(sanctions.NewDetector(nil))
+
+	// The treasury's key is split 2-of-3 and the dealer's copy discarded
+	recipientPrivKey, err := ecdsa.GenerateKey(crypto.S256(), rand.Reader)
+	assert.NoError(t, err)
+	shares, err := threshold.SplitPrivateKey(recipientPrivKey, 2, 3)
+	assert.NoError(t, err)
+
+	stealthPub, ephemeralPrivKey, err := pm.GenerateStealthAddress(&recipientPrivKey.PublicKey)
+	assert.NoError(t, err)
+	ephemeralPub := &ephemeralPrivKey.PublicKey
+
+	// Each party publishes its partial ECDH and derives its stealth key share
+	partials := make([]*threshold.PartialECDH, len(shares))
+	for i, share := range shares {
+		partials[i] = share.PartialECDH(ephemeralPub)
+	}
+	stealthShares := make([]*threshold.KeyShare, len(shares))
+	for i, share := range shares {
+		stealthShares[i], err = pm.RecoverStealthKeyShare(share, partials[:2])
+		assert.NoError(t, err)
+		assert.Equal(t, stealthPub.X, stealthShares[i].PublicKey.X)
+		assert.Equal(t, stealthPub.Y, stealthShares[i].PublicKey.Y)
+	}
+
+	// The shares match the single-party recovery
+	expected, err := pm.RecoverStealthPrivateKey(recipientPrivKey, ephemeralPub)
+	assert.NoError(t, err)
+	combined, err := threshold.CombineShares(stealthShares[1:])
+	assert.NoError(t, err)
+	assert.Equal(t, expected.D, combined.D)
+
+	// The parties jointly sign a withdrawal from the stealth address
+	chainID := big.NewInt(1)
+	signer := types.LatestSignerForChainID(chainID)
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     0,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(1),
+		Gas:       21000,
+		To:        &common.Address{0x01},
+		Value:     big.NewInt(1),
+	})
+	hash := signer.Hash(tx)
+
+	parties := make([]*threshold.SigningParty, len(stealthShares))
+	for i, share := range stealthShares {
+		parties[i], err = threshold.NewSigningParty(share, []int{1, 2, 3}, hash[:])
+		assert.NoError(t, err)
+	}
+	inbox := make(map[int][]*threshold.DealMessage)
+	var commitments []*threshold.NonceCommitment
+	for _, p := range parties {
+		deals, commitment, err := p.Round1()
+		assert.NoError(t, err)
+		for _, d := range deals {
+			inbox[d.To] = append(inbox[d.To], d)
+		}
+		commitments = append(commitments, commitment)
+	}
+	var products []*threshold.MaskedProduct
+	for i, p := range parties {
+		product, err := p.Round2(inbox[i+1], commitments)
+		assert.NoError(t, err)
+		products = append(products, product)
+	}
+	var sigShares []*threshold.SignatureShare
+	for _, p := range parties {
+		sigShare, err := p.Round3(products)
+		assert.NoError(t, err)
+		sigShares = append(sigShares, sigShare)
+	}
+	sig, err := parties[2].Combine(sigShares)
+	assert.NoError(t, err)
+
+	signedTx, err := tx.WithSignature(signer, sig)
+	assert.NoError(t, err)
+	sender, err := types.Sender(signer, signedTx)
+	assert.NoError(t, err)
+	assert.Equal(t, crypto.PubkeyToAddress(*stealthPub), sender)
+}
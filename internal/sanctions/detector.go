@@ -1,22 +1,76 @@
 package sanctions
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"maps"
+	"regexp"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	ErrInvalidAddress  = errors.New("invalid Ethereum address")
+	ErrInvalidListName = errors.New("invalid sanctions list name")
 )
 
+// Well-known list names. Other names may be used for additional lists.
+const (
+	ListOFAC     = "ofac"
+	ListEU       = "eu"
+	ListUN       = "un"
+	ListInternal = "internal" // Our own blocklist, and the list AddAddress and RemoveAddress work on
+)
+
+// listNamePattern restricts list names to short lower-case identifiers usable in URLs.
+var listNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// Entry records why an address is on a list.
+type Entry struct {
+	List      string    `json:"list"`
+	Reference string    `json:"reference,omitempty"` // File, publication or ticket the listing comes from
+	Reason    string    `json:"reason,omitempty"`
+	Added     time.Time `json:"added"`
+	EntityID  string    `json:"entity_id,omitempty"` // Listed entity, for lists that name one
+	Entity    string    `json:"entity,omitempty"`
+	Currency  string    `json:"currency,omitempty"`
+	Expires   time.Time `json:"expires,omitzero"`     // Zero for entries that never lapse
+	NoHistory bool      `json:"no_history,omitempty"` // A current index match in a point-in-time answer; its past is not recorded
+}
+
 // Detector is responsible for detecting if a given address is sanctioned.
+// It holds several named lists, since OFAC, EU, UN and internal listings carry different legal meaning.
+// Entries are keyed on the 20-byte address, so the casing of the hex form never matters.
+//
+// Checks are served from an immutable snapshot published through an atomic pointer, so screening never
+// waits on a lock. Writers are serialised; each builds a new snapshot, copying only the list it changes,
+// and publishes it once the change is written to the Store, so a snapshot never holds a listing the
+// store failed to record.
 type Detector struct {
-	SanctionedAddresses map[string]struct{} // We can replace the storage to Database for scalability.
-	mu                  sync.RWMutex
+	current atomic.Pointer[snapshot]
+	store   Store
+	mu      sync.Mutex // Serialises writers
+	now     func() time.Time
+}
+
+// snapshot is one version of the lists. It is never modified once published.
+type snapshot struct {
+	lists      map[string]map[common.Address]Entry
+	indexes    map[string]*Index // Lists too large to hold as entries
+	exemptions map[common.Address]Exemption
 }
 
-// NewDetector creates a new Detector instance with an initial list of sanctioned addresses.
-func NewDetector(initialAddresses []string) *Detector {
+// NewDetector creates a new Detector instance backed by a memory store, with an initial list of
+// sanctioned addresses on the internal list.
+func NewDetector(initialAddresses []common.Address) *Detector {
 	log.Println("Initializing sanctions detector")
-	detector := &Detector{
-		SanctionedAddresses: make(map[string]struct{}),
-	}
+	detector := newDetector(make(map[string]map[common.Address]Entry), NewMemoryStore())
 
 	for _, addr := range initialAddresses {
 		detector.AddAddress(addr)
@@ -27,31 +81,334 @@ func NewDetector(initialAddresses []string) *Detector {
 	return detector
 }
 
-// AddAddress adds a new address to the sanctioned list.
-func (d *Detector) AddAddress(address string) {
+// LoadDetector creates a Detector holding the lists of store, which receives every later change.
+func LoadDetector(store Store) (*Detector, error) {
+	lists, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	exemptions, err := store.LoadExemptions()
+	if err != nil {
+		return nil, err
+	}
+	total := 0
+	for _, entries := range lists {
+		total += len(entries)
+	}
+	log.Printf("Sanctions detector loaded %d lists with %d entries and %d exemptions\n", len(lists), total, len(exemptions))
+	d := &Detector{store: store, now: time.Now}
+	d.current.Store(&snapshot{lists: lists, exemptions: exemptions})
+	return d, nil
+}
+
+func newDetector(lists map[string]map[common.Address]Entry, store Store) *Detector {
+	d := &Detector{store: store, now: time.Now}
+	d.current.Store(&snapshot{lists: lists})
+	return d
+}
+
+// withList returns a copy of the snapshot in which list is replaced by entries.
+func (s *snapshot) withList(list string, entries map[common.Address]Entry) *snapshot {
+	lists := maps.Clone(s.lists)
+	lists[list] = entries
+	return &snapshot{lists: lists, indexes: s.indexes, exemptions: s.exemptions}
+}
+
+// withIndex returns a copy of the snapshot in which the index of list is replaced by idx, or removed if nil.
+func (s *snapshot) withIndex(list string, idx *Index) *snapshot {
+	indexes := maps.Clone(s.indexes)
+	if indexes == nil {
+		indexes = make(map[string]*Index)
+	}
+	if idx == nil {
+		delete(indexes, list)
+	} else {
+		indexes[list] = idx
+	}
+	return &snapshot{lists: s.lists, indexes: indexes, exemptions: s.exemptions}
+}
+
+// ParseAddress validates a hex address and returns it in canonical form. The address must be 0x-prefixed
+// and 40 hex digits long. All-lower and all-upper case addresses are accepted; mixed case must be a valid
+// EIP-55 checksum, so a mistyped address is refused rather than silently matching nothing.
+func ParseAddress(s string) (common.Address, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "0x") && !strings.HasPrefix(s, "0X") {
+		return common.Address{}, fmt.Errorf("%w: %q has no 0x prefix", ErrInvalidAddress, s)
+	}
+	if !common.IsHexAddress(s) {
+		return common.Address{}, fmt.Errorf("%w: %q", ErrInvalidAddress, s)
+	}
+	address := common.HexToAddress(s)
+	digits := s[2:]
+	if digits != strings.ToLower(digits) && digits != strings.ToUpper(digits) && address.Hex()[2:] != digits {
+		return common.Address{}, fmt.Errorf("%w: %q fails its EIP-55 checksum", ErrInvalidAddress, s)
+	}
+	return address, nil
+}
+
+// MigrateEntries converts entries of the former string-keyed list to addresses. Entries that differ only
+// in case collapse into one address; malformed entries are returned separately for review.
+func MigrateEntries(entries []string) ([]common.Address, []string) {
+	seen := make(map[common.Address]bool, len(entries))
+	var addresses []common.Address
+	var rejected []string
+	for _, entry := range entries {
+		address, err := ParseAddress(entry)
+		if err != nil {
+			rejected = append(rejected, entry)
+			continue
+		}
+		if !seen[address] {
+			seen[address] = true
+			addresses = append(addresses, address)
+		}
+	}
+	log.Printf("Migrated %d sanctions entries to %d addresses, %d rejected\n", len(entries), len(addresses), len(rejected))
+	return addresses, rejected
+}
+
+// ValidateListName checks that name can be used as a list name.
+func ValidateListName(name string) error {
+	if !listNamePattern.MatchString(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidListName, name)
+	}
+	return nil
+}
+
+// Add puts address on list with the given provenance. Adding an address that is already on the list
+// replaces its entry. Added defaults to now. An entry with an expiry stops matching once it passes.
+func (d *Detector) Add(list string, address common.Address, entry Entry) error {
+	if err := ValidateListName(list); err != nil {
+		return err
+	}
+	entry.List = list
+	if entry.Added.IsZero() {
+		entry.Added = d.now().UTC()
+	}
+	if !entry.Expires.IsZero() && !d.now().Before(entry.Expires) {
+		return fmt.Errorf("%w: %s", ErrAlreadyExpired, entry.Expires.Format(time.RFC3339))
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	current := d.current.Load()
+	var previous *Entry
+	if e, ok := current.lists[list][address]; ok {
+		previous = &e
+	}
+	if err := d.store.Put(list, address, entry, change(list, address, entry, previous, d.now().UTC())); err != nil {
+		return fmt.Errorf("storing %s on list %s: %w", address.Hex(), list, err)
+	}
+	entries := maps.Clone(current.lists[list])
+	if entries == nil {
+		entries = make(map[common.Address]Entry)
+	}
+	entries[address] = entry
+	d.current.Store(current.withList(list, entries))
+	return nil
+}
+
+// ReplaceList replaces the whole content of list, as when a new version of a published list is imported.
+// Entries already on the list keep the date they were first added unless the new entry sets one.
+func (d *Detector) ReplaceList(list string, entries map[common.Address]Entry) error {
+	if err := ValidateListName(list); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	current := d.current.Load()
+	now := d.now().UTC()
+	replacement := make(map[common.Address]Entry, len(entries))
+	for address, entry := range entries {
+		entry.List = list
+		if entry.Added.IsZero() {
+			if previous, ok := current.lists[list][address]; ok {
+				entry.Added = previous.Added
+			} else {
+				entry.Added = now
+			}
+		}
+		replacement[address] = entry
+	}
+
+	// Only the differences to the current version go into the history
+	var changes []Change
+	for address, entry := range replacement {
+		previous, ok := current.lists[list][address]
+		switch {
+		case !ok:
+			changes = append(changes, change(list, address, entry, nil, now))
+		case !sameEntry(previous, entry):
+			changes = append(changes, change(list, address, entry, &previous, now))
+		}
+	}
+	for address, previous := range current.lists[list] {
+		if _, ok := replacement[address]; !ok {
+			changes = append(changes, removal(list, address, previous, now))
+		}
+	}
+	if err := d.store.ReplaceList(list, replacement, changes); err != nil {
+		return fmt.Errorf("storing list %s: %w", list, err)
+	}
+	log.Printf("Replaced sanctions list %s: %d entries, previously %d\n", list, len(replacement), len(current.lists[list]))
+	d.current.Store(current.withList(list, replacement))
+	return nil
+}
+
+// Remove takes address off list and reports whether it was there. Other lists are not affected. The
+// removal is recorded in the history, so the address still matches checks of earlier times.
+func (d *Detector) Remove(list string, address common.Address) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	current := d.current.Load()
+	previous, ok := current.lists[list][address]
+	if !ok {
+		return false, nil
+	}
+	if err := d.store.Delete(list, address, removal(list, address, previous, d.now().UTC())); err != nil {
+		return false, fmt.Errorf("removing %s from list %s: %w", address.Hex(), list, err)
+	}
+	entries := maps.Clone(current.lists[list])
+	delete(entries, address)
+	d.current.Store(current.withList(list, entries))
+	log.Printf("Removed %s from sanctions list %s\n", address.Hex(), list)
+	return true, nil
+}
+
+// AttachIndex serves list from idx, replacing any index the list had. Index lists are not written to the
+// Store; the index file is their storage.
+func (d *Detector) AttachIndex(list string, idx *Index) error {
+	if err := ValidateListName(list); err != nil {
+		return err
+	}
 	d.mu.Lock()
 	defer d.mu.Unlock()
-	d.SanctionedAddresses[address] = struct{}{}
-	log.Printf("Added sanctioned address: %s\n", address)
+	d.current.Store(d.current.Load().withIndex(list, idx))
+	log.Printf("Attached sanctions index %s as list %s with %d addresses\n", idx.Reference(), list, idx.Len())
+	return nil
 }
 
-// RemoveAddress removes an address from the sanctioned list.
-func (d *Detector) RemoveAddress(address string) {
+// DetachIndex stops serving list from its index and reports whether it had one.
+func (d *Detector) DetachIndex(list string) bool {
 	d.mu.Lock()
 	defer d.mu.Unlock()
-	delete(d.SanctionedAddresses, address)
-	log.Printf("Removed sanctioned address: %s\n", address)
+	current := d.current.Load()
+	if current.indexes[list] == nil {
+		return false
+	}
+	d.current.Store(current.withIndex(list, nil))
+	return true
 }
 
-// IsSanctioned checks if a given address is sanctioned.
-func (d *Detector) IsSanctioned(address string) bool {
-	d.mu.RLock()
-	defer d.mu.RUnlock()
-	_, exists := d.SanctionedAddresses[address]
-	if exists {
-		log.Printf("Address %s is sanctioned\n", address)
-	} else {
-		log.Printf("Address %s is not sanctioned\n", address)
+// Indexes returns the size and filter accuracy of every index list.
+func (d *Detector) Indexes() map[string]IndexStats {
+	indexes := d.current.Load().indexes
+	stats := make(map[string]IndexStats, len(indexes))
+	for list, idx := range indexes {
+		stats[list] = idx.Stats()
+	}
+	return stats
+}
+
+// Matches returns the entry of every list address is on, ordered by list name. Index lists only record
+// the index file and its build time.
+func (d *Detector) Matches(address common.Address) []Entry {
+	return d.matches(d.current.Load(), address)
+}
+
+func (d *Detector) matches(current *snapshot, address common.Address) []Entry {
+	var matches []Entry
+	for _, entries := range current.lists {
+		if entry, ok := entries[address]; ok && d.active(entry) {
+			matches = append(matches, entry)
+		}
 	}
-	return exists
+	for list, idx := range current.indexes {
+		if indexed(list, idx, address) {
+			matches = append(matches, Entry{List: list, Reference: idx.Reference(), Added: idx.built})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].List < matches[j].List })
+	return matches
+}
+
+// Lists returns the number of addresses on each list. Expired entries are not counted.
+func (d *Detector) Lists() map[string]int {
+	current := d.current.Load()
+	counts := make(map[string]int, len(current.lists)+len(current.indexes))
+	now := d.now()
+	for name, entries := range current.lists {
+		counts[name] = 0
+		for _, entry := range entries {
+			if entry.activeAt(now) {
+				counts[name]++
+			}
+		}
+	}
+	for name, idx := range current.indexes {
+		counts[name] += int(idx.Len())
+	}
+	return counts
+}
+
+// AddAddress adds a new address to the internal list.
+func (d *Detector) AddAddress(address common.Address) error {
+	if err := d.Add(ListInternal, address, Entry{Reason: "added manually"}); err != nil {
+		return err
+	}
+	log.Printf("Added sanctioned address: %s\n", address.Hex())
+	return nil
+}
+
+// RemoveAddress removes an address from the internal list.
+func (d *Detector) RemoveAddress(address common.Address) error {
+	if _, err := d.Remove(ListInternal, address); err != nil {
+		return err
+	}
+	log.Printf("Removed sanctioned address: %s\n", address.Hex())
+	return nil
+}
+
+// IsSanctioned checks if a given address is on any list that its exemption, if any, does not override.
+// It is on the screening path of every payment, so it neither locks nor logs; callers log the decisions
+// they act on.
+func (d *Detector) IsSanctioned(address common.Address) bool {
+	current := d.current.Load()
+	for list, entries := range current.lists {
+		if entry, ok := entries[address]; ok && d.active(entry) && !d.exempted(current, address, list) {
+			return true
+		}
+	}
+	for list, idx := range current.indexes {
+		if indexed(list, idx, address) && !d.exempted(current, address, list) {
+			return true
+		}
+	}
+	return false
+}
+
+// exempted reports whether an exemption in force overrides the match of address on list.
+func (d *Detector) exempted(s *snapshot, address common.Address, list string) bool {
+	exemption := d.exemption(s, address)
+	return exemption != nil && exemption.covers(list)
+}
+
+// indexed looks address up in idx. An index that cannot be read fails closed: screening must not pass
+// an address only because its list was unavailable.
+func indexed(list string, idx *Index, address common.Address) bool {
+	found, err := idx.Contains(address)
+	if err != nil {
+		log.Printf("Treating %s as listed on %s: %v\n", address.Hex(), list, err)
+		return true
+	}
+	return found
+}
+
+// Close closes the detector's store.
+func (d *Detector) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.store.Close()
 }
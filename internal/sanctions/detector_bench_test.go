@@ -0,0 +1,138 @@
+package sanctions
+
+import (
+	"encoding/binary"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// lockedDetector is the detector as it was before snapshots: every check takes a read lock and logs
+// twice. It is kept as the baseline for the benchmarks below.
+type lockedDetector struct {
+	lists map[string]map[common.Address]Entry
+	mu    sync.RWMutex
+}
+
+func (d *lockedDetector) IsSanctioned(address common.Address) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	exists := false
+	for _, entries := range d.lists {
+		if _, ok := entries[address]; ok {
+			exists = true
+			break
+		}
+	}
+	if exists {
+		log.Printf("Address %s is sanctioned\n", address.Hex())
+	} else {
+		log.Printf("Address %s is not sanctioned\n", address.Hex())
+	}
+	return exists
+}
+
+func (d *lockedDetector) Add(list string, address common.Address, entry Entry) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.lists[list] == nil {
+		d.lists[list] = make(map[common.Address]Entry)
+	}
+	d.lists[list][address] = entry
+}
+
+const benchListSize = 10_000
+
+func benchAddress(i int) common.Address {
+	var address common.Address
+	binary.BigEndian.PutUint64(address[12:], uint64(i))
+	return address
+}
+
+func benchLists() map[string]map[common.Address]Entry {
+	lists := make(map[string]map[common.Address]Entry)
+	for _, list := range []string{ListOFAC, ListEU, ListUN, ListInternal} {
+		lists[list] = make(map[common.Address]Entry)
+	}
+	for i := 0; i < benchListSize; i++ {
+		lists[ListOFAC][benchAddress(i)] = Entry{List: ListOFAC}
+	}
+	return lists
+}
+
+// discardLogs silences the baseline's logging for the benchmark, which still pays for formatting it.
+func discardLogs(b *testing.B) {
+	log.SetOutput(io.Discard)
+	b.Cleanup(func() { log.SetOutput(os.Stderr) })
+}
+
+// Parallel checks, half of them hits. Run with -cpu 1,4,16 to see how each scales with readers.
+func BenchmarkIsSanctionedParallel(b *testing.B) {
+	discardLogs(b)
+	b.Run("snapshot", func(b *testing.B) {
+		d := newDetector(benchLists(), NewMemoryStore())
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				d.IsSanctioned(benchAddress(i % (2 * benchListSize)))
+			}
+		})
+	})
+	b.Run("rwmutex", func(b *testing.B) {
+		d := &lockedDetector{lists: benchLists()}
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				d.IsSanctioned(benchAddress(i % (2 * benchListSize)))
+			}
+		})
+	})
+}
+
+// Parallel checks while one writer keeps adding to the small internal list.
+func BenchmarkIsSanctionedParallelWithWriter(b *testing.B) {
+	discardLogs(b)
+	b.Run("snapshot", func(b *testing.B) {
+		d := newDetector(benchLists(), NewMemoryStore())
+		stop := startWriter(func(i int) { d.Add(ListInternal, benchAddress(i%100), Entry{}) })
+		defer stop()
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				d.IsSanctioned(benchAddress(i % (2 * benchListSize)))
+			}
+		})
+	})
+	b.Run("rwmutex", func(b *testing.B) {
+		d := &lockedDetector{lists: benchLists()}
+		stop := startWriter(func(i int) { d.Add(ListInternal, benchAddress(i%100), Entry{}) })
+		defer stop()
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				d.IsSanctioned(benchAddress(i % (2 * benchListSize)))
+			}
+		})
+	})
+}
+
+// startWriter calls write in a loop until the returned function is called.
+func startWriter(write func(i int)) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+				write(i)
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
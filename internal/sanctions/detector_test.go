@@ -1,34 +1,144 @@
 package sanctions
 
 import (
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	tornadoRouter = common.HexToAddress("0x8589427373D6D84E98730D7795D8f6f8731FDA16")
+	otherAddress  = common.HexToAddress("0x722122dF12D4e14e13Ac3b6895a86e84145b6967")
 )
 
 func TestDetector(t *testing.T) {
-	initialList := []string{
-		"0xAbc123",
-		"0xDef456",
-	}
+	initialList := []common.Address{tornadoRouter}
 
 	detector := NewDetector(initialList)
 
 	// Test adding a new address
-	detector.AddAddress("0xNew789")
-	if !detector.IsSanctioned("0xNew789") {
+	detector.AddAddress(otherAddress)
+	if !detector.IsSanctioned(otherAddress) {
 		t.Error("Address should be marked as sanctioned")
 	}
 
 	// Test removing an address
-	detector.RemoveAddress("0xNew789")
-	if detector.IsSanctioned("0xNew789") {
+	detector.RemoveAddress(otherAddress)
+	if detector.IsSanctioned(otherAddress) {
 		t.Error("Address should not be marked as sanctioned")
 	}
 
 	// Test checking initial addresses
-	if !detector.IsSanctioned("0xAbc123") {
+	if !detector.IsSanctioned(tornadoRouter) {
 		t.Error("Address should be marked as sanctioned")
 	}
-	if detector.IsSanctioned("0xUnknown") {
+	if detector.IsSanctioned(common.HexToAddress("0x000000000000000000000000000000000000dEaD")) {
 		t.Error("Address should not be marked as sanctioned")
 	}
 }
+
+func TestCasingDoesNotMatter(t *testing.T) {
+	detector := NewDetector(nil)
+	lower, err := ParseAddress(strings.ToLower(tornadoRouter.Hex()))
+	require.NoError(t, err)
+	detector.AddAddress(lower)
+
+	for _, form := range []string{tornadoRouter.Hex(), strings.ToLower(tornadoRouter.Hex()), "0x" + strings.ToUpper(tornadoRouter.Hex()[2:])} {
+		address, err := ParseAddress(form)
+		require.NoError(t, err, form)
+		assert.True(t, detector.IsSanctioned(address), form)
+	}
+
+	upper, err := ParseAddress("0x" + strings.ToUpper(tornadoRouter.Hex()[2:]))
+	require.NoError(t, err)
+	detector.RemoveAddress(upper)
+	assert.False(t, detector.IsSanctioned(tornadoRouter))
+}
+
+func TestParseAddressRejectsMalformed(t *testing.T) {
+	badChecksum := "0x8589427373d6D84E98730D7795D8f6f8731FDA16"
+	for _, input := range []string{"", "0xAbc123", "8589427373D6D84E98730D7795D8f6f8731FDA16", "0x8589427373D6D84E98730D7795D8f6f8731FDA1G", badChecksum} {
+		_, err := ParseAddress(input)
+		assert.ErrorIs(t, err, ErrInvalidAddress, input)
+	}
+
+	address, err := ParseAddress("  " + tornadoRouter.Hex() + "\n")
+	require.NoError(t, err)
+	assert.Equal(t, tornadoRouter, address)
+}
+
+func TestMigrateEntries(t *testing.T) {
+	addresses, rejected := MigrateEntries([]string{
+		tornadoRouter.Hex(),
+		strings.ToLower(tornadoRouter.Hex()),
+		"0xAbc123",
+		otherAddress.Hex(),
+		"0xDef456",
+	})
+	assert.Equal(t, []common.Address{tornadoRouter, otherAddress}, addresses)
+	assert.Equal(t, []string{"0xAbc123", "0xDef456"}, rejected)
+}
+
+func TestNamedLists(t *testing.T) {
+	detector := NewDetector(nil)
+	added := time.Date(2022, 8, 8, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, detector.Add(ListOFAC, tornadoRouter, Entry{Reference: "SDN 40115", Reason: "Tornado Cash", Added: added}))
+	require.NoError(t, detector.Add(ListEU, tornadoRouter, Entry{Reference: "Regulation 2022/1269", Reason: "Mixer"}))
+	require.NoError(t, detector.Add(ListInternal, otherAddress, Entry{Reason: "Phishing"}))
+
+	matches := detector.Matches(tornadoRouter)
+	require.Len(t, matches, 2)
+	assert.Equal(t, ListEU, matches[0].List)
+	assert.Equal(t, Entry{List: ListOFAC, Reference: "SDN 40115", Reason: "Tornado Cash", Added: added}, matches[1])
+	assert.False(t, matches[0].Added.IsZero())
+	assert.Equal(t, map[string]int{ListOFAC: 1, ListEU: 1, ListInternal: 1}, detector.Lists())
+
+	// Removing from one list leaves the others
+	removed, err := detector.Remove(ListEU, tornadoRouter)
+	require.NoError(t, err)
+	assert.True(t, removed)
+	removed, err = detector.Remove(ListEU, tornadoRouter)
+	require.NoError(t, err)
+	assert.False(t, removed)
+	assert.True(t, detector.IsSanctioned(tornadoRouter))
+	removed, err = detector.Remove(ListOFAC, tornadoRouter)
+	require.NoError(t, err)
+	assert.True(t, removed)
+	assert.False(t, detector.IsSanctioned(tornadoRouter))
+	assert.Empty(t, detector.Matches(tornadoRouter))
+
+	for _, name := range []string{"", "OFAC", "eu list", "../internal"} {
+		assert.ErrorIs(t, detector.Add(name, tornadoRouter, Entry{}), ErrInvalidListName, name)
+	}
+}
+
+func TestChecksSeeWholeSnapshots(t *testing.T) {
+	detector := NewDetector(nil)
+	versions := []map[common.Address]Entry{
+		{tornadoRouter: {Reason: "v1"}},
+		{otherAddress: {Reason: "v2"}},
+	}
+	require.NoError(t, detector.ReplaceList(ListEU, versions[0]))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			assert.NoError(t, detector.ReplaceList(ListEU, versions[i%2]))
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		// A replaced list is swapped in whole, so a check never sees both versions or neither
+		assert.Equal(t, 1, detector.Lists()[ListEU])
+		detector.IsSanctioned(tornadoRouter)
+	}
+}
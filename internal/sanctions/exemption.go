@@ -0,0 +1,157 @@
+package sanctions
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var ErrMissingJustification = errors.New("exemption needs a justification")
+
+// Exemption overrides list matches of an address that must not be blocked, such as our own hot wallets
+// or a counterparty holding an OFAC specific license. It is evaluated after the list matches and always
+// lapses, so every exemption is reviewed again.
+type Exemption struct {
+	Justification string    `json:"justification"`
+	Reference     string    `json:"reference,omitempty"` // License number, ticket or approval it rests on
+	Lists         []string  `json:"lists,omitempty"`     // Lists it overrides; every list when empty
+	Granted       time.Time `json:"granted"`
+	Expires       time.Time `json:"expires"`
+}
+
+// Result is the outcome of a check with the reasoning behind it.
+type Result struct {
+	Sanctioned    bool       `json:"sanctioned"`
+	Matches       []Entry    `json:"matches"`                  // Every list match, exempted or not
+	Exemption     *Exemption `json:"exemption,omitempty"`      // The exemption that overrode some matches
+	ExemptedLists []string   `json:"exempted_lists,omitempty"` // Matched lists the exemption overrode
+}
+
+// covers reports whether the exemption overrides matches on list.
+func (e Exemption) covers(list string) bool {
+	return len(e.Lists) == 0 || slices.Contains(e.Lists, list)
+}
+
+// Exempt records an exemption for address, replacing any it had. Granted defaults to now.
+func (d *Detector) Exempt(address common.Address, exemption Exemption) error {
+	if strings.TrimSpace(exemption.Justification) == "" {
+		return ErrMissingJustification
+	}
+	for _, list := range exemption.Lists {
+		if err := ValidateListName(list); err != nil {
+			return err
+		}
+	}
+	if !d.now().Before(exemption.Expires) {
+		return fmt.Errorf("%w: %s", ErrAlreadyExpired, exemption.Expires.Format(time.RFC3339))
+	}
+	if exemption.Granted.IsZero() {
+		exemption.Granted = d.now().UTC()
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	current := d.current.Load()
+	change := Change{Action: ActionExempted, Address: address, Effective: exemption.Granted, Recorded: d.now().UTC(), Exemption: &exemption}
+	if previous, ok := current.exemptions[address]; ok {
+		change.PreviousExemption = &previous
+	}
+	if err := d.store.PutExemption(address, exemption, change); err != nil {
+		return fmt.Errorf("storing exemption of %s: %w", address.Hex(), err)
+	}
+	d.current.Store(current.withExemption(address, &exemption))
+	log.Printf("Exempted %s from sanctions lists %v until %s: %s\n", address.Hex(), exemption.Lists, exemption.Expires.Format(time.RFC3339), exemption.Justification)
+	return nil
+}
+
+// RemoveExemption withdraws the exemption of address and reports whether it had one.
+func (d *Detector) RemoveExemption(address common.Address) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	current := d.current.Load()
+	previous, ok := current.exemptions[address]
+	if !ok {
+		return false, nil
+	}
+	now := d.now().UTC()
+	change := Change{Action: ActionExemptionRemoved, Address: address, Effective: now, Recorded: now, PreviousExemption: &previous}
+	if err := d.store.DeleteExemption(address, change); err != nil {
+		return false, fmt.Errorf("removing exemption of %s: %w", address.Hex(), err)
+	}
+	d.current.Store(current.withExemption(address, nil))
+	log.Printf("Removed sanctions exemption of %s\n", address.Hex())
+	return true, nil
+}
+
+// Exemptions returns the exemptions in force, keyed by address.
+func (d *Detector) Exemptions() map[common.Address]Exemption {
+	now := d.now()
+	exemptions := make(map[common.Address]Exemption)
+	for address, exemption := range d.current.Load().exemptions {
+		if now.Before(exemption.Expires) {
+			exemptions[address] = exemption
+		}
+	}
+	return exemptions
+}
+
+// Check returns whether address is sanctioned, every list match and the exemption that overrode any of
+// them, so a response can explain why a listed address was let through. Matches and the exemption are
+// read from the same snapshot.
+func (d *Detector) Check(address common.Address) Result {
+	current := d.current.Load()
+	matches := d.matches(current, address)
+	if len(matches) == 0 {
+		return result(matches, nil)
+	}
+	return result(matches, d.exemption(current, address))
+}
+
+// result applies exemption, which may be nil, to the list matches of an address.
+func result(matches []Entry, exemption *Exemption) Result {
+	r := Result{Matches: matches}
+	for _, match := range matches {
+		if exemption != nil && exemption.covers(match.List) {
+			r.ExemptedLists = append(r.ExemptedLists, match.List)
+		} else {
+			r.Sanctioned = true
+		}
+	}
+	if len(r.ExemptedLists) > 0 {
+		r.Exemption = exemption
+	}
+	sort.Strings(r.ExemptedLists)
+	return r
+}
+
+// exemption returns the exemption of address in force, or nil. Checks only look it up once an address has
+// matched a list, so screening unlisted addresses never reads it.
+func (d *Detector) exemption(s *snapshot, address common.Address) *Exemption {
+	exemption, ok := s.exemptions[address]
+	if !ok || !d.now().Before(exemption.Expires) {
+		return nil
+	}
+	return &exemption
+}
+
+// withExemption returns a copy of the snapshot in which the exemption of address is replaced by exemption,
+// or removed if nil.
+func (s *snapshot) withExemption(address common.Address, exemption *Exemption) *snapshot {
+	exemptions := maps.Clone(s.exemptions)
+	if exemptions == nil {
+		exemptions = make(map[common.Address]Exemption)
+	}
+	if exemption == nil {
+		delete(exemptions, address)
+	} else {
+		exemptions[address] = *exemption
+	}
+	return &snapshot{lists: s.lists, indexes: s.indexes, exemptions: exemptions}
+}
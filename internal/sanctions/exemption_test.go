@@ -0,0 +1,130 @@
+package sanctions
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExemptionOverridesListedMatches(t *testing.T) {
+	detector := NewDetector(nil)
+	clock := day("2024-05-01")
+	detector.now = func() time.Time { return clock }
+
+	require.NoError(t, detector.Add(ListEU, tornadoRouter, Entry{Reason: "Exposure"}))
+	require.NoError(t, detector.Add(ListOFAC, tornadoRouter, Entry{Reason: "Mixer"}))
+
+	assert.ErrorIs(t, detector.Exempt(tornadoRouter, Exemption{Expires: clock.AddDate(0, 1, 0)}), ErrMissingJustification)
+	assert.ErrorIs(t, detector.Exempt(tornadoRouter, Exemption{Justification: "Hot wallet", Expires: clock}), ErrAlreadyExpired)
+	assert.ErrorIs(t, detector.Exempt(tornadoRouter, Exemption{Justification: "Hot wallet", Lists: []string{"EU list"}, Expires: clock.AddDate(0, 1, 0)}), ErrInvalidListName)
+
+	// A specific license covering the EU listing leaves the OFAC listing in force
+	expires := clock.AddDate(0, 1, 0)
+	require.NoError(t, detector.Exempt(tornadoRouter, Exemption{Justification: "Licensed counterparty", Reference: "EU-2024-17", Lists: []string{ListEU}, Expires: expires}))
+	assert.True(t, detector.IsSanctioned(tornadoRouter))
+	result := detector.Check(tornadoRouter)
+	assert.True(t, result.Sanctioned)
+	assert.Len(t, result.Matches, 2)
+	assert.Equal(t, []string{ListEU}, result.ExemptedLists)
+	assert.Equal(t, "EU-2024-17", result.Exemption.Reference)
+	assert.Equal(t, clock, result.Exemption.Granted)
+
+	// An exemption from every list lets the address through and explains why
+	require.NoError(t, detector.Exempt(tornadoRouter, Exemption{Justification: "Our hot wallet", Expires: expires}))
+	assert.False(t, detector.IsSanctioned(tornadoRouter))
+	result = detector.Check(tornadoRouter)
+	assert.False(t, result.Sanctioned)
+	assert.Len(t, result.Matches, 2)
+	assert.Equal(t, []string{ListEU, ListOFAC}, result.ExemptedLists)
+	assert.Equal(t, "Our hot wallet", result.Exemption.Justification)
+
+	// An exemption does not apply to an address that is not listed
+	result = detector.Check(otherAddress)
+	assert.False(t, result.Sanctioned)
+	assert.Nil(t, result.Exemption)
+
+	// Once it lapses the listings apply again, and the sweeper purges it
+	clock = expires
+	assert.True(t, detector.IsSanctioned(tornadoRouter))
+	assert.Nil(t, detector.Check(tornadoRouter).Exemption)
+	assert.Empty(t, detector.Exemptions())
+	purged, err := detector.PurgeExpired()
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	require.NoError(t, detector.Exempt(tornadoRouter, Exemption{Justification: "Our hot wallet", Expires: clock.AddDate(0, 1, 0)}))
+	removed, err := detector.RemoveExemption(tornadoRouter)
+	require.NoError(t, err)
+	assert.True(t, removed)
+	assert.True(t, detector.IsSanctioned(tornadoRouter))
+	removed, err = detector.RemoveExemption(tornadoRouter)
+	require.NoError(t, err)
+	assert.False(t, removed)
+}
+
+func TestExemptionsPersistAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sanctions.db")
+	store, err := OpenBoltStore(path)
+	require.NoError(t, err)
+	detector, err := LoadDetector(store)
+	require.NoError(t, err)
+	require.NoError(t, detector.AddAddress(tornadoRouter))
+	require.NoError(t, detector.Exempt(tornadoRouter, Exemption{Justification: "Our hot wallet", Expires: time.Now().AddDate(1, 0, 0)}))
+	require.NoError(t, detector.Close())
+
+	store, err = OpenBoltStore(path)
+	require.NoError(t, err)
+	detector, err = LoadDetector(store)
+	require.NoError(t, err)
+	defer detector.Close()
+	assert.False(t, detector.IsSanctioned(tornadoRouter))
+	assert.Equal(t, "Our hot wallet", detector.Exemptions()[tornadoRouter].Justification)
+}
+
+func TestPointInTimeChecksApplyExemptions(t *testing.T) {
+	detector := NewDetector(nil)
+	clock := day("2024-04-01")
+	detector.now = func() time.Time { return clock }
+	require.NoError(t, detector.Add(ListOFAC, tornadoRouter, Entry{Reason: "Mixer"}))
+
+	clock = day("2024-05-01")
+	require.NoError(t, detector.Exempt(tornadoRouter, Exemption{Justification: "Licensed counterparty", Reference: "OFAC-2024-123", Expires: day("2024-08-01")}))
+	clock = day("2024-06-01")
+	removed, err := detector.RemoveExemption(tornadoRouter)
+	require.NoError(t, err)
+	require.True(t, removed)
+	require.NoError(t, detector.Exempt(tornadoRouter, Exemption{Justification: "Wind-down licence", Expires: day("2024-07-01")}))
+
+	history, err := detector.History(tornadoRouter)
+	require.NoError(t, err)
+	var actions []string
+	for _, change := range history {
+		actions = append(actions, change.Action)
+	}
+	assert.Equal(t, []string{ActionAdded, ActionExempted, ActionExemptionRemoved, ActionExempted}, actions)
+
+	checkAt := func(at string) Result {
+		t.Helper()
+		result, err := detector.CheckAt(tornadoRouter, day(at))
+		require.NoError(t, err)
+		return result
+	}
+	assert.True(t, checkAt("2024-04-15").Sanctioned)
+
+	// A withdrawn exemption still explains the answer for the time it was in force
+	result := checkAt("2024-05-15")
+	assert.False(t, result.Sanctioned)
+	assert.Equal(t, "OFAC-2024-123", result.Exemption.Reference)
+	assert.Equal(t, []string{ListOFAC}, result.ExemptedLists)
+
+	assert.Equal(t, "Wind-down licence", checkAt("2024-06-15").Exemption.Justification)
+	result = checkAt("2024-07-15")
+	assert.True(t, result.Sanctioned, "the exemption had lapsed")
+	assert.Nil(t, result.Exemption)
+
+	// MatchesAt still reports the listing itself
+	assert.Equal(t, []string{ListOFAC}, listsAt(t, detector, tornadoRouter, "2024-05-15"))
+}
@@ -0,0 +1,94 @@
+package sanctions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var ErrAlreadyExpired = errors.New("entry expiry is not in the future")
+
+// activeAt reports whether the entry still matches at t.
+func (e Entry) activeAt(t time.Time) bool {
+	return e.Expires.IsZero() || t.Before(e.Expires)
+}
+
+// active reports whether entry matches now. Only entries with an expiry read the clock, so checks of
+// permanent listings cost nothing extra.
+func (d *Detector) active(entry Entry) bool {
+	return entry.Expires.IsZero() || d.now().Before(entry.Expires)
+}
+
+// Entries returns the entries of list that still match, keyed by address. Index lists cannot be listed.
+func (d *Detector) Entries(list string) map[common.Address]Entry {
+	now := d.now()
+	entries := make(map[common.Address]Entry)
+	for address, entry := range d.current.Load().lists[list] {
+		if entry.activeAt(now) {
+			entries[address] = entry
+		}
+	}
+	return entries
+}
+
+// PurgeExpired removes every entry and exemption whose expiry has passed and returns how many it removed.
+// Both already stop applying when they lapse; purging only reclaims them, recording each in the history.
+func (d *Detector) PurgeExpired() (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := d.now().UTC()
+	purged := 0
+	for list, entries := range d.current.Load().lists {
+		for address, entry := range entries {
+			if entry.activeAt(now) {
+				continue
+			}
+			change := Change{Action: ActionExpired, List: list, Address: address, Effective: entry.Expires, Recorded: now, Previous: &entry}
+			if err := d.store.Delete(list, address, change); err != nil {
+				return purged, fmt.Errorf("purging %s from list %s: %w", address.Hex(), list, err)
+			}
+			// Publish each purge, so a failure part way leaves memory in step with the store
+			current := d.current.Load()
+			remaining := maps.Clone(current.lists[list])
+			delete(remaining, address)
+			d.current.Store(current.withList(list, remaining))
+			purged++
+		}
+	}
+	for address, exemption := range d.current.Load().exemptions {
+		if now.Before(exemption.Expires) {
+			continue
+		}
+		change := Change{Action: ActionExemptionExpired, Address: address, Effective: exemption.Expires, Recorded: now, PreviousExemption: &exemption}
+		if err := d.store.DeleteExemption(address, change); err != nil {
+			return purged, fmt.Errorf("purging exemption of %s: %w", address.Hex(), err)
+		}
+		d.current.Store(d.current.Load().withExemption(address, nil))
+		purged++
+	}
+	if purged > 0 {
+		log.Printf("Purged %d expired sanctions entries and exemptions\n", purged)
+	}
+	return purged, nil
+}
+
+// RunSweeper purges expired entries and exemptions every interval until ctx is cancelled.
+func (d *Detector) RunSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := d.PurgeExpired(); err != nil {
+				log.Println("Error purging expired sanctions entries:", err)
+			}
+		}
+	}
+}
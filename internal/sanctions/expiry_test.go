@@ -0,0 +1,54 @@
+package sanctions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpiringEntries(t *testing.T) {
+	detector := NewDetector(nil)
+	clock := day("2024-05-01")
+	detector.now = func() time.Time { return clock }
+
+	assert.ErrorIs(t, detector.Add(ListInternal, otherAddress, Entry{Reason: "Fraud hold", Expires: clock}), ErrAlreadyExpired)
+
+	// A fraud hold lapsing after a 30-day review period
+	expires := clock.AddDate(0, 0, 30)
+	require.NoError(t, detector.Add(ListInternal, tornadoRouter, Entry{Reason: "Fraud hold", Expires: expires}))
+	require.NoError(t, detector.Add(ListEU, otherAddress, Entry{Reason: "Mixer"}))
+	assert.True(t, detector.IsSanctioned(tornadoRouter))
+	assert.Equal(t, expires, detector.Matches(tornadoRouter)[0].Expires)
+	assert.Len(t, detector.Entries(ListInternal), 1)
+
+	// Once the expiry passes the entry stops matching without any write
+	clock = expires
+	assert.False(t, detector.IsSanctioned(tornadoRouter))
+	assert.Empty(t, detector.Matches(tornadoRouter))
+	assert.Empty(t, detector.Entries(ListInternal))
+	assert.Equal(t, map[string]int{ListInternal: 0, ListEU: 1}, detector.Lists())
+	history, err := detector.History(tornadoRouter)
+	require.NoError(t, err)
+	assert.Len(t, history, 1)
+
+	// The sweeper purges it and records the expiry in the history
+	clock = expires.Add(time.Hour)
+	purged, err := detector.PurgeExpired()
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	purged, err = detector.PurgeExpired()
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+	assert.True(t, detector.IsSanctioned(otherAddress))
+
+	history, err = detector.History(tornadoRouter)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, ActionExpired, history[1].Action)
+	assert.Equal(t, expires, history[1].Effective)
+
+	assert.Equal(t, []string{ListInternal}, listsAt(t, detector, tornadoRouter, "2024-05-30"))
+	assert.Equal(t, []string{}, listsAt(t, detector, tornadoRouter, "2024-05-31"))
+}
@@ -0,0 +1,155 @@
+package sanctions
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Change actions.
+const (
+	ActionAdded   = "added"
+	ActionUpdated = "updated"
+	ActionRemoved = "removed"
+	ActionExpired = "expired" // Purged by the sweeper; takes effect at the entry's expiry
+
+	ActionExempted         = "exempted" // Takes effect when the exemption was granted
+	ActionExemptionRemoved = "exemption_removed"
+	ActionExemptionExpired = "exemption_expired" // Purged by the sweeper; takes effect at the exemption's expiry
+)
+
+// Change records one add, update, removal or expiry of an address on a list, or one grant, withdrawal or
+// expiry of its exemption. Exemption changes carry no list.
+type Change struct {
+	Action            string         `json:"action"`
+	List              string         `json:"list,omitempty"`
+	Address           common.Address `json:"address"`
+	Effective         time.Time      `json:"effective"`                    // When the change took effect; for additions, when the entry was added
+	Recorded          time.Time      `json:"recorded"`                     // When the detector recorded it
+	Entry             *Entry         `json:"entry,omitempty"`              // The entry in force after the change; nil for removals
+	Previous          *Entry         `json:"previous,omitempty"`           // The entry it replaced or removed
+	Exemption         *Exemption     `json:"exemption,omitempty"`          // The exemption in force after the change
+	PreviousExemption *Exemption     `json:"previous_exemption,omitempty"` // The exemption it replaced or withdrew
+}
+
+// exemptionChange reports whether the change is to the address's exemption rather than a list.
+func (c Change) exemptionChange() bool {
+	switch c.Action {
+	case ActionExempted, ActionExemptionRemoved, ActionExemptionExpired:
+		return true
+	}
+	return false
+}
+
+// History returns every change recorded for address, in the order they were recorded.
+func (d *Detector) History(address common.Address) ([]Change, error) {
+	return d.store.History(address)
+}
+
+// MatchesAt returns the entry of every list address was on at t, ordered by list name, answering
+// "was this address sanctioned when the payment was made?". Entries listed before history was recorded
+// count from the date they were added, as does the entry replaced by the first change recorded for a
+// list. Index lists keep no history, so an address on one now is included with NoHistory set rather
+// than answered as never listed. Exemptions are not applied; CheckAt applies them.
+func (d *Detector) MatchesAt(address common.Address, t time.Time) ([]Entry, error) {
+	matches, _, err := d.replay(address, t)
+	return matches, err
+}
+
+// CheckAt returns whether address was sanctioned at t, with the exemption in force at t and the matched
+// lists it overrode, so a point-in-time answer explains itself as a current check does.
+func (d *Detector) CheckAt(address common.Address, t time.Time) (Result, error) {
+	matches, exemption, err := d.replay(address, t)
+	if err != nil {
+		return Result{}, err
+	}
+	return result(matches, exemption), nil
+}
+
+// replay rebuilds the list entries and the exemption of address in force at t from its history.
+func (d *Detector) replay(address common.Address, t time.Time) ([]Entry, *Exemption, error) {
+	history, err := d.store.History(address)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Replay in order of effect; changes taking effect at the same time apply in the order recorded
+	sort.SliceStable(history, func(i, j int) bool { return history[i].Effective.Before(history[j].Effective) })
+	current := d.current.Load()
+
+	inForce := make(map[string]Entry)
+	recorded := make(map[string]bool)
+	var exemption *Exemption
+	exemptionRecorded := false
+	for _, change := range history {
+		if change.exemptionChange() {
+			if !exemptionRecorded && change.PreviousExemption != nil && !change.PreviousExemption.Granted.After(t) {
+				exemption = change.PreviousExemption
+			}
+			exemptionRecorded = true
+			if !change.Effective.After(t) {
+				exemption = change.Exemption
+			}
+			continue
+		}
+		if !recorded[change.List] && change.Previous != nil && !change.Previous.Added.After(t) {
+			inForce[change.List] = *change.Previous
+		}
+		recorded[change.List] = true
+		if change.Effective.After(t) {
+			continue
+		}
+		if change.Entry == nil {
+			delete(inForce, change.List)
+		} else {
+			inForce[change.List] = *change.Entry
+		}
+	}
+	for list, entries := range current.lists {
+		if entry, ok := entries[address]; ok && !recorded[list] && !entry.Added.After(t) {
+			inForce[list] = entry
+		}
+	}
+	if current, ok := current.exemptions[address]; ok && !exemptionRecorded && !current.Granted.After(t) {
+		exemption = &current
+	}
+	if exemption != nil && !t.Before(exemption.Expires) {
+		exemption = nil
+	}
+
+	matches := make([]Entry, 0, len(inForce))
+	for _, entry := range inForce {
+		if entry.activeAt(t) {
+			matches = append(matches, entry)
+		}
+	}
+	for list, idx := range current.indexes {
+		if indexed(list, idx, address) {
+			matches = append(matches, Entry{List: list, Reference: idx.Reference(), Added: idx.built, NoHistory: true})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].List < matches[j].List })
+	return matches, exemption, nil
+}
+
+// change returns the record of an add or update of address on list to entry, given the previous entry.
+func change(list string, address common.Address, entry Entry, previous *Entry, now time.Time) Change {
+	c := Change{Action: ActionAdded, List: list, Address: address, Effective: entry.Added, Recorded: now, Entry: &entry}
+	if previous != nil {
+		c.Action = ActionUpdated
+		c.Effective = now
+		c.Previous = previous
+	}
+	return c
+}
+
+// removal returns the record of taking address off list, where it had the previous entry.
+func removal(list string, address common.Address, previous Entry, now time.Time) Change {
+	return Change{Action: ActionRemoved, List: list, Address: address, Effective: now, Recorded: now, Previous: &previous}
+}
+
+// sameEntry reports whether two entries record the same listing.
+func sameEntry(a, b Entry) bool {
+	return a.Added.Equal(b.Added) && a.List == b.List && a.Reference == b.Reference && a.Reason == b.Reason &&
+		a.EntityID == b.EntityID && a.Entity == b.Entity && a.Currency == b.Currency && a.Expires.Equal(b.Expires)
+}
@@ -0,0 +1,111 @@
+package sanctions
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func day(s string) time.Time {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func listsAt(t *testing.T, d *Detector, address common.Address, at string) []string {
+	t.Helper()
+	matches, err := d.MatchesAt(address, day(at))
+	require.NoError(t, err)
+	lists := []string{}
+	for _, m := range matches {
+		lists = append(lists, m.List)
+	}
+	return lists
+}
+
+func TestPointInTimeChecks(t *testing.T) {
+	store, err := OpenBoltStore(filepath.Join(t.TempDir(), "sanctions.db"))
+	require.NoError(t, err)
+	detector, err := LoadDetector(store)
+	require.NoError(t, err)
+	defer detector.Close()
+	clock := day("2022-08-09")
+	detector.now = func() time.Time { return clock }
+
+	// Designated on 8 August 2022, imported the next day
+	sdn := map[common.Address]Entry{tornadoRouter: {Reference: "sdn.xml", Reason: "Tornado Cash", Added: day("2022-08-08")}}
+	require.NoError(t, detector.ReplaceList(ListOFAC, sdn))
+	clock = day("2022-09-01")
+	require.NoError(t, detector.Add(ListInternal, tornadoRouter, Entry{Reason: "Mixer"}))
+	clock = day("2022-10-01")
+	require.NoError(t, detector.Add(ListInternal, tornadoRouter, Entry{Reason: "Mixer, confirmed"}))
+	require.NoError(t, detector.ReplaceList(ListOFAC, sdn)) // An unchanged import records nothing
+
+	// Delisted on 21 March 2025
+	clock = day("2025-03-21")
+	require.NoError(t, detector.ReplaceList(ListOFAC, map[common.Address]Entry{otherAddress: {Reference: "sdn.xml"}}))
+	clock = day("2025-04-01")
+	_, err = detector.Remove(ListInternal, tornadoRouter)
+	require.NoError(t, err)
+
+	assert.False(t, detector.IsSanctioned(tornadoRouter))
+	assert.Equal(t, []string{}, listsAt(t, detector, tornadoRouter, "2022-08-07"))
+	assert.Equal(t, []string{ListOFAC}, listsAt(t, detector, tornadoRouter, "2022-08-08"))
+	assert.Equal(t, []string{ListInternal, ListOFAC}, listsAt(t, detector, tornadoRouter, "2023-01-01"))
+	assert.Equal(t, []string{ListInternal}, listsAt(t, detector, tornadoRouter, "2025-03-25"))
+	assert.Equal(t, []string{}, listsAt(t, detector, tornadoRouter, "2025-04-01"))
+
+	matches, err := detector.MatchesAt(tornadoRouter, day("2022-09-15"))
+	require.NoError(t, err)
+	assert.Equal(t, "Mixer", matches[0].Reason)
+
+	history, err := detector.History(tornadoRouter)
+	require.NoError(t, err)
+	var actions []string
+	for _, c := range history {
+		actions = append(actions, c.List+" "+c.Action)
+	}
+	assert.Equal(t, []string{"ofac added", "internal added", "internal updated", "ofac removed", "internal removed"}, actions)
+	assert.Equal(t, day("2022-08-08"), history[0].Effective)
+	assert.Equal(t, day("2022-08-09"), history[0].Recorded)
+	assert.Nil(t, history[3].Entry)
+}
+
+func TestEntriesWithoutHistoryCountFromWhenAdded(t *testing.T) {
+	memory := NewMemoryStore()
+	// Stored before history was recorded
+	require.NoError(t, memory.ReplaceList(ListEU, map[common.Address]Entry{tornadoRouter: {List: ListEU, Added: day("2023-01-01")}}, nil))
+	detector, err := LoadDetector(memory)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{}, listsAt(t, detector, tornadoRouter, "2022-12-31"))
+	assert.Equal(t, []string{ListEU}, listsAt(t, detector, tornadoRouter, "2023-01-01"))
+
+	// Removing it keeps the time it was listed
+	detector.now = func() time.Time { return day("2024-01-01") }
+	_, err = detector.Remove(ListEU, tornadoRouter)
+	require.NoError(t, err)
+	assert.Equal(t, []string{ListEU}, listsAt(t, detector, tornadoRouter, "2023-06-01"))
+	assert.Equal(t, []string{}, listsAt(t, detector, tornadoRouter, "2024-01-01"))
+}
+
+func TestPointInTimeChecksIncludeIndexLists(t *testing.T) {
+	idx, err := OpenIndex(buildTestIndex(t, []common.Address{tornadoRouter}), IndexOptions{})
+	require.NoError(t, err)
+	detector := NewDetector(nil)
+	require.NoError(t, detector.AttachIndex("exposure", idx))
+
+	// An index keeps no history, so a current match is reported as such rather than answered as never listed
+	matches, err := detector.MatchesAt(tornadoRouter, day("2020-01-01"))
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "exposure", matches[0].List)
+	assert.True(t, matches[0].NoHistory)
+	assert.Equal(t, []string{}, listsAt(t, detector, otherAddress, "2020-01-01"))
+}
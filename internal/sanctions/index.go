@@ -0,0 +1,212 @@
+package sanctions
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/prikshit/blockchain-privacy-module/internal/bloom"
+)
+
+// Index file layout: an 8-byte magic, the record count and build time as big-endian uint64s, then the
+// addresses as sorted, unique 20-byte records.
+const (
+	indexMagic      = "SANCIDX1"
+	indexHeaderSize = 24
+	indexFanout     = 1 << 16 // Records are bucketed by their first two bytes
+)
+
+// DefaultIndexFalsePositiveRate is the prefilter's false-positive rate when IndexOptions leaves it unset.
+// At 10M addresses the filter takes about 36 MB.
+const DefaultIndexFalsePositiveRate = 1e-6
+
+var ErrInvalidIndex = errors.New("invalid sanctions index file")
+
+// IndexOptions sizes the in-memory prefilter of an index.
+type IndexOptions struct {
+	FalsePositiveRate float64
+}
+
+// IndexStats describes an index for operators.
+type IndexStats struct {
+	Entries           uint64    `json:"entries"`
+	Built             time.Time `json:"built"`
+	FilterBytes       uint64    `json:"filter_bytes"`
+	FalsePositiveRate float64   `json:"false_positive_rate"`
+	ExactLookups      uint64    `json:"exact_lookups"` // Filter hits that read the index file
+}
+
+// Index holds a very large list, such as an exposure list of tens of millions of risky addresses, which
+// would not fit in memory as entries. A Bloom filter answers most lookups from memory; only filter hits
+// read the file, one bucket of sorted records located through a fanout table on the first two address
+// bytes. An index is immutable once opened and safe for concurrent lookups.
+type Index struct {
+	file         *os.File
+	filter       *bloom.Filter
+	fanout       []uint32 // fanout[p] is the first record whose first two bytes are at least p
+	count        uint64
+	built        time.Time
+	reference    string
+	exactLookups atomic.Uint64
+}
+
+// BuildIndex writes addresses to an index file at path, replacing any previous file only once the new one
+// is complete. addresses is sorted in place.
+func BuildIndex(path string, addresses []common.Address) error {
+	slices.SortFunc(addresses, func(a, b common.Address) int { return bytes.Compare(a[:], b[:]) })
+	addresses = slices.Compact(addresses)
+
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	w := bufio.NewWriterSize(file, 1<<20)
+	header := make([]byte, 0, indexHeaderSize)
+	header = append(header, indexMagic...)
+	header = binary.BigEndian.AppendUint64(header, uint64(len(addresses)))
+	header = binary.BigEndian.AppendUint64(header, uint64(time.Now().Unix()))
+	w.Write(header)
+	for _, address := range addresses {
+		w.Write(address[:])
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("writing index: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("writing index: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	log.Printf("Built sanctions index %s with %d addresses\n", path, len(addresses))
+	return os.Rename(tmp, path)
+}
+
+// OpenIndex opens the index file at path and builds its prefilter and fanout table.
+func OpenIndex(path string, opts IndexOptions) (*Index, error) {
+	if opts.FalsePositiveRate <= 0 || opts.FalsePositiveRate >= 1 {
+		opts.FalsePositiveRate = DefaultIndexFalsePositiveRate
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := loadIndex(file, opts)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%w %s: %v", ErrInvalidIndex, path, err)
+	}
+	idx.reference = filepath.Base(path)
+	log.Printf("Opened sanctions index %s: %d addresses, %d byte filter\n", path, idx.count, idx.filter.SizeBytes())
+	return idx, nil
+}
+
+func loadIndex(file *os.File, opts IndexOptions) (*Index, error) {
+	header := make([]byte, indexHeaderSize)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return nil, err
+	}
+	if string(header[:8]) != indexMagic {
+		return nil, errors.New("bad magic")
+	}
+	count := binary.BigEndian.Uint64(header[8:16])
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(info.Size()) != indexHeaderSize+count*common.AddressLength || count > 1<<32-1 {
+		return nil, fmt.Errorf("size %d does not hold %d records", info.Size(), count)
+	}
+
+	idx := &Index{
+		file:   file,
+		filter: bloom.New(count, opts.FalsePositiveRate),
+		fanout: make([]uint32, indexFanout+1),
+		count:  count,
+		built:  time.Unix(int64(binary.BigEndian.Uint64(header[16:24])), 0).UTC(),
+	}
+	r := bufio.NewReaderSize(file, 1<<20)
+	var previous, record common.Address
+	for i := uint64(0); i < count; i++ {
+		if _, err := io.ReadFull(r, record[:]); err != nil {
+			return nil, err
+		}
+		if i > 0 && bytes.Compare(previous[:], record[:]) >= 0 {
+			return nil, fmt.Errorf("record %d is out of order", i)
+		}
+		idx.filter.Add(record[:])
+		idx.fanout[prefix(record)+1]++
+		previous = record
+	}
+	for p := 1; p <= indexFanout; p++ {
+		idx.fanout[p] += idx.fanout[p-1]
+	}
+	return idx, nil
+}
+
+// Contains reports whether address is in the index. An error means the file could not be read.
+func (idx *Index) Contains(address common.Address) (bool, error) {
+	if !idx.filter.Test(address[:]) {
+		return false, nil
+	}
+	idx.exactLookups.Add(1)
+	p := prefix(address)
+	lo, hi := idx.fanout[p], idx.fanout[p+1]
+	if lo == hi {
+		return false, nil
+	}
+	bucket := make([]byte, int(hi-lo)*common.AddressLength)
+	if _, err := idx.file.ReadAt(bucket, indexHeaderSize+int64(lo)*common.AddressLength); err != nil {
+		return false, fmt.Errorf("reading sanctions index %s: %w", idx.reference, err)
+	}
+	record := func(i int) []byte { return bucket[i*common.AddressLength : (i+1)*common.AddressLength] }
+	n := int(hi - lo)
+	i := sort.Search(n, func(i int) bool { return bytes.Compare(record(i), address[:]) >= 0 })
+	return i < n && bytes.Equal(record(i), address[:]), nil
+}
+
+// Len returns the number of addresses in the index.
+func (idx *Index) Len() uint64 {
+	return idx.count
+}
+
+// Reference returns the index file name, recorded on the entries it matches.
+func (idx *Index) Reference() string {
+	return idx.reference
+}
+
+// Stats returns the size and filter accuracy of the index.
+func (idx *Index) Stats() IndexStats {
+	return IndexStats{
+		Entries:           idx.count,
+		Built:             idx.built,
+		FilterBytes:       idx.filter.SizeBytes(),
+		FalsePositiveRate: idx.filter.FalsePositiveRate(),
+		ExactLookups:      idx.exactLookups.Load(),
+	}
+}
+
+// Close releases the index file. An index still attached to a detector must not be closed; a detached
+// index is closed when it is garbage collected, after the last check using it.
+func (idx *Index) Close() error {
+	return idx.file.Close()
+}
+
+func prefix(address common.Address) int {
+	return int(address[0])<<8 | int(address[1])
+}
@@ -0,0 +1,79 @@
+package sanctions
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// benchIndexEntries is the size of the exposure list in the index benchmarks.
+const benchIndexEntries = 10_000_000
+
+func randomAddresses(n int, seed uint64) []common.Address {
+	rng := rand.NewChaCha8([32]byte{byte(seed)})
+	addresses := make([]common.Address, n)
+	for i := range addresses {
+		rng.Read(addresses[i][:])
+	}
+	return addresses
+}
+
+func heapInUse() uint64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+// benchLookups runs hit and miss lookups as sub-benchmarks, reporting the memory the list takes.
+func benchLookups(b *testing.B, mb float64, hits, misses []common.Address, contains func(common.Address)) {
+	for name, addresses := range map[string][]common.Address{"hit": hits, "miss": misses} {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				contains(addresses[i%len(addresses)])
+			}
+			b.ReportMetric(mb, "MB")
+		})
+	}
+}
+
+// Memory and lookup latency of a 10M address list held as map[string]struct{}, as the detector once did,
+// and as an index. Building the list takes about a minute.
+func BenchmarkLargeList(b *testing.B) {
+	discardLogs(b)
+	addresses := randomAddresses(benchIndexEntries, 1)
+	misses := randomAddresses(1<<16, 2)
+	hits := append([]common.Address(nil), addresses[:1<<16]...)
+
+	b.Run("map", func(b *testing.B) {
+		before := heapInUse()
+		list := make(map[string]struct{})
+		for _, address := range addresses {
+			list[address.Hex()] = struct{}{}
+		}
+		mb := float64(heapInUse()-before) / (1 << 20)
+		benchLookups(b, mb, hits, misses, func(address common.Address) { _ = list[address.Hex()] })
+		runtime.KeepAlive(list)
+	})
+
+	path := filepath.Join(b.TempDir(), "exposure.idx")
+	if err := BuildIndex(path, addresses); err != nil {
+		b.Fatal(err)
+	}
+	for _, rate := range []float64{1e-3, DefaultIndexFalsePositiveRate} {
+		b.Run(fmt.Sprintf("index/fpr=%g", rate), func(b *testing.B) {
+			before := heapInUse()
+			idx, err := OpenIndex(path, IndexOptions{FalsePositiveRate: rate})
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer idx.Close()
+			mb := float64(heapInUse()-before) / (1 << 20)
+			benchLookups(b, mb, hits, misses, func(address common.Address) { idx.Contains(address) })
+		})
+	}
+}
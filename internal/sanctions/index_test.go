@@ -0,0 +1,86 @@
+package sanctions
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func buildTestIndex(t *testing.T, addresses []common.Address) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "exposure.idx")
+	require.NoError(t, BuildIndex(path, addresses))
+	return path
+}
+
+func TestIndexLookups(t *testing.T) {
+	var addresses []common.Address
+	for i := 0; i < 50_000; i += 2 {
+		addresses = append(addresses, benchAddress(i))
+	}
+	addresses = append(addresses, tornadoRouter, tornadoRouter) // Duplicates are dropped
+	idx, err := OpenIndex(buildTestIndex(t, addresses), IndexOptions{FalsePositiveRate: 0.01})
+	require.NoError(t, err)
+	defer idx.Close()
+
+	assert.Equal(t, uint64(25_001), idx.Len())
+	for i := 0; i < 50_000; i++ {
+		found, err := idx.Contains(benchAddress(i))
+		require.NoError(t, err)
+		require.Equal(t, i%2 == 0, found, i)
+	}
+	found, err := idx.Contains(tornadoRouter)
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	// Only filter hits read the file: every listed address and about 1% of the others
+	stats := idx.Stats()
+	assert.Less(t, stats.ExactLookups, uint64(25_001+500))
+	assert.InDelta(t, 0.01, stats.FalsePositiveRate, 0.002)
+}
+
+func TestOpenIndexRejectsDamagedFile(t *testing.T) {
+	path := buildTestIndex(t, []common.Address{tornadoRouter, otherAddress})
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, data[:len(data)-1], 0o600))
+	_, err = OpenIndex(path, IndexOptions{})
+	assert.ErrorIs(t, err, ErrInvalidIndex)
+
+	// Records out of order would make lookups miss
+	swapped := append(append(append([]byte{}, data[:indexHeaderSize]...), data[indexHeaderSize+20:]...), data[indexHeaderSize:indexHeaderSize+20]...)
+	require.NoError(t, os.WriteFile(path, swapped, 0o600))
+	_, err = OpenIndex(path, IndexOptions{})
+	assert.ErrorIs(t, err, ErrInvalidIndex)
+}
+
+func TestDetectorServesIndexLists(t *testing.T) {
+	idx, err := OpenIndex(buildTestIndex(t, []common.Address{tornadoRouter, benchAddress(1)}), IndexOptions{})
+	require.NoError(t, err)
+
+	detector := NewDetector(nil)
+	require.NoError(t, detector.Add(ListOFAC, tornadoRouter, Entry{Reason: "Tornado Cash"}))
+	require.NoError(t, detector.AttachIndex("exposure", idx))
+
+	assert.True(t, detector.IsSanctioned(benchAddress(1)))
+	assert.False(t, detector.IsSanctioned(otherAddress))
+	matches := detector.Matches(tornadoRouter)
+	require.Len(t, matches, 2)
+	assert.Equal(t, "exposure", matches[0].List)
+	assert.Equal(t, "exposure.idx", matches[0].Reference)
+	assert.Equal(t, map[string]int{ListOFAC: 1, "exposure": 2}, detector.Lists())
+	assert.Equal(t, uint64(2), detector.Indexes()["exposure"].Entries)
+
+	// An index that cannot be read fails closed
+	require.NoError(t, idx.Close())
+	assert.True(t, detector.IsSanctioned(benchAddress(1)))
+
+	assert.True(t, detector.DetachIndex("exposure"))
+	assert.False(t, detector.DetachIndex("exposure"))
+	assert.False(t, detector.IsSanctioned(benchAddress(1)))
+}
@@ -0,0 +1,326 @@
+package sanctions
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Store persists the detector's lists and the history of changes to them. Every write is one transaction:
+// the change and its history records apply in full or not at all.
+type Store interface {
+	// Load returns every list with its entries.
+	Load() (map[string]map[common.Address]Entry, error)
+	// Put adds or replaces the entry of address on list.
+	Put(list string, address common.Address, entry Entry, change Change) error
+	// Delete removes address from list. Deleting an address that is not listed is not an error.
+	Delete(list string, address common.Address, change Change) error
+	// ReplaceList replaces the whole content of list with entries.
+	ReplaceList(list string, entries map[common.Address]Entry, changes []Change) error
+	// History returns the changes recorded for address in the order they were recorded.
+	History(address common.Address) ([]Change, error)
+	// LoadExemptions returns every exemption.
+	LoadExemptions() (map[common.Address]Exemption, error)
+	// PutExemption adds or replaces the exemption of address.
+	PutExemption(address common.Address, exemption Exemption, change Change) error
+	// DeleteExemption removes the exemption of address, if any.
+	DeleteExemption(address common.Address, change Change) error
+	Close() error
+}
+
+// MemoryStore keeps lists in memory only. It is lost on restart, for tests and single-run tools.
+type MemoryStore struct {
+	mu         sync.Mutex
+	lists      map[string]map[common.Address]Entry
+	history    map[common.Address][]Change
+	exemptions map[common.Address]Exemption
+}
+
+// NewMemoryStore returns an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		lists:      make(map[string]map[common.Address]Entry),
+		history:    make(map[common.Address][]Change),
+		exemptions: make(map[common.Address]Exemption),
+	}
+}
+
+func (s *MemoryStore) Load() (map[string]map[common.Address]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lists := make(map[string]map[common.Address]Entry, len(s.lists))
+	for name, entries := range s.lists {
+		lists[name] = maps.Clone(entries)
+	}
+	return lists, nil
+}
+
+func (s *MemoryStore) Put(list string, address common.Address, entry Entry, change Change) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lists[list] == nil {
+		s.lists[list] = make(map[common.Address]Entry)
+	}
+	s.lists[list][address] = entry
+	s.history[address] = append(s.history[address], change)
+	return nil
+}
+
+func (s *MemoryStore) Delete(list string, address common.Address, change Change) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.lists[list], address)
+	s.history[address] = append(s.history[address], change)
+	return nil
+}
+
+func (s *MemoryStore) ReplaceList(list string, entries map[common.Address]Entry, changes []Change) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lists[list] = maps.Clone(entries)
+	for _, change := range changes {
+		s.history[change.Address] = append(s.history[change.Address], change)
+	}
+	return nil
+}
+
+func (s *MemoryStore) History(address common.Address) ([]Change, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.history[address]), nil
+}
+
+func (s *MemoryStore) LoadExemptions() (map[common.Address]Exemption, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return maps.Clone(s.exemptions), nil
+}
+
+func (s *MemoryStore) PutExemption(address common.Address, exemption Exemption, change Change) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.exemptions[address] = exemption
+	s.history[address] = append(s.history[address], change)
+	return nil
+}
+
+func (s *MemoryStore) DeleteExemption(address common.Address, change Change) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.exemptions, address)
+	s.history[address] = append(s.history[address], change)
+	return nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// Buckets of the bbolt store. listsBucket holds one nested bucket per list, keyed by the 20-byte address
+// with JSON-encoded entries. historyBucket holds one nested bucket per address, keyed by a big-endian
+// sequence number with JSON-encoded changes. exemptionsBucket is keyed by the 20-byte address with
+// JSON-encoded exemptions.
+var (
+	listsBucket      = []byte("lists")
+	historyBucket    = []byte("history")
+	exemptionsBucket = []byte("exemptions")
+)
+
+// BoltStore keeps lists in an embedded bbolt database file.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// OpenBoltStore opens the database at path, creating it if needed. It fails rather than waits if another
+// process holds the file.
+func OpenBoltStore(path string) (*BoltStore, error) {
+	log.Printf("Opening sanctions store at %s\n", path)
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("opening sanctions store: %w", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{listsBucket, historyBucket, exemptionsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltStore{db: db}, nil
+}
+
+func (s *BoltStore) Load() (map[string]map[common.Address]Entry, error) {
+	lists := make(map[string]map[common.Address]Entry)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(listsBucket).ForEachBucket(func(name []byte) error {
+			entries := make(map[common.Address]Entry)
+			err := tx.Bucket(listsBucket).Bucket(name).ForEach(func(k, v []byte) error {
+				var entry Entry
+				if len(k) != common.AddressLength {
+					return fmt.Errorf("list %s has a malformed key %x", name, k)
+				}
+				if err := json.Unmarshal(v, &entry); err != nil {
+					return fmt.Errorf("list %s entry %x: %w", name, k, err)
+				}
+				entries[common.BytesToAddress(k)] = entry
+				return nil
+			})
+			lists[string(name)] = entries
+			return err
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("loading sanctions store: %w", err)
+	}
+	return lists, nil
+}
+
+func (s *BoltStore) Put(list string, address common.Address, entry Entry, change Change) error {
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.Bucket(listsBucket).CreateBucketIfNotExists([]byte(list))
+		if err != nil {
+			return err
+		}
+		if err := bucket.Put(address.Bytes(), value); err != nil {
+			return err
+		}
+		return appendHistory(tx, change)
+	})
+}
+
+func (s *BoltStore) Delete(list string, address common.Address, change Change) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if bucket := tx.Bucket(listsBucket).Bucket([]byte(list)); bucket != nil {
+			if err := bucket.Delete(address.Bytes()); err != nil {
+				return err
+			}
+		}
+		return appendHistory(tx, change)
+	})
+}
+
+func (s *BoltStore) ReplaceList(list string, entries map[common.Address]Entry, changes []Change) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for _, change := range changes {
+			if err := appendHistory(tx, change); err != nil {
+				return err
+			}
+		}
+		root := tx.Bucket(listsBucket)
+		if root.Bucket([]byte(list)) != nil {
+			if err := root.DeleteBucket([]byte(list)); err != nil {
+				return err
+			}
+		}
+		bucket, err := root.CreateBucket([]byte(list))
+		if err != nil {
+			return err
+		}
+		for address, entry := range entries {
+			value, err := json.Marshal(entry)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put(address.Bytes(), value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *BoltStore) History(address common.Address) ([]Change, error) {
+	var history []Change
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(historyBucket).Bucket(address.Bytes())
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(_, v []byte) error {
+			var change Change
+			if err := json.Unmarshal(v, &change); err != nil {
+				return fmt.Errorf("history of %s: %w", address.Hex(), err)
+			}
+			history = append(history, change)
+			return nil
+		})
+	})
+	return history, err
+}
+
+func (s *BoltStore) LoadExemptions() (map[common.Address]Exemption, error) {
+	exemptions := make(map[common.Address]Exemption)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(exemptionsBucket).ForEach(func(k, v []byte) error {
+			var exemption Exemption
+			if err := json.Unmarshal(v, &exemption); err != nil {
+				return fmt.Errorf("exemption %x: %w", k, err)
+			}
+			exemptions[common.BytesToAddress(k)] = exemption
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("loading sanctions exemptions: %w", err)
+	}
+	return exemptions, nil
+}
+
+func (s *BoltStore) PutExemption(address common.Address, exemption Exemption, change Change) error {
+	value, err := json.Marshal(exemption)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(exemptionsBucket).Put(address.Bytes(), value); err != nil {
+			return err
+		}
+		return appendHistory(tx, change)
+	})
+}
+
+func (s *BoltStore) DeleteExemption(address common.Address, change Change) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(exemptionsBucket).Delete(address.Bytes()); err != nil {
+			return err
+		}
+		return appendHistory(tx, change)
+	})
+}
+
+func appendHistory(tx *bolt.Tx, change Change) error {
+	bucket, err := tx.Bucket(historyBucket).CreateBucketIfNotExists(change.Address.Bytes())
+	if err != nil {
+		return err
+	}
+	seq, err := bucket.NextSequence()
+	if err != nil {
+		return err
+	}
+	value, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(binary.BigEndian.AppendUint64(nil, seq), value)
+}
+
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,91 @@
+package sanctions
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectorPersistsAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sanctions.db")
+
+	store, err := OpenBoltStore(path)
+	require.NoError(t, err)
+	detector, err := LoadDetector(store)
+	require.NoError(t, err)
+	added := time.Date(2022, 8, 8, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, detector.Add(ListEU, tornadoRouter, Entry{Reference: "Regulation 2022/1269", Reason: "Mixer", Added: added}))
+	require.NoError(t, detector.AddAddress(otherAddress))
+	require.NoError(t, detector.ReplaceList(ListOFAC, map[common.Address]Entry{
+		tornadoRouter: {Reference: "sdn.xml", EntityID: "40115", Entity: "TORNADO CASH", Currency: "ETH"},
+		otherAddress:  {Reference: "sdn.xml"},
+	}))
+	require.NoError(t, detector.RemoveAddress(otherAddress))
+	require.NoError(t, detector.ReplaceList(ListOFAC, map[common.Address]Entry{
+		tornadoRouter: {Reference: "sdn.xml", EntityID: "40115", Entity: "TORNADO CASH", Currency: "ETH"},
+	}))
+	want := detector.Matches(tornadoRouter)
+	require.NoError(t, detector.Close())
+
+	store, err = OpenBoltStore(path)
+	require.NoError(t, err)
+	detector, err = LoadDetector(store)
+	require.NoError(t, err)
+	defer detector.Close()
+
+	assert.Equal(t, map[string]int{ListEU: 1, ListOFAC: 1, ListInternal: 0}, detector.Lists())
+	assert.Equal(t, want, detector.Matches(tornadoRouter))
+	assert.Equal(t, added, detector.Matches(tornadoRouter)[0].Added)
+	assert.False(t, detector.IsSanctioned(otherAddress))
+}
+
+func TestBoltStoreIsExclusive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sanctions.db")
+	store, err := OpenBoltStore(path)
+	require.NoError(t, err)
+	defer store.Close()
+
+	_, err = OpenBoltStore(path)
+	assert.Error(t, err)
+}
+
+// failingStore fails every write, as a full disk would.
+type failingStore struct {
+	*MemoryStore
+}
+
+var errStoreFull = errors.New("no space left on device")
+
+func (failingStore) Put(string, common.Address, Entry, Change) error { return errStoreFull }
+func (failingStore) Delete(string, common.Address, Change) error     { return errStoreFull }
+func (failingStore) ReplaceList(string, map[common.Address]Entry, []Change) error {
+	return errStoreFull
+}
+func (failingStore) PutExemption(common.Address, Exemption, Change) error { return errStoreFull }
+func (failingStore) DeleteExemption(common.Address, Change) error         { return errStoreFull }
+
+func TestDetectorKeepsMemoryInStepWithStore(t *testing.T) {
+	memory := NewMemoryStore()
+	require.NoError(t, memory.Put(ListInternal, tornadoRouter, Entry{List: ListInternal, Reason: "Phishing"}, Change{}))
+	detector, err := LoadDetector(failingStore{memory})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, detector.AddAddress(otherAddress), errStoreFull)
+	assert.False(t, detector.IsSanctioned(otherAddress))
+
+	assert.ErrorIs(t, detector.ReplaceList(ListOFAC, map[common.Address]Entry{otherAddress: {}}), errStoreFull)
+	assert.False(t, detector.IsSanctioned(otherAddress))
+
+	_, err = detector.Remove(ListInternal, tornadoRouter)
+	assert.ErrorIs(t, err, errStoreFull)
+	assert.True(t, detector.IsSanctioned(tornadoRouter))
+
+	exemption := Exemption{Justification: "Hot wallet", Expires: time.Now().Add(time.Hour)}
+	assert.ErrorIs(t, detector.Exempt(tornadoRouter, exemption), errStoreFull)
+	assert.True(t, detector.IsSanctioned(tornadoRouter))
+}
@@ -0,0 +1,263 @@
+package smartaccount
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/prikshit/blockchain-privacy-module/internal/privacy"
+)
+
+// DefaultEntryPoint is the canonical ERC-4337 v0.7 EntryPoint address.
+var DefaultEntryPoint = common.HexToAddress("0x0000000071727De22E5E9d8BAf0edAc6f37da032")
+
+// Default gas limits for a sweep. Deploying the account dominates verification gas.
+var (
+	DefaultCallGasLimit         = big.NewInt(100_000)
+	DefaultVerificationGasLimit = big.NewInt(500_000)
+	DefaultPreVerificationGas   = big.NewInt(50_000)
+)
+
+var (
+	ErrNoFactory      = errors.New("no account factory is configured")
+	ErrNothingToSweep = errors.New("account holds nothing to sweep")
+	ErrValueTooLarge  = errors.New("value does not fit in 128 bits")
+	ErrNeedsPaymaster = errors.New("sweeping the whole ETH balance needs a paymaster to cover the gas")
+)
+
+const userOpTuple = `{"name":"ops","type":"tuple[]","components":[{"name":"sender","type":"address"},{"name":"nonce","type":"uint256"},
+	{"name":"initCode","type":"bytes"},{"name":"callData","type":"bytes"},{"name":"accountGasLimits","type":"bytes32"},
+	{"name":"preVerificationGas","type":"uint256"},{"name":"gasFees","type":"bytes32"},{"name":"paymasterAndData","type":"bytes"},
+	{"name":"signature","type":"bytes"}]}`
+
+var (
+	entryPointABI = mustParseABI(`[
+		{"type":"function","name":"handleOps","stateMutability":"nonpayable","inputs":[` + userOpTuple + `,{"name":"beneficiary","type":"address"}],"outputs":[]},
+		{"type":"function","name":"getNonce","stateMutability":"view","inputs":[{"name":"sender","type":"address"},{"name":"key","type":"uint192"}],
+			"outputs":[{"name":"nonce","type":"uint256"}]}
+	]`)
+	accountABI = mustParseABI(`[
+		{"type":"function","name":"execute","stateMutability":"nonpayable","inputs":[{"name":"dest","type":"address"},{"name":"value","type":"uint256"},
+			{"name":"func","type":"bytes"}],"outputs":[]}
+	]`)
+	factoryABI = mustParseABI(`[
+		{"type":"function","name":"createAccount","stateMutability":"nonpayable","inputs":[{"name":"owner","type":"address"},{"name":"salt","type":"uint256"}],
+			"outputs":[{"name":"ret","type":"address"}]}
+	]`)
+	erc20ABI = mustParseABI(`[
+		{"type":"function","name":"transfer","stateMutability":"nonpayable","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],
+			"outputs":[{"name":"","type":"bool"}]},
+		{"type":"function","name":"balanceOf","stateMutability":"view","inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"","type":"uint256"}]}
+	]`)
+)
+
+// Backend is the chain access needed to build user operations.
+type Backend interface {
+	ethereum.ChainStateReader
+	ethereum.ContractCaller
+}
+
+// Config describes the account factory stealth payments are sent through.
+type Config struct {
+	EntryPoint common.Address // DefaultEntryPoint when zero
+	Factory    common.Address
+	// AccountCreationCode is the creation code the factory deploys with CREATE2; the owner is appended
+	// to it as a single ABI word, so the account address follows from the owner alone.
+	AccountCreationCode []byte
+	Salt                *big.Int // Zero when nil
+	ChainID             *big.Int
+}
+
+// Gas holds the gas limits and fees of a user operation. Zero limits take the defaults.
+type Gas struct {
+	CallGasLimit         *big.Int
+	VerificationGasLimit *big.Int
+	PreVerificationGas   *big.Int
+	MaxFeePerGas         *big.Int
+	MaxPriorityFeePerGas *big.Int
+}
+
+// Accounts builds user operations for counterfactual smart accounts owned by stealth keys. The payer
+// sends to AccountAddress of the stealth owner instead of the owner itself; the recipient recovers the
+// stealth key as usual and signs a user operation that deploys the account and sweeps it in one step.
+type Accounts struct {
+	cfg     Config
+	backend Backend
+}
+
+// NewAccounts returns a builder for accounts of cfg's factory.
+func NewAccounts(cfg Config, backend Backend) (*Accounts, error) {
+	if cfg.Factory == (common.Address{}) || len(cfg.AccountCreationCode) == 0 {
+		return nil, ErrNoFactory
+	}
+	if cfg.EntryPoint == (common.Address{}) {
+		cfg.EntryPoint = DefaultEntryPoint
+	}
+	if cfg.Salt == nil {
+		cfg.Salt = new(big.Int)
+	}
+	if cfg.ChainID == nil {
+		return nil, errors.New("chain ID is required")
+	}
+	log.Printf("Smart accounts from factory %s via EntryPoint %s\n", cfg.Factory.Hex(), cfg.EntryPoint.Hex())
+	return &Accounts{cfg: cfg, backend: backend}, nil
+}
+
+// EntryPoint returns the EntryPoint the user operations are signed for.
+func (a *Accounts) EntryPoint() common.Address {
+	return a.cfg.EntryPoint
+}
+
+// ChainID returns the chain the user operations are signed for.
+func (a *Accounts) ChainID() *big.Int {
+	return new(big.Int).Set(a.cfg.ChainID)
+}
+
+// AccountAddress returns the CREATE2 address of the account the factory deploys for owner.
+func (a *Accounts) AccountAddress(owner common.Address) common.Address {
+	initCode := append(append([]byte{}, a.cfg.AccountCreationCode...), common.LeftPadBytes(owner.Bytes(), 32)...)
+	return crypto.CreateAddress2(a.cfg.Factory, common.BigToHash(a.cfg.Salt), crypto.Keccak256(initCode))
+}
+
+// BuildSweep returns a signed user operation that moves amount of token out of the account owned by
+// ownerKey to destination, deploying the account first if needed. A nil amount sweeps the whole balance,
+// which is only possible when a paymaster covers the gas.
+func (a *Accounts) BuildSweep(ctx context.Context, ownerKey *ecdsa.PrivateKey, token, destination common.Address, amount *big.Int, gas Gas, paymaster *Paymaster) (*UserOperation, error) {
+	owner := crypto.PubkeyToAddress(ownerKey.PublicKey)
+	account := a.AccountAddress(owner)
+	log.Printf("Building sweep user operation for account %s\n", account.Hex())
+
+	if amount == nil && paymaster == nil && token == privacy.NativeToken {
+		// The whole balance would leave nothing to prefund the EntryPoint, so validation always fails
+		return nil, ErrNeedsPaymaster
+	}
+	if amount == nil {
+		balance, err := a.balance(ctx, token, account)
+		if err != nil {
+			return nil, err
+		}
+		amount = balance
+	}
+	if amount.Sign() <= 0 {
+		return nil, ErrNothingToSweep
+	}
+	callData, err := SweepCallData(token, destination, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	op := &UserOperation{
+		Sender:               account,
+		CallData:             callData,
+		CallGasLimit:         orDefault(gas.CallGasLimit, DefaultCallGasLimit),
+		VerificationGasLimit: orDefault(gas.VerificationGasLimit, DefaultVerificationGasLimit),
+		PreVerificationGas:   orDefault(gas.PreVerificationGas, DefaultPreVerificationGas),
+		MaxFeePerGas:         orDefault(gas.MaxFeePerGas, new(big.Int)),
+		MaxPriorityFeePerGas: orDefault(gas.MaxPriorityFeePerGas, new(big.Int)),
+		Paymaster:            paymaster,
+	}
+	if err := op.checkPackedFields(); err != nil {
+		return nil, err
+	}
+
+	code, err := a.backend.CodeAt(ctx, account, nil)
+	if err != nil {
+		return nil, fmt.Errorf("reading account code: %w", err)
+	}
+	if len(code) == 0 {
+		factory := a.cfg.Factory
+		op.Factory = &factory
+		if op.FactoryData, err = factoryABI.Pack("createAccount", owner, a.cfg.Salt); err != nil {
+			return nil, err
+		}
+	}
+	if op.Nonce, err = a.nonce(ctx, account); err != nil {
+		return nil, err
+	}
+
+	if err := op.Sign(ownerKey, a.cfg.EntryPoint, a.cfg.ChainID); err != nil {
+		return nil, err
+	}
+	log.Printf("Signed user operation %s\n", op.Hash(a.cfg.EntryPoint, a.cfg.ChainID).Hex())
+	return op, nil
+}
+
+// HandleOpsCallData returns the EntryPoint calldata a bundler submits for ops, paying gas refunds to beneficiary.
+func HandleOpsCallData(ops []*UserOperation, beneficiary common.Address) ([]byte, error) {
+	packed := make([]packedUserOperation, len(ops))
+	for i, op := range ops {
+		packed[i] = op.pack()
+	}
+	return entryPointABI.Pack("handleOps", packed, beneficiary)
+}
+
+// SweepCallData returns the account calldata that moves amount of token (or ETH for privacy.NativeToken) to destination.
+func SweepCallData(token, destination common.Address, amount *big.Int) ([]byte, error) {
+	if token == privacy.NativeToken {
+		return accountABI.Pack("execute", destination, amount, []byte{})
+	}
+	transfer, err := erc20ABI.Pack("transfer", destination, amount)
+	if err != nil {
+		return nil, err
+	}
+	return accountABI.Pack("execute", token, new(big.Int), transfer)
+}
+
+// nonce reads the account's next sequence number for nonce key 0 from the EntryPoint.
+func (a *Accounts) nonce(ctx context.Context, account common.Address) (*big.Int, error) {
+	data, err := entryPointABI.Pack("getNonce", account, new(big.Int))
+	if err != nil {
+		return nil, err
+	}
+	result, err := a.backend.CallContract(ctx, ethereum.CallMsg{To: &a.cfg.EntryPoint, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("reading account nonce: %w", err)
+	}
+	values, err := entryPointABI.Unpack("getNonce", result)
+	if err != nil {
+		return nil, err
+	}
+	return values[0].(*big.Int), nil
+}
+
+func (a *Accounts) balance(ctx context.Context, token, account common.Address) (*big.Int, error) {
+	if token == privacy.NativeToken {
+		return a.backend.BalanceAt(ctx, account, nil)
+	}
+	data, err := erc20ABI.Pack("balanceOf", account)
+	if err != nil {
+		return nil, err
+	}
+	result, err := a.backend.CallContract(ctx, ethereum.CallMsg{To: &token, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("reading token balance: %w", err)
+	}
+	values, err := erc20ABI.Unpack("balanceOf", result)
+	if err != nil {
+		return nil, err
+	}
+	return values[0].(*big.Int), nil
+}
+
+func orDefault(value, fallback *big.Int) *big.Int {
+	if value == nil || value.Sign() == 0 {
+		return new(big.Int).Set(fallback)
+	}
+	return new(big.Int).Set(value)
+}
+
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
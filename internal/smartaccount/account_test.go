@@ -0,0 +1,203 @@
+package smartaccount
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/prikshit/blockchain-privacy-module/internal/chain"
+	"github.com/prikshit/blockchain-privacy-module/internal/evmtest"
+	"github.com/prikshit/blockchain-privacy-module/internal/privacy"
+	"github.com/prikshit/blockchain-privacy-module/internal/sanctions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	factory     = common.HexToAddress("0x000000000000000000000000000000000000fac7")
+	paymaster   = common.HexToAddress("0x000000000000000000000000000000000000ba11")
+	destination = common.HexToAddress("0x000000000000000000000000000000000000de57")
+)
+
+type testChain struct {
+	backend  *simulated.Backend
+	bundler  *chain.Sender
+	accounts *Accounts
+}
+
+// newTestChain deploys the EntryPoint, an account factory and a sponsoring paymaster, and funds a bundler.
+func newTestChain(t *testing.T) *testChain {
+	bundlerKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	creationCode := evmtest.SmartAccountCreationCode(DefaultEntryPoint)
+	backend := simulated.NewBackend(types.GenesisAlloc{
+		crypto.PubkeyToAddress(bundlerKey.PublicKey): {Balance: big.NewInt(params.Ether)},
+		DefaultEntryPoint: evmtest.EntryPoint(),
+		factory:           evmtest.AccountFactory(creationCode),
+		paymaster:         evmtest.SponsoringPaymaster(),
+	})
+	t.Cleanup(func() { backend.Close() })
+
+	bundler, err := chain.NewSender(context.Background(), backend.Client(), bundlerKey)
+	require.NoError(t, err)
+	accounts, err := NewAccounts(Config{
+		Factory:             factory,
+		AccountCreationCode: creationCode,
+		ChainID:             bundler.ChainID(),
+	}, backend.Client())
+	require.NoError(t, err)
+	return &testChain{backend: backend, bundler: bundler, accounts: accounts}
+}
+
+// submit sends ops to the EntryPoint from the bundler and returns the mined receipt.
+func (tc *testChain) submit(t *testing.T, ops ...*UserOperation) (*types.Receipt, error) {
+	data, err := HandleOpsCallData(ops, tc.bundler.Address())
+	require.NoError(t, err)
+	tx, err := tc.bundler.Send(context.Background(), DefaultEntryPoint, nil, data)
+	if err != nil {
+		return nil, err
+	}
+	tc.backend.Commit()
+	return tc.backend.Client().TransactionReceipt(context.Background(), tx.Hash())
+}
+
+// stealthOwner pays a fresh stealth meta-address and returns the recovered stealth key.
+func stealthOwner(t *testing.T) *ecdsa.PrivateKey {
+	pm := privacy.NewPrivacyManager(sanctions.NewDetector(nil))
+	spendingKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	viewingKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	keys := &privacy.StealthKeys{SpendingKey: spendingKey, ViewingKey: viewingKey}
+
+	_, ann, err := pm.GenerateStealthPayment(keys.MetaAddress())
+	require.NoError(t, err)
+	ownerKey, err := pm.RecoverLabelledStealthPrivateKey(keys, "", ann)
+	require.NoError(t, err)
+	return ownerKey
+}
+
+func sponsor() *Paymaster {
+	return &Paymaster{
+		Address:                       paymaster,
+		PaymasterVerificationGasLimit: big.NewInt(50_000),
+		PaymasterPostOpGasLimit:       big.NewInt(0),
+	}
+}
+
+func TestSweepDeploysCounterfactualAccount(t *testing.T) {
+	tc := newTestChain(t)
+	ctx := context.Background()
+	ownerKey := stealthOwner(t)
+	account := tc.accounts.AccountAddress(crypto.PubkeyToAddress(ownerKey.PublicKey))
+
+	// The payer sends to the account before it exists
+	_, err := tc.bundler.Send(ctx, account, big.NewInt(params.Ether/10), nil)
+	require.NoError(t, err)
+	tc.backend.Commit()
+
+	op, err := tc.accounts.BuildSweep(ctx, ownerKey, privacy.NativeToken, destination, nil, Gas{}, sponsor())
+	require.NoError(t, err)
+	require.NotNil(t, op.Factory)
+	assert.Equal(t, int64(0), op.Nonce.Int64())
+
+	receipt, err := tc.submit(t, op)
+	require.NoError(t, err)
+	require.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+
+	code, err := tc.backend.Client().CodeAt(ctx, account, nil)
+	require.NoError(t, err)
+	assert.NotEmpty(t, code)
+	swept, err := tc.backend.Client().BalanceAt(ctx, destination, nil)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(params.Ether/10), swept)
+	sponsored, err := tc.backend.Client().StorageAt(ctx, paymaster, common.Hash{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), new(big.Int).SetBytes(sponsored).Int64())
+
+	// Later sweeps use the deployed account and the next nonce
+	_, err = tc.bundler.Send(ctx, account, big.NewInt(1000), nil)
+	require.NoError(t, err)
+	tc.backend.Commit()
+	op, err = tc.accounts.BuildSweep(ctx, ownerKey, privacy.NativeToken, destination, nil, Gas{}, sponsor())
+	require.NoError(t, err)
+	assert.Nil(t, op.Factory)
+	assert.Equal(t, int64(1), op.Nonce.Int64())
+	receipt, err = tc.submit(t, op)
+	require.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+}
+
+func TestSweepRejectsForeignSignature(t *testing.T) {
+	tc := newTestChain(t)
+	ctx := context.Background()
+	ownerKey := stealthOwner(t)
+	account := tc.accounts.AccountAddress(crypto.PubkeyToAddress(ownerKey.PublicKey))
+	_, err := tc.bundler.Send(ctx, account, big.NewInt(params.Ether/10), nil)
+	require.NoError(t, err)
+	tc.backend.Commit()
+
+	op, err := tc.accounts.BuildSweep(ctx, ownerKey, privacy.NativeToken, destination, big.NewInt(1000), Gas{}, sponsor())
+	require.NoError(t, err)
+	otherKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	require.NoError(t, op.Sign(otherKey, tc.accounts.EntryPoint(), tc.bundler.ChainID()))
+
+	_, err = tc.submit(t, op)
+	assert.Error(t, err)
+	swept, err := tc.backend.Client().BalanceAt(ctx, destination, nil)
+	require.NoError(t, err)
+	assert.Zero(t, swept.Sign())
+}
+
+func TestBuildSweepRejectsEmptyAccount(t *testing.T) {
+	tc := newTestChain(t)
+	_, err := tc.accounts.BuildSweep(context.Background(), stealthOwner(t), privacy.NativeToken, destination, nil, Gas{}, sponsor())
+	assert.ErrorIs(t, err, ErrNothingToSweep)
+
+	// Without a paymaster, sweeping the whole ETH balance leaves nothing for the gas
+	_, err = tc.accounts.BuildSweep(context.Background(), stealthOwner(t), privacy.NativeToken, destination, nil, Gas{MaxFeePerGas: big.NewInt(1)}, nil)
+	assert.ErrorIs(t, err, ErrNeedsPaymaster)
+}
+
+func TestBuildSweepRejectsOversizedGasValues(t *testing.T) {
+	tc := newTestChain(t)
+	maxUint128 := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+	tooLarge := new(big.Int).Lsh(big.NewInt(1), 128)
+
+	_, err := tc.accounts.BuildSweep(context.Background(), stealthOwner(t), privacy.NativeToken, destination, big.NewInt(1), Gas{MaxFeePerGas: tooLarge}, sponsor())
+	assert.ErrorIs(t, err, ErrValueTooLarge)
+
+	paymaster := sponsor()
+	paymaster.PaymasterPostOpGasLimit = tooLarge
+	_, err = tc.accounts.BuildSweep(context.Background(), stealthOwner(t), privacy.NativeToken, destination, big.NewInt(1), Gas{}, paymaster)
+	assert.ErrorIs(t, err, ErrValueTooLarge)
+
+	_, err = tc.accounts.BuildSweep(context.Background(), stealthOwner(t), privacy.NativeToken, destination, big.NewInt(1), Gas{MaxPriorityFeePerGas: maxUint128}, sponsor())
+	assert.NoError(t, err)
+}
+
+func TestUserOperationHashCoversPaymaster(t *testing.T) {
+	op := &UserOperation{
+		Sender:               common.HexToAddress("0x1"),
+		Nonce:                big.NewInt(0),
+		CallGasLimit:         DefaultCallGasLimit,
+		VerificationGasLimit: DefaultVerificationGasLimit,
+		PreVerificationGas:   DefaultPreVerificationGas,
+		MaxFeePerGas:         big.NewInt(2),
+		MaxPriorityFeePerGas: big.NewInt(1),
+	}
+	chainID := big.NewInt(1)
+	unsponsored := op.Hash(DefaultEntryPoint, chainID)
+	op.Paymaster = sponsor()
+	assert.NotEqual(t, unsponsored, op.Hash(DefaultEntryPoint, chainID))
+	assert.Len(t, op.PaymasterAndData(), 20+16+16)
+	assert.NotEqual(t, op.Hash(DefaultEntryPoint, chainID), op.Hash(DefaultEntryPoint, big.NewInt(2)))
+}
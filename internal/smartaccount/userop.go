@@ -0,0 +1,144 @@
+package smartaccount
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// UserOperation is an ERC-4337 v0.7 user operation in its unpacked form, as bundlers accept it over RPC.
+type UserOperation struct {
+	Sender               common.Address
+	Nonce                *big.Int
+	Factory              *common.Address // nil once the account is deployed
+	FactoryData          []byte
+	CallData             []byte
+	CallGasLimit         *big.Int
+	VerificationGasLimit *big.Int
+	PreVerificationGas   *big.Int
+	MaxFeePerGas         *big.Int
+	MaxPriorityFeePerGas *big.Int
+	Paymaster            *Paymaster // nil when the account pays its own gas
+	Signature            []byte
+}
+
+// Paymaster sponsors the gas of a user operation.
+type Paymaster struct {
+	Address                       common.Address
+	PaymasterVerificationGasLimit *big.Int
+	PaymasterPostOpGasLimit       *big.Int
+	PaymasterData                 []byte
+}
+
+// packedUserOperation is the PackedUserOperation struct the EntryPoint takes.
+type packedUserOperation struct {
+	Sender             common.Address
+	Nonce              *big.Int
+	InitCode           []byte
+	CallData           []byte
+	AccountGasLimits   [32]byte
+	PreVerificationGas *big.Int
+	GasFees            [32]byte
+	PaymasterAndData   []byte
+	Signature          []byte
+}
+
+// InitCode returns factory || factoryData, or nothing for a deployed account.
+func (op *UserOperation) InitCode() []byte {
+	if op.Factory == nil {
+		return nil
+	}
+	return append(op.Factory.Bytes(), op.FactoryData...)
+}
+
+// PaymasterAndData returns paymaster || verificationGasLimit || postOpGasLimit || paymasterData,
+// with both gas limits as 16-byte integers, or nothing without a paymaster.
+func (op *UserOperation) PaymasterAndData() []byte {
+	if op.Paymaster == nil {
+		return nil
+	}
+	p := op.Paymaster
+	data := append(p.Address.Bytes(), packUint128s(p.PaymasterVerificationGasLimit, p.PaymasterPostOpGasLimit)...)
+	return append(data, p.PaymasterData...)
+}
+
+// Hash returns the userOpHash the account verifies the signature against:
+// keccak256(abi.encode(keccak256(packed fields), entryPoint, chainId)).
+func (op *UserOperation) Hash(entryPoint common.Address, chainID *big.Int) common.Hash {
+	packed := op.pack()
+	inner := crypto.Keccak256(
+		common.LeftPadBytes(packed.Sender.Bytes(), 32),
+		math.U256Bytes(new(big.Int).Set(packed.Nonce)),
+		crypto.Keccak256(packed.InitCode),
+		crypto.Keccak256(packed.CallData),
+		packed.AccountGasLimits[:],
+		math.U256Bytes(new(big.Int).Set(packed.PreVerificationGas)),
+		packed.GasFees[:],
+		crypto.Keccak256(packed.PaymasterAndData),
+	)
+	return crypto.Keccak256Hash(inner, common.LeftPadBytes(entryPoint.Bytes(), 32), math.U256Bytes(new(big.Int).Set(chainID)))
+}
+
+// Sign signs the EIP-191 hash of the userOpHash with the account owner's key, as SimpleAccount expects.
+func (op *UserOperation) Sign(owner *ecdsa.PrivateKey, entryPoint common.Address, chainID *big.Int) error {
+	hash := op.Hash(entryPoint, chainID)
+	signature, err := crypto.Sign(accounts.TextHash(hash.Bytes()), owner)
+	if err != nil {
+		return err
+	}
+	signature[crypto.RecoveryIDOffset] += 27
+	op.Signature = signature
+	return nil
+}
+
+func (op *UserOperation) pack() packedUserOperation {
+	packed := packedUserOperation{
+		Sender:             op.Sender,
+		Nonce:              op.Nonce,
+		InitCode:           op.InitCode(),
+		CallData:           op.CallData,
+		PreVerificationGas: op.PreVerificationGas,
+		PaymasterAndData:   op.PaymasterAndData(),
+		Signature:          op.Signature,
+	}
+	copy(packed.AccountGasLimits[:], packUint128s(op.VerificationGasLimit, op.CallGasLimit))
+	copy(packed.GasFees[:], packUint128s(op.MaxPriorityFeePerGas, op.MaxFeePerGas))
+	return packed
+}
+
+// checkPackedFields checks that the gas limits and fees packed in pairs fit in their 16 bytes, since a larger
+// value would be cut to its low bytes and sign a different operation than the one asked for.
+func (op *UserOperation) checkPackedFields() error {
+	type field struct {
+		name  string
+		value *big.Int
+	}
+	fields := []field{
+		{"call gas limit", op.CallGasLimit},
+		{"verification gas limit", op.VerificationGasLimit},
+		{"max fee per gas", op.MaxFeePerGas},
+		{"max priority fee per gas", op.MaxPriorityFeePerGas},
+	}
+	if p := op.Paymaster; p != nil {
+		fields = append(fields,
+			field{"paymaster verification gas limit", p.PaymasterVerificationGasLimit},
+			field{"paymaster post-op gas limit", p.PaymasterPostOpGasLimit},
+		)
+	}
+	for _, f := range fields {
+		if f.value.Sign() < 0 || f.value.BitLen() > 128 {
+			return fmt.Errorf("%w: %s %s", ErrValueTooLarge, f.name, f.value)
+		}
+	}
+	return nil
+}
+
+// packUint128s concatenates high and low as two 16-byte big-endian integers.
+func packUint128s(high, low *big.Int) []byte {
+	return append(common.LeftPadBytes(high.Bytes(), 16), common.LeftPadBytes(low.Bytes(), 16)...)
+}
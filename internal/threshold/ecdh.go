@@ -0,0 +1,50 @@
+package threshold
+
+import (
+	"crypto/ecdsa"
+	"log"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// PartialECDH is one party's contribution f(Index) * P to a threshold ECDH with point P.
+type PartialECDH struct {
+	Index int
+	Point *ecdsa.PublicKey
+}
+
+// PartialECDH multiplies pub by this party's share. No party learns d * pub on its own.
+func (ks *KeyShare) PartialECDH(pub *ecdsa.PublicKey) *PartialECDH {
+	x, y := crypto.S256().ScalarMult(pub.X, pub.Y, scalarBytes(ks.Value))
+	return &PartialECDH{Index: ks.Index, Point: &ecdsa.PublicKey{Curve: crypto.S256(), X: x, Y: y}}
+}
+
+// CombinePartialECDH interpolates d * P in the exponent from at least threshold partial results.
+func CombinePartialECDH(partials []*PartialECDH, threshold int) (*ecdsa.PublicKey, error) {
+	if len(partials) < threshold {
+		return nil, ErrNotEnoughShares
+	}
+
+	indices := make([]int, len(partials))
+	for i, p := range partials {
+		indices[i] = p.Index
+	}
+	if err := checkIndices(indices); err != nil {
+		return nil, err
+	}
+
+	curve := crypto.S256()
+	var x, y *big.Int
+	for _, p := range partials {
+		px, py := curve.ScalarMult(p.Point.X, p.Point.Y, scalarBytes(lagrangeAtZero(p.Index, indices)))
+		if x == nil {
+			x, y = px, py
+		} else {
+			x, y = curve.Add(x, y, px, py)
+		}
+	}
+
+	log.Printf("Combined %d partial ECDH results\n", len(partials))
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
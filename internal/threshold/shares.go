@@ -0,0 +1,189 @@
+package threshold
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// MaxParties caps a sharing, as backup.Split does; each share costs a scalar multiplication to check.
+const MaxParties = 255
+
+var (
+	ErrInvalidThreshold = errors.New("threshold must satisfy 1 < threshold <= parties <= 255")
+	ErrNotEnoughShares  = errors.New("not enough shares")
+	ErrDuplicateShare   = errors.New("duplicate share index")
+)
+
+// KeyShare is one party's Shamir share f(Index) of a secp256k1 private key.
+// PublicKey is the group public key, known to every party.
+type KeyShare struct {
+	Index     int
+	Threshold int
+	Value     *big.Int
+	PublicKey *ecdsa.PublicKey
+}
+
+// PublicShare returns Value * G, which other parties can use to check this share.
+func (ks *KeyShare) PublicShare() *ecdsa.PublicKey {
+	x, y := crypto.S256().ScalarBaseMult(scalarBytes(ks.Value))
+	return &ecdsa.PublicKey{Curve: crypto.S256(), X: x, Y: y}
+}
+
+// Tweak returns the share of (d + t) mod n, the constant term shifted by t, with the matching group key.
+// Adding the same public scalar to every share shifts the shared secret without any interaction.
+func (ks *KeyShare) Tweak(t *big.Int) *KeyShare {
+	n := crypto.S256().Params().N
+	value := new(big.Int).Add(ks.Value, t)
+	value.Mod(value, n)
+
+	tGx, tGy := crypto.S256().ScalarBaseMult(scalarBytes(new(big.Int).Mod(t, n)))
+	x, y := crypto.S256().Add(ks.PublicKey.X, ks.PublicKey.Y, tGx, tGy)
+
+	return &KeyShare{
+		Index:     ks.Index,
+		Threshold: ks.Threshold,
+		Value:     value,
+		PublicKey: &ecdsa.PublicKey{Curve: crypto.S256(), X: x, Y: y},
+	}
+}
+
+// SplitPrivateKey deals a threshold-of-parties Shamir sharing of key. The dealer must erase key afterwards.
+func SplitPrivateKey(key *ecdsa.PrivateKey, threshold, parties int) ([]*KeyShare, error) {
+	if threshold < 2 || threshold > parties || parties > MaxParties {
+		return nil, ErrInvalidThreshold
+	}
+	log.Printf("Splitting private key into %d-of-%d shares\n", threshold, parties)
+
+	poly, err := randomPolynomial(key.D, threshold-1)
+	if err != nil {
+		return nil, err
+	}
+
+	shares := make([]*KeyShare, parties)
+	for i := range shares {
+		shares[i] = &KeyShare{
+			Index:     i + 1,
+			Threshold: threshold,
+			Value:     evaluate(poly, i+1),
+			PublicKey: &key.PublicKey,
+		}
+	}
+	return shares, nil
+}
+
+// CombineShares reconstructs the private key from at least Threshold shares.
+// It exists for recovery drills; the threshold protocols never call it.
+func CombineShares(shares []*KeyShare) (*ecdsa.PrivateKey, error) {
+	if len(shares) == 0 || len(shares) < shares[0].Threshold {
+		return nil, ErrNotEnoughShares
+	}
+
+	indices := make([]int, len(shares))
+	for i, s := range shares {
+		indices[i] = s.Index
+	}
+	if err := checkIndices(indices); err != nil {
+		return nil, err
+	}
+
+	n := crypto.S256().Params().N
+	secret := new(big.Int)
+	for _, s := range shares {
+		term := new(big.Int).Mul(s.Value, lagrangeAtZero(s.Index, indices))
+		secret.Add(secret, term)
+	}
+	secret.Mod(secret, n)
+
+	key, err := crypto.ToECDSA(scalarBytes(secret))
+	if err != nil {
+		return nil, err
+	}
+	if key.PublicKey.X.Cmp(shares[0].PublicKey.X) != 0 || key.PublicKey.Y.Cmp(shares[0].PublicKey.Y) != 0 {
+		return nil, fmt.Errorf("reconstructed key does not match the group public key")
+	}
+	return key, nil
+}
+
+// randomPolynomial returns coefficients of a random polynomial of the given degree with f(0) = constant.
+func randomPolynomial(constant *big.Int, degree int) ([]*big.Int, error) {
+	n := crypto.S256().Params().N
+	poly := make([]*big.Int, degree+1)
+	poly[0] = new(big.Int).Mod(constant, n)
+	for i := 1; i <= degree; i++ {
+		c, err := rand.Int(rand.Reader, n)
+		if err != nil {
+			return nil, err
+		}
+		poly[i] = c
+	}
+	return poly, nil
+}
+
+// evaluate computes f(x) mod n using Horner's rule.
+func evaluate(poly []*big.Int, x int) *big.Int {
+	n := crypto.S256().Params().N
+	bx := big.NewInt(int64(x))
+	result := new(big.Int)
+	for i := len(poly) - 1; i >= 0; i-- {
+		result.Mul(result, bx)
+		result.Add(result, poly[i])
+		result.Mod(result, n)
+	}
+	return result
+}
+
+// lagrangeAtZero returns the Lagrange coefficient of index i for interpolating f(0) from indices.
+func lagrangeAtZero(i int, indices []int) *big.Int {
+	n := crypto.S256().Params().N
+	num, den := big.NewInt(1), big.NewInt(1)
+	for _, j := range indices {
+		if j == i {
+			continue
+		}
+		num.Mul(num, big.NewInt(int64(j)))
+		den.Mul(den, big.NewInt(int64(j-i)))
+	}
+	den.Mod(den, n)
+	num.Mul(num, den.ModInverse(den, n))
+	return num.Mod(num, n)
+}
+
+// interpolateAtZero reconstructs f(0) from the values f(i) keyed by index.
+func interpolateAtZero(values map[int]*big.Int) *big.Int {
+	indices := make([]int, 0, len(values))
+	for i := range values {
+		indices = append(indices, i)
+	}
+
+	n := crypto.S256().Params().N
+	result := new(big.Int)
+	for i, v := range values {
+		result.Add(result, new(big.Int).Mul(v, lagrangeAtZero(i, indices)))
+	}
+	return result.Mod(result, n)
+}
+
+func checkIndices(indices []int) error {
+	seen := make(map[int]bool, len(indices))
+	for _, i := range indices {
+		if i < 1 {
+			return fmt.Errorf("invalid share index %d", i)
+		}
+		if seen[i] {
+			return fmt.Errorf("%w: %d", ErrDuplicateShare, i)
+		}
+		seen[i] = true
+	}
+	return nil
+}
+
+func scalarBytes(v *big.Int) []byte {
+	b := make([]byte, 32)
+	return v.FillBytes(b)
+}
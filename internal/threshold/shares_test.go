@@ -0,0 +1,67 @@
+package threshold
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitAndCombineShares(t *testing.T) {
+	key, err := ecdsa.GenerateKey(crypto.S256(), rand.Reader)
+	assert.NoError(t, err)
+
+	shares, err := SplitPrivateKey(key, 3, 5)
+	assert.NoError(t, err)
+	assert.Len(t, shares, 5)
+
+	// Any 3 shares reconstruct the key
+	recovered, err := CombineShares([]*KeyShare{shares[4], shares[0], shares[2]})
+	assert.NoError(t, err)
+	assert.Equal(t, key.D, recovered.D)
+
+	// 2 shares are not enough
+	_, err = CombineShares(shares[:2])
+	assert.ErrorIs(t, err, ErrNotEnoughShares)
+
+	// The same share twice is rejected
+	_, err = CombineShares([]*KeyShare{shares[0], shares[0], shares[1]})
+	assert.ErrorIs(t, err, ErrDuplicateShare)
+
+	_, err = SplitPrivateKey(key, 1, 5)
+	assert.ErrorIs(t, err, ErrInvalidThreshold)
+	_, err = SplitPrivateKey(key, 6, 5)
+	assert.ErrorIs(t, err, ErrInvalidThreshold)
+	_, err = SplitPrivateKey(key, 2, MaxParties+1)
+	assert.ErrorIs(t, err, ErrInvalidThreshold)
+}
+
+func TestTweakAndPartialECDH(t *testing.T) {
+	key, err := ecdsa.GenerateKey(crypto.S256(), rand.Reader)
+	assert.NoError(t, err)
+	shares, err := SplitPrivateKey(key, 2, 3)
+	assert.NoError(t, err)
+
+	// Tweaking every share tweaks the shared key
+	tweak := big.NewInt(12345)
+	tweaked := []*KeyShare{shares[0].Tweak(tweak), shares[2].Tweak(tweak)}
+	recovered, err := CombineShares(tweaked)
+	assert.NoError(t, err)
+	expected := new(big.Int).Add(key.D, tweak)
+	assert.Equal(t, expected.Mod(expected, crypto.S256().Params().N), recovered.D)
+
+	// Partial ECDH results combine to d * P
+	other, err := ecdsa.GenerateKey(crypto.S256(), rand.Reader)
+	assert.NoError(t, err)
+	point, err := CombinePartialECDH([]*PartialECDH{shares[1].PartialECDH(&other.PublicKey), shares[2].PartialECDH(&other.PublicKey)}, 2)
+	assert.NoError(t, err)
+	x, y := crypto.S256().ScalarMult(other.PublicKey.X, other.PublicKey.Y, key.D.Bytes())
+	assert.Equal(t, x, point.X)
+	assert.Equal(t, y, point.Y)
+
+	_, err = CombinePartialECDH([]*PartialECDH{shares[1].PartialECDH(&other.PublicKey)}, 2)
+	assert.ErrorIs(t, err, ErrNotEnoughShares)
+}
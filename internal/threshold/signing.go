@@ -0,0 +1,268 @@
+package threshold
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// The signing protocol follows Gennaro, Jarecki, Krawczyk and Rabin's threshold DSS for honest-but-curious
+// parties. The nonce k and a blinding factor gamma are shared jointly, so no party ever knows k or the key.
+// Products of two degree t-1 sharings have degree 2t-2, hence a session needs at least 2*threshold-1 signers.
+
+var (
+	ErrNotEnoughSigners = errors.New("signing needs at least 2*threshold-1 signers")
+	ErrUnexpectedSigner = errors.New("message from a party outside the signing set")
+	ErrInvalidSignature = errors.New("combined signature does not verify")
+)
+
+// DealMessage is sent privately from one signer to another in round 1.
+type DealMessage struct {
+	From, To int
+	K        *big.Int // Share of the sender's nonce contribution
+	Gamma    *big.Int // Share of the sender's blinding contribution
+	ZeroW    *big.Int // Share of zero masking the k*gamma product
+	ZeroS    *big.Int // Share of zero masking the signature share
+}
+
+// NonceCommitment is broadcast in round 1: the sender's contribution to R = k * G.
+type NonceCommitment struct {
+	From  int
+	Point *ecdsa.PublicKey
+}
+
+// MaskedProduct is broadcast in round 2: a share of k * gamma.
+type MaskedProduct struct {
+	From  int
+	Value *big.Int
+}
+
+// SignatureShare is broadcast in round 3: a share of the ECDSA s value.
+type SignatureShare struct {
+	From  int
+	Value *big.Int
+}
+
+// SigningParty holds one signer's state for signing a single hash.
+type SigningParty struct {
+	share   *KeyShare
+	signers []int
+	hash    []byte
+
+	deals        []*DealMessage
+	k, gamma     *big.Int
+	zeroW, zeroS *big.Int
+	r            *big.Int
+	rPoint       *ecdsa.PublicKey
+}
+
+// NewSigningParty prepares a signer holding share to sign hash together with the given signer indices.
+func NewSigningParty(share *KeyShare, signers []int, hash []byte) (*SigningParty, error) {
+	if len(hash) != 32 {
+		return nil, fmt.Errorf("hash must be 32 bytes, got %d", len(hash))
+	}
+	if err := checkIndices(signers); err != nil {
+		return nil, err
+	}
+	if len(signers) < 2*share.Threshold-1 {
+		return nil, ErrNotEnoughSigners
+	}
+	if !slices.Contains(signers, share.Index) {
+		return nil, fmt.Errorf("%w: %d", ErrUnexpectedSigner, share.Index)
+	}
+	return &SigningParty{share: share, signers: slices.Clone(signers), hash: slices.Clone(hash)}, nil
+}
+
+// Round1 deals fresh random sharings of a nonce contribution, a blinding factor and two zero masks,
+// returning one private message per signer (including this party) and the public nonce commitment.
+func (p *SigningParty) Round1() ([]*DealMessage, *NonceCommitment, error) {
+	t := p.share.Threshold
+
+	kContribution, err := randomScalar()
+	if err != nil {
+		return nil, nil, err
+	}
+	gammaContribution, err := randomScalar()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kPoly, err := randomPolynomial(kContribution, t-1)
+	if err != nil {
+		return nil, nil, err
+	}
+	gammaPoly, err := randomPolynomial(gammaContribution, t-1)
+	if err != nil {
+		return nil, nil, err
+	}
+	zeroWPoly, err := randomPolynomial(big.NewInt(0), 2*t-2)
+	if err != nil {
+		return nil, nil, err
+	}
+	zeroSPoly, err := randomPolynomial(big.NewInt(0), 2*t-2)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	deals := make([]*DealMessage, 0, len(p.signers))
+	for _, j := range p.signers {
+		deals = append(deals, &DealMessage{
+			From:  p.share.Index,
+			To:    j,
+			K:     evaluate(kPoly, j),
+			Gamma: evaluate(gammaPoly, j),
+			ZeroW: evaluate(zeroWPoly, j),
+			ZeroS: evaluate(zeroSPoly, j),
+		})
+	}
+
+	x, y := crypto.S256().ScalarBaseMult(scalarBytes(kPoly[0]))
+	commitment := &NonceCommitment{From: p.share.Index, Point: &ecdsa.PublicKey{Curve: crypto.S256(), X: x, Y: y}}
+
+	log.Printf("Signer %d completed round 1\n", p.share.Index)
+	return deals, commitment, nil
+}
+
+// Round2 aggregates the deals addressed to this party and the nonce commitments, fixing R,
+// and returns this party's masked share of k * gamma.
+func (p *SigningParty) Round2(deals []*DealMessage, commitments []*NonceCommitment) (*MaskedProduct, error) {
+	if err := p.checkSenders(len(deals), func(i int) int { return deals[i].From }); err != nil {
+		return nil, err
+	}
+	if err := p.checkSenders(len(commitments), func(i int) int { return commitments[i].From }); err != nil {
+		return nil, err
+	}
+
+	n := crypto.S256().Params().N
+	p.k, p.gamma, p.zeroW, p.zeroS = new(big.Int), new(big.Int), new(big.Int), new(big.Int)
+	for _, d := range deals {
+		if d.To != p.share.Index {
+			return nil, fmt.Errorf("deal from %d is addressed to %d", d.From, d.To)
+		}
+		p.k.Add(p.k, d.K)
+		p.gamma.Add(p.gamma, d.Gamma)
+		p.zeroW.Add(p.zeroW, d.ZeroW)
+		p.zeroS.Add(p.zeroS, d.ZeroS)
+	}
+	p.k.Mod(p.k, n)
+	p.gamma.Mod(p.gamma, n)
+	p.zeroW.Mod(p.zeroW, n)
+	p.zeroS.Mod(p.zeroS, n)
+
+	// R = sum of the nonce contributions, r = R.x mod n
+	curve := crypto.S256()
+	rx, ry := commitments[0].Point.X, commitments[0].Point.Y
+	for _, c := range commitments[1:] {
+		rx, ry = curve.Add(rx, ry, c.Point.X, c.Point.Y)
+	}
+	p.rPoint = &ecdsa.PublicKey{Curve: curve, X: rx, Y: ry}
+	p.r = new(big.Int).Mod(rx, n)
+
+	w := new(big.Int).Mul(p.k, p.gamma)
+	w.Add(w, p.zeroW)
+	w.Mod(w, n)
+
+	log.Printf("Signer %d completed round 2\n", p.share.Index)
+	return &MaskedProduct{From: p.share.Index, Value: w}, nil
+}
+
+// Round3 opens k * gamma from every signer's masked product and returns this party's share of
+// s = k^-1 * (z + r * d), computed as gamma * (k * gamma)^-1 * (z + r * d_i).
+func (p *SigningParty) Round3(products []*MaskedProduct) (*SignatureShare, error) {
+	if err := p.checkSenders(len(products), func(i int) int { return products[i].From }); err != nil {
+		return nil, err
+	}
+
+	n := crypto.S256().Params().N
+	values := make(map[int]*big.Int, len(products))
+	for _, m := range products {
+		values[m.From] = m.Value
+	}
+	mu := interpolateAtZero(values)
+	if mu.Sign() == 0 {
+		return nil, errors.New("degenerate nonce, restart the session")
+	}
+
+	rho := new(big.Int).Mul(p.gamma, new(big.Int).ModInverse(mu, n))
+	z := new(big.Int).SetBytes(p.hash)
+	sum := new(big.Int).Mul(p.r, p.share.Value)
+	sum.Add(sum, z)
+
+	s := new(big.Int).Mul(rho, sum)
+	s.Add(s, p.zeroS)
+	s.Mod(s, n)
+
+	log.Printf("Signer %d completed round 3\n", p.share.Index)
+	return &SignatureShare{From: p.share.Index, Value: s}, nil
+}
+
+// Combine interpolates the signature shares into a 65-byte [R || S || V] Ethereum signature
+// and verifies it against the group public key.
+func (p *SigningParty) Combine(shares []*SignatureShare) ([]byte, error) {
+	if err := p.checkSenders(len(shares), func(i int) int { return shares[i].From }); err != nil {
+		return nil, err
+	}
+
+	values := make(map[int]*big.Int, len(shares))
+	for _, s := range shares {
+		values[s.From] = s.Value
+	}
+	s := interpolateAtZero(values)
+
+	// Normalize to low s as Ethereum requires, flipping the recovery id accordingly
+	n := crypto.S256().Params().N
+	v := byte(p.rPoint.Y.Bit(0))
+	if s.Cmp(new(big.Int).Rsh(n, 1)) > 0 {
+		s.Sub(n, s)
+		v ^= 1
+	}
+
+	sig := make([]byte, crypto.SignatureLength)
+	p.r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:64])
+	sig[crypto.RecoveryIDOffset] = v
+
+	pub, err := crypto.SigToPub(p.hash, sig)
+	if err != nil || pub.X.Cmp(p.share.PublicKey.X) != 0 || pub.Y.Cmp(p.share.PublicKey.Y) != 0 {
+		log.Println("Combined threshold signature failed verification")
+		return nil, ErrInvalidSignature
+	}
+
+	log.Println("Threshold signature combined successfully")
+	return sig, nil
+}
+
+// checkSenders ensures exactly one message arrived from every signer in the session.
+func (p *SigningParty) checkSenders(count int, from func(i int) int) error {
+	if count != len(p.signers) {
+		return fmt.Errorf("expected %d messages, got %d", len(p.signers), count)
+	}
+	seen := make(map[int]bool, count)
+	for i := 0; i < count; i++ {
+		sender := from(i)
+		if !slices.Contains(p.signers, sender) {
+			return fmt.Errorf("%w: %d", ErrUnexpectedSigner, sender)
+		}
+		if seen[sender] {
+			return fmt.Errorf("duplicate message from signer %d", sender)
+		}
+		seen[sender] = true
+	}
+	return nil
+}
+
+// randomScalar returns a uniform scalar in [1, n).
+func randomScalar() (*big.Int, error) {
+	n := crypto.S256().Params().N
+	k, err := rand.Int(rand.Reader, new(big.Int).Sub(n, big.NewInt(1)))
+	if err != nil {
+		return nil, err
+	}
+	return k.Add(k, big.NewInt(1)), nil
+}
@@ -0,0 +1,93 @@
+package threshold
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+// runSigning executes all rounds for the given shares, routing messages as a network would.
+func runSigning(t *testing.T, shares []*KeyShare, hash []byte) []byte {
+	signers := make([]int, len(shares))
+	for i, s := range shares {
+		signers[i] = s.Index
+	}
+
+	parties := make([]*SigningParty, len(shares))
+	for i, s := range shares {
+		p, err := NewSigningParty(s, signers, hash)
+		assert.NoError(t, err)
+		parties[i] = p
+	}
+
+	inbox := make(map[int][]*DealMessage)
+	var commitments []*NonceCommitment
+	for _, p := range parties {
+		deals, commitment, err := p.Round1()
+		assert.NoError(t, err)
+		for _, d := range deals {
+			inbox[d.To] = append(inbox[d.To], d)
+		}
+		commitments = append(commitments, commitment)
+	}
+
+	var products []*MaskedProduct
+	for i, p := range parties {
+		product, err := p.Round2(inbox[signers[i]], commitments)
+		assert.NoError(t, err)
+		products = append(products, product)
+	}
+
+	var sigShares []*SignatureShare
+	for _, p := range parties {
+		share, err := p.Round3(products)
+		assert.NoError(t, err)
+		sigShares = append(sigShares, share)
+	}
+
+	sig, err := parties[0].Combine(sigShares)
+	assert.NoError(t, err)
+	return sig
+}
+
+func TestThresholdSigning(t *testing.T) {
+	key, err := ecdsa.GenerateKey(crypto.S256(), rand.Reader)
+	assert.NoError(t, err)
+	hash := crypto.Keccak256([]byte("spend from stealth address"))
+
+	shares, err := SplitPrivateKey(key, 2, 4)
+	assert.NoError(t, err)
+
+	for _, signers := range [][]*KeyShare{shares[:3], shares[1:], shares} {
+		sig := runSigning(t, signers, hash)
+		pub, err := crypto.SigToPub(hash, sig)
+		assert.NoError(t, err)
+		assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), crypto.PubkeyToAddress(*pub))
+		assert.True(t, crypto.VerifySignature(crypto.CompressPubkey(&key.PublicKey), hash, sig[:64]))
+	}
+}
+
+func TestThresholdSigningRejectsSmallSessions(t *testing.T) {
+	key, err := ecdsa.GenerateKey(crypto.S256(), rand.Reader)
+	assert.NoError(t, err)
+	shares, err := SplitPrivateKey(key, 2, 3)
+	assert.NoError(t, err)
+	hash := crypto.Keccak256([]byte("hash"))
+
+	_, err = NewSigningParty(shares[0], []int{1, 2}, hash)
+	assert.ErrorIs(t, err, ErrNotEnoughSigners)
+
+	_, err = NewSigningParty(shares[0], []int{2, 3, 4}, hash)
+	assert.ErrorIs(t, err, ErrUnexpectedSigner)
+
+	// A round with a message missing is rejected
+	party, err := NewSigningParty(shares[0], []int{1, 2, 3}, hash)
+	assert.NoError(t, err)
+	deals, commitment, err := party.Round1()
+	assert.NoError(t, err)
+	_, err = party.Round2(deals[:1], []*NonceCommitment{commitment})
+	assert.Error(t, err)
+}
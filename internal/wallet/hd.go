@@ -0,0 +1,151 @@
+package wallet
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// HardenedKeyStart is the first BIP-32 hardened child index.
+const HardenedKeyStart uint32 = 0x80000000
+
+// Supported BIP-39 entropy sizes: 128 bits gives 12 words, 256 bits gives 24 words.
+const (
+	MnemonicBits12Words = 128
+	MnemonicBits24Words = 256
+)
+
+var (
+	ErrInvalidMnemonic = errors.New("invalid mnemonic")
+	ErrInvalidSeed     = errors.New("seed produces an invalid key")
+	ErrHardenedIndex   = errors.New("account index must be below the hardened range")
+)
+
+// HDWallet derives Ethereum accounts from a BIP-39 seed using BIP-32/BIP-44.
+type HDWallet struct {
+	master *extendedKey
+}
+
+// Account is a key derived from an HDWallet together with its derivation path.
+type Account struct {
+	Path       accounts.DerivationPath
+	PrivateKey *ecdsa.PrivateKey
+	Address    common.Address
+}
+
+type extendedKey struct {
+	key       *big.Int
+	chainCode []byte
+}
+
+// NewMnemonic generates a new BIP-39 mnemonic with the given entropy size in bits.
+func NewMnemonic(bits int) (string, error) {
+	entropy, err := bip39.NewEntropy(bits)
+	if err != nil {
+		log.Printf("Error generating mnemonic entropy: %v\n", err)
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// NewHDWalletFromMnemonic validates the mnemonic and builds a wallet from its seed and optional passphrase.
+func NewHDWalletFromMnemonic(mnemonic, passphrase string) (*HDWallet, error) {
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, passphrase)
+	if err != nil {
+		log.Printf("Invalid mnemonic: %v\n", err)
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMnemonic, err)
+	}
+	return NewHDWalletFromSeed(seed)
+}
+
+// NewHDWalletFromSeed builds a wallet from a raw BIP-32 seed.
+func NewHDWalletFromSeed(seed []byte) (*HDWallet, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	key := new(big.Int).SetBytes(sum[:32])
+	if key.Sign() == 0 || key.Cmp(crypto.S256().Params().N) >= 0 {
+		return nil, ErrInvalidSeed
+	}
+
+	log.Println("HD wallet master key derived")
+	return &HDWallet{master: &extendedKey{key: key, chainCode: sum[32:]}}, nil
+}
+
+// Derive returns the private key at the given BIP-32 path.
+func (w *HDWallet) Derive(path accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	k := w.master
+	for _, index := range path {
+		child, err := k.child(index)
+		if err != nil {
+			log.Printf("Error deriving child %d of path %s: %v\n", index, path, err)
+			return nil, err
+		}
+		k = child
+	}
+	return crypto.ToECDSA(common.LeftPadBytes(k.key.Bytes(), 32))
+}
+
+// DeriveAccount returns the account at the standard Ethereum path m/44'/60'/0'/0/index.
+// The index is a non-hardened child, so it must be below HardenedKeyStart.
+func (w *HDWallet) DeriveAccount(index uint32) (*Account, error) {
+	if index >= HardenedKeyStart {
+		return nil, ErrHardenedIndex
+	}
+	path := make(accounts.DerivationPath, len(accounts.DefaultBaseDerivationPath))
+	copy(path, accounts.DefaultBaseDerivationPath)
+	path[len(path)-1] = index
+
+	privKey, err := w.Derive(path)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Derived account at %s\n", path)
+	return &Account{
+		Path:       path,
+		PrivateKey: privKey,
+		Address:    crypto.PubkeyToAddress(privKey.PublicKey),
+	}, nil
+}
+
+// child implements BIP-32 private parent key -> private child key derivation.
+func (k *extendedKey) child(index uint32) (*extendedKey, error) {
+	var data []byte
+	if index >= HardenedKeyStart {
+		data = append([]byte{0x00}, common.LeftPadBytes(k.key.Bytes(), 32)...)
+	} else {
+		x, y := crypto.S256().ScalarBaseMult(common.LeftPadBytes(k.key.Bytes(), 32))
+		data = crypto.CompressPubkey(&ecdsa.PublicKey{Curve: crypto.S256(), X: x, Y: y})
+	}
+	data = binary.BigEndian.AppendUint32(data, index)
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	// The spec requires skipping indices whose tweak is out of range; callers see it as an error
+	n := crypto.S256().Params().N
+	tweak := new(big.Int).SetBytes(sum[:32])
+	if tweak.Cmp(n) >= 0 {
+		return nil, ErrInvalidSeed
+	}
+	key := tweak.Add(tweak, k.key)
+	key.Mod(key, n)
+	if key.Sign() == 0 {
+		return nil, ErrInvalidSeed
+	}
+
+	return &extendedKey{key: key, chainCode: sum[32:]}, nil
+}
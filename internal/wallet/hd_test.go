@@ -0,0 +1,79 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBIP32TestVector1(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	w, err := NewHDWalletFromSeed(seed)
+	assert.NoError(t, err)
+
+	vectors := map[string]string{
+		"m":                      "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35",
+		"m/0'":                   "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea",
+		"m/0'/1":                 "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368",
+		"m/0'/1/2'/2/1000000000": "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8",
+	}
+	for pathStr, expected := range vectors {
+		var path accounts.DerivationPath
+		if pathStr != "m" {
+			path, err = accounts.ParseDerivationPath(pathStr)
+			assert.NoError(t, err)
+		}
+		key, err := w.Derive(path)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, hex.EncodeToString(crypto.FromECDSA(key)), pathStr)
+	}
+}
+
+func TestDeriveAccountFromMnemonic(t *testing.T) {
+	// Well-known development mnemonic used by Hardhat and Foundry
+	w, err := NewHDWalletFromMnemonic("test test test test test test test test test test test junk", "")
+	assert.NoError(t, err)
+
+	account, err := w.DeriveAccount(0)
+	assert.NoError(t, err)
+	assert.Equal(t, "m/44'/60'/0'/0/0", account.Path.String())
+	assert.Equal(t, "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266", account.Address.Hex())
+
+	account, err = w.DeriveAccount(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x70997970C51812dc3A010C7d01b50e0d17dc79C8", account.Address.Hex())
+
+	// A passphrase yields a completely different wallet
+	withPassphrase, err := NewHDWalletFromMnemonic("test test test test test test test test test test test junk", "secret")
+	assert.NoError(t, err)
+	other, err := withPassphrase.DeriveAccount(1)
+	assert.NoError(t, err)
+	assert.NotEqual(t, account.Address, other.Address)
+
+	// Hardened indices are not Ethereum account indices
+	_, err = w.DeriveAccount(HardenedKeyStart)
+	assert.ErrorIs(t, err, ErrHardenedIndex)
+}
+
+func TestNewMnemonic(t *testing.T) {
+	mnemonic, err := NewMnemonic(MnemonicBits12Words)
+	assert.NoError(t, err)
+	assert.Len(t, strings.Fields(mnemonic), 12)
+
+	mnemonic, err = NewMnemonic(MnemonicBits24Words)
+	assert.NoError(t, err)
+	assert.Len(t, strings.Fields(mnemonic), 24)
+
+	_, err = NewHDWalletFromMnemonic(mnemonic, "")
+	assert.NoError(t, err)
+
+	_, err = NewMnemonic(100)
+	assert.Error(t, err)
+
+	_, err = NewHDWalletFromMnemonic("test test test test test test test test test test test test", "")
+	assert.ErrorIs(t, err, ErrInvalidMnemonic)
+}
@@ -1,27 +1,170 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/prikshit/blockchain-privacy-module/helpers"
+	"github.com/prikshit/blockchain-privacy-module/internal/announcer"
+	"github.com/prikshit/blockchain-privacy-module/internal/chain"
+	"github.com/prikshit/blockchain-privacy-module/internal/ephemeral"
+	"github.com/prikshit/blockchain-privacy-module/internal/escrow"
+	"github.com/prikshit/blockchain-privacy-module/internal/gasrelay"
+	"github.com/prikshit/blockchain-privacy-module/internal/listwatch"
 	"github.com/prikshit/blockchain-privacy-module/internal/privacy"
 	"github.com/prikshit/blockchain-privacy-module/internal/sanctions"
+	"github.com/prikshit/blockchain-privacy-module/internal/smartaccount"
 	"github.com/prikshit/blockchain-privacy-module/server"
 )
 
 func main() {
-	// Initialize list of sanctioned addresses
-	initialAddresses := []string{"0xAbc123", "0xDef456"}
-	log.Printf("Initializing sanctions detector with %d initial addresses\n", len(initialAddresses))
+	// Sanctions lists are kept on disk when a store is configured, so manual listings survive restarts
+	var detector *sanctions.Detector
+	if path := os.Getenv("SANCTIONS_STORE"); path != "" {
+		store, err := sanctions.OpenBoltStore(path)
+		if err != nil {
+			log.Fatal("Error opening sanctions store: ", err)
+		}
+		detector, err = sanctions.LoadDetector(store)
+		if err != nil {
+			log.Fatal("Error loading sanctions store: ", err)
+		}
+		defer detector.Close()
+	} else {
+		log.Println("SANCTIONS_STORE not set; sanctions lists are only kept until restart")
+		detector = sanctions.NewDetector(nil)
+	}
+
+	// List files are watched and reloaded when compliance drops a new version. A version that fails
+	// to load keeps the previous one, so startup only fails if a list has never loaded.
+	var watcher *listwatch.Watcher
+	if sources, err := listSources(); err != nil {
+		log.Fatal("Error parsing SANCTIONS_LIST_FILES: ", err)
+	} else if len(sources) > 0 {
+		watcher, err = listwatch.NewWatcher(detector, sources...)
+		if err != nil {
+			log.Fatal("Error watching sanctions list files: ", err)
+		}
+		for _, event := range watcher.Check() {
+			if event.Status == listwatch.StatusFailed && detector.Lists()[event.List] == 0 {
+				log.Fatalf("Error loading sanctions list %s from %s: %s", event.List, event.Path, event.Error)
+			}
+		}
+		go watcher.Run(context.Background(), listReloadInterval)
+	} else {
+		log.Println("No sanctions list files configured; lists only change through the API")
+	}
+
+	// Exposure lists of tens of millions of addresses are served from on-disk indexes
+	if files := os.Getenv("SANCTIONS_INDEX_FILES"); files != "" {
+		if err := attachIndexes(detector, files); err != nil {
+			log.Fatal("Error loading SANCTIONS_INDEX_FILES: ", err)
+		}
+	}
 
-	// Create sanctions detector and PrivacyManager
-	detector := sanctions.NewDetector(initialAddresses)
+	// Temporary entries stop matching when they lapse; the sweeper only reclaims them
+	go detector.RunSweeper(context.Background(), expirySweepInterval)
 	log.Println("Sanctions detector initialized")
 
 	privacyManager := privacy.NewPrivacyManager(detector)
 	log.Println("Privacy manager initialized")
 
+	// Every ephemeral key is recorded so a repeated key is refused before it can link payments
+	if path := os.Getenv("EPHEMERAL_KEY_STORE"); path != "" {
+		keyStore, err := ephemeral.Open(path, ephemeral.Options{})
+		if err != nil {
+			log.Fatal("Error opening ephemeral key store: ", err)
+		}
+		defer keyStore.Close()
+		privacyManager.EphemeralKeys = keyStore
+	} else {
+		log.Println("EPHEMERAL_KEY_STORE not set; ephemeral keys are only checked until restart")
+		privacyManager.EphemeralKeys = ephemeral.NewMemoryStore(ephemeral.Options{})
+	}
+
+	// Viewing key escrow is only enabled when a committee is configured
+	var esc *escrow.Escrow
+	if path := os.Getenv("ESCROW_COMMITTEE_FILE"); path != "" {
+		committee, err := escrow.LoadCommitteeFile(path)
+		if err != nil {
+			log.Fatal("Error loading escrow committee: ", err)
+		}
+		// Records, release requests and the audit log must survive restarts to serve later court orders
+		if storePath := os.Getenv("ESCROW_STORE"); storePath != "" {
+			store, err := escrow.OpenBoltStore(storePath)
+			if err != nil {
+				log.Fatal("Error opening escrow store: ", err)
+			}
+			esc, err = escrow.LoadEscrow(committee, store)
+			if err != nil {
+				log.Fatal("Error loading escrow store: ", err)
+			}
+			defer esc.Close()
+		} else {
+			log.Println("ESCROW_STORE not set; escrowed viewing keys and the audit log are only kept until restart")
+			esc, err = escrow.NewEscrow(committee)
+			if err != nil {
+				log.Fatal("Error initializing escrow: ", err)
+			}
+		}
+		log.Println("Viewing key escrow initialized")
+	}
+
+	// The relayers are only enabled when an RPC endpoint and relayer key are configured.
+	// Both send from the same key, so they share one sender to keep nonces in order.
+	var queue *announcer.Queue
+	var gasRelayer *gasrelay.Relayer
+	var smartAccounts *smartaccount.Accounts
+	var backend chain.Backend
+	if rpcURL := os.Getenv("ETH_RPC_URL"); rpcURL != "" {
+		client, err := ethclient.Dial(rpcURL)
+		if err != nil {
+			log.Fatal("Error connecting to ETH_RPC_URL: ", err)
+		}
+		backend = client
+		sender, err := newRelayerSender(client)
+		if err != nil {
+			log.Fatal("Error initializing relayer: ", err)
+		}
+
+		queue, err = newAnnouncementQueue(sender)
+		if err != nil {
+			log.Fatal("Error initializing announcement relayer: ", err)
+		}
+		defer queue.Close()
+		go queue.Run(context.Background(), announcementFlushInterval)
+		log.Println("Announcement relayer initialized")
+
+		if fees := os.Getenv("GAS_RELAYER_FEES"); fees != "" {
+			cfg, err := parseRelayerFees(fees)
+			if err != nil {
+				log.Fatal("Error parsing GAS_RELAYER_FEES: ", err)
+			}
+			gasRelayer = gasrelay.NewRelayer(sender, detector, cfg)
+			log.Println("Gas relayer initialized")
+		}
+
+		// Smart account payments need a factory whose account address follows from the owner
+		if factory := os.Getenv("SMART_ACCOUNT_FACTORY"); factory != "" {
+			smartAccounts, err = newSmartAccounts(client, factory, sender.ChainID())
+			if err != nil {
+				log.Fatal("Error initializing smart accounts: ", err)
+			}
+			log.Println("Smart accounts initialized")
+		}
+	}
+
 	// Initialize and start the server
-	s := server.NewServer(privacyManager)
+	s := server.NewServer(privacyManager, esc, queue, gasRelayer, smartAccounts, backend, watcher)
 	log.Println("Server instance created")
 
 	log.Println("Server starting on port 8080")
@@ -31,3 +174,124 @@ func main() {
 		log.Println("Server started successfully")
 	}
 }
+
+// announcementFlushInterval is how often the relayer publishes due announcements.
+const announcementFlushInterval = 30 * time.Second
+
+// expirySweepInterval is how often expired sanctions entries are purged.
+const expirySweepInterval = time.Hour
+
+// listReloadInterval is how often watched sanctions list files are checked for a new version.
+const listReloadInterval = time.Minute
+
+// listSources returns the OFAC_SDN_FILE and the comma-separated list:path pairs of SANCTIONS_LIST_FILES,
+// with the SANCTIONS_LIST_MAX_SHRINK fraction a new version may drop.
+func listSources() ([]listwatch.Source, error) {
+	var sources []listwatch.Source
+	if path := os.Getenv("OFAC_SDN_FILE"); path != "" {
+		sources = append(sources, listwatch.OFACFile(path))
+	}
+	if files := os.Getenv("SANCTIONS_LIST_FILES"); files != "" {
+		for _, entry := range strings.Split(files, ",") {
+			list, path, ok := strings.Cut(strings.TrimSpace(entry), ":")
+			if !ok || path == "" {
+				return nil, fmt.Errorf("invalid entry %q", entry)
+			}
+			sources = append(sources, listwatch.PlainFile(list, path))
+		}
+	}
+	if value := os.Getenv("SANCTIONS_LIST_MAX_SHRINK"); value != "" {
+		maxShrink, err := strconv.ParseFloat(value, 64)
+		if err != nil || maxShrink <= 0 || maxShrink > 1 {
+			return nil, fmt.Errorf("invalid SANCTIONS_LIST_MAX_SHRINK %q", value)
+		}
+		for i := range sources {
+			sources[i].MaxShrink = maxShrink
+		}
+	}
+	return sources, nil
+}
+
+// attachIndexes attaches the comma-separated list:path pairs of plain list files as index lists.
+func attachIndexes(detector *sanctions.Detector, files string) error {
+	opts := sanctions.IndexOptions{}
+	if rate := os.Getenv("SANCTIONS_INDEX_FALSE_POSITIVE_RATE"); rate != "" {
+		var err error
+		if opts.FalsePositiveRate, err = strconv.ParseFloat(rate, 64); err != nil {
+			return fmt.Errorf("invalid SANCTIONS_INDEX_FALSE_POSITIVE_RATE: %w", err)
+		}
+	}
+	for _, entry := range strings.Split(files, ",") {
+		list, path, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || path == "" {
+			return fmt.Errorf("invalid entry %q", entry)
+		}
+		idx, err := listwatch.OpenIndexFile(path, opts)
+		if err != nil {
+			return err
+		}
+		if err := detector.AttachIndex(list, idx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newRelayerSender(client *ethclient.Client) (*chain.Sender, error) {
+	relayerKey, err := helpers.ParseECDSAPrivKey(os.Getenv("RELAYER_PRIVATE_KEY"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RELAYER_PRIVATE_KEY: %w", err)
+	}
+	return chain.NewSender(context.Background(), client, relayerKey)
+}
+
+func newSmartAccounts(client *ethclient.Client, factory string, chainID *big.Int) (*smartaccount.Accounts, error) {
+	if !common.IsHexAddress(factory) {
+		return nil, fmt.Errorf("invalid SMART_ACCOUNT_FACTORY %q", factory)
+	}
+	creationCode, err := hexutil.Decode(os.Getenv("SMART_ACCOUNT_CREATION_CODE"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMART_ACCOUNT_CREATION_CODE: %w", err)
+	}
+	cfg := smartaccount.Config{
+		Factory:             common.HexToAddress(factory),
+		AccountCreationCode: creationCode,
+		ChainID:             chainID,
+	}
+	if addr := os.Getenv("ENTRYPOINT_ADDRESS"); common.IsHexAddress(addr) {
+		cfg.EntryPoint = common.HexToAddress(addr)
+	}
+	return smartaccount.NewAccounts(cfg, client)
+}
+
+func newAnnouncementQueue(sender *chain.Sender) (*announcer.Queue, error) {
+	// Announcements are held for up to hours, so they are journalled to survive a restart
+	cfg := announcer.Config{Journal: os.Getenv("ANNOUNCEMENT_QUEUE_FILE")}
+	if cfg.Journal == "" {
+		log.Println("ANNOUNCEMENT_QUEUE_FILE not set; queued announcements are lost on restart")
+	}
+	if addr := os.Getenv("ANNOUNCER_ADDRESS"); common.IsHexAddress(addr) {
+		cfg.Announcer = common.HexToAddress(addr)
+	}
+	if addr := os.Getenv("MULTICALL_ADDRESS"); common.IsHexAddress(addr) {
+		cfg.Multicall = common.HexToAddress(addr)
+	}
+	return announcer.NewQueue(cfg, sender)
+}
+
+// parseRelayerFees parses a comma-separated list of token:fee pairs, with fees in token units.
+func parseRelayerFees(list string) (gasrelay.Config, error) {
+	cfg := gasrelay.Config{Fees: make(map[common.Address]*big.Int)}
+	for _, entry := range strings.Split(list, ",") {
+		token, fee, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || !common.IsHexAddress(token) {
+			return cfg, fmt.Errorf("invalid entry %q", entry)
+		}
+		amount, ok := new(big.Int).SetString(fee, 10)
+		if !ok || amount.Sign() < 0 {
+			return cfg, fmt.Errorf("invalid fee in %q", entry)
+		}
+		cfg.Fees[common.HexToAddress(token)] = amount
+	}
+	return cfg, nil
+}
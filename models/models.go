@@ -1,9 +1,25 @@
 package models
 
-import "github.com/prikshit/blockchain-privacy-module/internal/privacy"
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/prikshit/blockchain-privacy-module/internal/analyzer"
+	"github.com/prikshit/blockchain-privacy-module/internal/announcer"
+	"github.com/prikshit/blockchain-privacy-module/internal/chain"
+	"github.com/prikshit/blockchain-privacy-module/internal/escrow"
+	"github.com/prikshit/blockchain-privacy-module/internal/gasrelay"
+	"github.com/prikshit/blockchain-privacy-module/internal/listwatch"
+	"github.com/prikshit/blockchain-privacy-module/internal/privacy"
+	"github.com/prikshit/blockchain-privacy-module/internal/smartaccount"
+)
 
 type Server struct {
 	PrivacyManager *privacy.PrivacyManager
+	Escrow         *escrow.Escrow         // nil when no escrow committee is configured
+	Announcements  *announcer.Queue       // nil when no announcement relayer is configured
+	GasRelayer     *gasrelay.Relayer      // nil when no gas relayer is configured
+	SmartAccounts  *smartaccount.Accounts // nil when no account factory is configured
+	Chain          chain.Backend          // nil when no RPC endpoint is configured
+	ListWatcher    *listwatch.Watcher     // nil when no list files are watched
 }
 
 type GenerateStealthAccountRequest struct {
@@ -19,3 +35,257 @@ type RecoverPrivKeyRequest struct {
 	RecipientPrivKey string `json:"recipient_privkey"`
 	EphemeralPubKey  string `json:"ephemeral_pubkey"`
 }
+
+type AnnouncementPayload struct {
+	StealthAddress  string `json:"stealth_address" binding:"required"`
+	EphemeralPubKey string `json:"ephemeral_pub_key" binding:"required"`
+	Metadata        string `json:"metadata"`
+}
+
+type MetaAddressRequest struct {
+	MetaAddress string `json:"meta_address" binding:"required"`
+}
+
+type ScanHybridRequest struct {
+	ViewingPrivKey string                `json:"viewing_privkey" binding:"required"`
+	KEMSeed        string                `json:"kem_seed" binding:"required"`
+	SpendingPubKey string                `json:"spending_pubkey" binding:"required"`
+	Announcements  []AnnouncementPayload `json:"announcements"`
+}
+
+type RecoverHybridPrivKeyRequest struct {
+	SpendingPrivKey string              `json:"spending_privkey" binding:"required"`
+	ViewingPrivKey  string              `json:"viewing_privkey" binding:"required"`
+	KEMSeed         string              `json:"kem_seed" binding:"required"`
+	Announcement    AnnouncementPayload `json:"announcement"`
+}
+
+type DeriveAccountsRequest struct {
+	Mnemonic   string `json:"mnemonic" binding:"required"`
+	Passphrase string `json:"passphrase"`
+	StartIndex uint32 `json:"start_index"`
+	Count      int    `json:"count"`
+}
+
+type DeriveStealthKeysRequest struct {
+	Address          string `json:"address" binding:"required"`
+	Signature        string `json:"signature" binding:"required"`
+	ConfirmSignature string `json:"confirm_signature" binding:"required"`
+}
+
+type LabelledMetaAddressRequest struct {
+	SpendingPubKey string `json:"spending_pubkey" binding:"required"`
+	ViewingPrivKey string `json:"viewing_privkey" binding:"required"`
+	Label          string `json:"label" binding:"required"`
+}
+
+type ScanStealthRequest struct {
+	ViewingPrivKey string                `json:"viewing_privkey" binding:"required"`
+	SpendingPubKey string                `json:"spending_pubkey" binding:"required"`
+	Labels         []string              `json:"labels"`
+	Announcements  []AnnouncementPayload `json:"announcements"`
+}
+
+type RecoverStealthPrivKeyRequest struct {
+	SpendingPrivKey string              `json:"spending_privkey" binding:"required"`
+	ViewingPrivKey  string              `json:"viewing_privkey" binding:"required"`
+	Label           string              `json:"label"`
+	Announcement    AnnouncementPayload `json:"announcement"`
+}
+
+type KeySharePayload struct {
+	Index     int    `json:"index" binding:"required"`
+	Threshold int    `json:"threshold" binding:"required"`
+	Value     string `json:"value" binding:"required"`
+	PublicKey string `json:"public_key" binding:"required"`
+}
+
+type PartialECDHPayload struct {
+	Index int    `json:"index" binding:"required"`
+	Point string `json:"point" binding:"required"`
+}
+
+type SplitKeyRequest struct {
+	PrivKey   string `json:"privkey" binding:"required"`
+	Threshold int    `json:"threshold" binding:"required"`
+	Parties   int    `json:"parties" binding:"required"`
+}
+
+type PartialECDHRequest struct {
+	Share           KeySharePayload `json:"share"`
+	EphemeralPubKey string          `json:"ephemeral_pubkey" binding:"required"`
+}
+
+type StealthKeyShareRequest struct {
+	Share    KeySharePayload      `json:"share"`
+	Partials []PartialECDHPayload `json:"partials"`
+}
+
+type RegisterEscrowRequest struct {
+	MetaAddress    string `json:"meta_address" binding:"required"`
+	ViewingPrivKey string `json:"viewing_privkey" binding:"required"`
+	Actor          string `json:"actor" binding:"required"`
+}
+
+type ReleaseRequest struct {
+	EscrowID    string `json:"escrow_id" binding:"required"`
+	CourtOrder  string `json:"court_order" binding:"required"`
+	RequestedBy string `json:"requested_by" binding:"required"`
+}
+
+type ApproveReleaseRequest struct {
+	MemberID string `json:"member_id" binding:"required"`
+	Share    string `json:"share" binding:"required"`
+}
+
+type ExecuteReleaseRequest struct {
+	Actor         string                `json:"actor" binding:"required"`
+	Labels        []string              `json:"labels"`
+	Announcements []AnnouncementPayload `json:"announcements"`
+}
+
+type BackupSplitRequest struct {
+	Type      string `json:"type" binding:"required,oneof=private_key mnemonic"`
+	Secret    string `json:"secret" binding:"required"`
+	Threshold int    `json:"threshold" binding:"required"`
+	Shares    int    `json:"shares" binding:"required"`
+}
+
+type BackupRestoreRequest struct {
+	Shares []string `json:"shares" binding:"required"`
+}
+
+type SplitPaymentRequest struct {
+	MetaAddress string `json:"meta_address" binding:"required"`
+	Token       string `json:"token"`
+	Amount      string `json:"amount" binding:"required"`
+	MinOutputs  int    `json:"min_outputs"`
+	MaxOutputs  int    `json:"max_outputs"`
+}
+
+type StealthBalancePayload struct {
+	Address string `json:"address" binding:"required"`
+	Token   string `json:"token"`
+	Amount  string `json:"amount" binding:"required"`
+}
+
+type ConsolidationPlanRequest struct {
+	Balances        []StealthBalancePayload `json:"balances" binding:"required"`
+	Destinations    []string                `json:"destinations" binding:"required"`
+	MainAddresses   []string                `json:"main_addresses"`
+	MaxPerGroup     int                     `json:"max_per_group"`
+	MinDelaySeconds int64                   `json:"min_delay_seconds"`
+	MaxDelaySeconds int64                   `json:"max_delay_seconds"`
+	StartBlock      uint64                  `json:"start_block"`
+}
+
+type SweepPayload struct {
+	From   string `json:"from" binding:"required"`
+	To     string `json:"to" binding:"required"`
+	Token  string `json:"token"`
+	Amount string `json:"amount"`
+	Block  uint64 `json:"block"`
+}
+
+type EvaluateSweepsRequest struct {
+	Sweeps        []SweepPayload `json:"sweeps" binding:"required"`
+	MainAddresses []string       `json:"main_addresses"`
+}
+
+type QueueAnnouncementsRequest struct {
+	Announcements []AnnouncementPayload `json:"announcements" binding:"required"`
+}
+
+type GasRelayQuoteRequest struct {
+	Token string `json:"token" binding:"required"`
+	Owner string `json:"owner" binding:"required"`
+}
+
+type SignSweepRequest struct {
+	StealthPrivKey  string `json:"stealth_priv_key" binding:"required"`
+	Token           string `json:"token" binding:"required"`
+	Destination     string `json:"destination" binding:"required"`
+	Amount          string `json:"amount" binding:"required"`
+	DeadlineSeconds int64  `json:"deadline_seconds"`
+}
+
+type SweepRequestPayload struct {
+	Token       string `json:"token" binding:"required"`
+	Owner       string `json:"owner" binding:"required"`
+	Destination string `json:"destination" binding:"required"`
+	Amount      string `json:"amount" binding:"required"`
+	Fee         string `json:"fee" binding:"required"`
+	Nonce       string `json:"nonce" binding:"required"`
+	Deadline    string `json:"deadline" binding:"required"`
+	Permit      string `json:"permit" binding:"required"`
+	Signature   string `json:"signature" binding:"required"`
+}
+
+type EphemeralKeyAuditRequest struct {
+	Announcements []AnnouncementPayload `json:"announcements" binding:"required"`
+}
+
+type LeakReportRequest struct {
+	Payments      []analyzer.Payment          `json:"payments" binding:"required"`
+	Transactions  []analyzer.Transaction      `json:"transactions"`
+	MainAddresses map[string][]common.Address `json:"main_addresses"`
+	SweepWindow   uint64                      `json:"sweep_window"`
+	GasTopUpMax   string                      `json:"gas_top_up_max"`
+}
+
+type SmartAccountSweepRequest struct {
+	StealthPrivKey       string            `json:"stealth_priv_key" binding:"required"`
+	Token                string            `json:"token"` // ETH when empty
+	Destination          string            `json:"destination" binding:"required"`
+	Amount               string            `json:"amount"` // Whole balance when empty
+	MaxFeePerGas         string            `json:"max_fee_per_gas"`
+	MaxPriorityFeePerGas string            `json:"max_priority_fee_per_gas"`
+	Paymaster            *PaymasterPayload `json:"paymaster"`
+}
+
+type PaymasterPayload struct {
+	Address              string `json:"address" binding:"required"`
+	VerificationGasLimit string `json:"verification_gas_limit"`
+	PostOpGasLimit       string `json:"post_op_gas_limit"`
+	Data                 string `json:"data"`
+}
+
+// UserOperationPayload is an ERC-4337 v0.7 user operation in the bundler RPC format.
+type UserOperationPayload struct {
+	Sender                        string `json:"sender"`
+	Nonce                         string `json:"nonce"`
+	Factory                       string `json:"factory,omitempty"`
+	FactoryData                   string `json:"factoryData,omitempty"`
+	CallData                      string `json:"callData"`
+	CallGasLimit                  string `json:"callGasLimit"`
+	VerificationGasLimit          string `json:"verificationGasLimit"`
+	PreVerificationGas            string `json:"preVerificationGas"`
+	MaxFeePerGas                  string `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas          string `json:"maxPriorityFeePerGas"`
+	Paymaster                     string `json:"paymaster,omitempty"`
+	PaymasterVerificationGasLimit string `json:"paymasterVerificationGasLimit,omitempty"`
+	PaymasterPostOpGasLimit       string `json:"paymasterPostOpGasLimit,omitempty"`
+	PaymasterData                 string `json:"paymasterData,omitempty"`
+	Signature                     string `json:"signature"`
+}
+
+type AirgapEnvelopeRequest struct {
+	Announcement AnnouncementPayload `json:"announcement" binding:"required"`
+	Label        string              `json:"label"`
+	To           string              `json:"to" binding:"required"`
+	Value        string              `json:"value" binding:"required"`
+	Data         string              `json:"data"`
+	ChunkSize    int                 `json:"chunk_size"`
+}
+
+type AirgapSignRequest struct {
+	Chunks          []string `json:"chunks" binding:"required"`
+	SpendingPrivKey string   `json:"spending_privkey" binding:"required"`
+	ViewingPrivKey  string   `json:"viewing_privkey" binding:"required"`
+	KEMSeed         string   `json:"kem_seed"` // Only for hybrid payments
+	ChunkSize       int      `json:"chunk_size"`
+}
+
+type AirgapBroadcastRequest struct {
+	Chunks []string `json:"chunks" binding:"required"`
+}
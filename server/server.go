@@ -6,13 +6,19 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/prikshit/blockchain-privacy-module/controller"
+	"github.com/prikshit/blockchain-privacy-module/internal/announcer"
+	"github.com/prikshit/blockchain-privacy-module/internal/chain"
+	"github.com/prikshit/blockchain-privacy-module/internal/escrow"
+	"github.com/prikshit/blockchain-privacy-module/internal/gasrelay"
+	"github.com/prikshit/blockchain-privacy-module/internal/listwatch"
 	"github.com/prikshit/blockchain-privacy-module/internal/privacy"
+	"github.com/prikshit/blockchain-privacy-module/internal/smartaccount"
 	"github.com/prikshit/blockchain-privacy-module/models"
 )
 
-func NewServer(pm *privacy.PrivacyManager) *models.Server {
+func NewServer(pm *privacy.PrivacyManager, esc *escrow.Escrow, queue *announcer.Queue, gasRelayer *gasrelay.Relayer, smartAccounts *smartaccount.Accounts, backend chain.Backend, watcher *listwatch.Watcher) *models.Server {
 	log.Println("Initializing server with PrivacyManager")
-	return &models.Server{PrivacyManager: pm}
+	return &models.Server{PrivacyManager: pm, Escrow: esc, Announcements: queue, GasRelayer: gasRelayer, SmartAccounts: smartAccounts, Chain: backend, ListWatcher: watcher}
 }
 
 func Start(s *models.Server) error {
@@ -28,6 +34,12 @@ func Start(s *models.Server) error {
 		log.Println("Handling generate account request")
 		controller.GenerateAccount(c)
 	})
+
+	r.POST("/hd/derive-accounts", func(c *gin.Context) {
+		log.Println("Handling derive HD accounts request")
+		controller.DeriveHDAccounts(c)
+	})
+
 	r.POST("/recover-stealth-priv-key", func(c *gin.Context) {
 		log.Println("Handling recover stealth private key request")
 		controller.RecoverStealthPrivKey(c, s)
@@ -38,6 +50,196 @@ func Start(s *models.Server) error {
 		controller.VerifyStealthKeys(c, s)
 	})
 
+	r.GET("/stealth-keys/message", func(c *gin.Context) {
+		log.Println("Handling stealth keys message request")
+		controller.GetStealthKeysMessage(c)
+	})
+
+	r.POST("/stealth-keys/from-signature", func(c *gin.Context) {
+		log.Println("Handling derive stealth keys from signature request")
+		controller.DeriveStealthKeysFromSignature(c)
+	})
+
+	r.POST("/stealth/labelled-meta-address", func(c *gin.Context) {
+		log.Println("Handling generate labelled meta-address request")
+		controller.GenerateLabelledMetaAddress(c)
+	})
+
+	r.POST("/stealth/generate", func(c *gin.Context) {
+		log.Println("Handling generate stealth payment request")
+		controller.GenerateStealthPayment(c, s)
+	})
+
+	r.POST("/stealth/split-payment", func(c *gin.Context) {
+		log.Println("Handling plan split payment request")
+		controller.PlanSplitPayment(c, s)
+	})
+
+	r.POST("/stealth/scan", func(c *gin.Context) {
+		log.Println("Handling scan stealth announcements request")
+		controller.ScanStealthAnnouncements(c, s)
+	})
+
+	r.POST("/stealth/recover", func(c *gin.Context) {
+		log.Println("Handling recover labelled stealth private key request")
+		controller.RecoverLabelledStealthPrivKey(c, s)
+	})
+
+	r.POST("/consolidation/plan", func(c *gin.Context) {
+		log.Println("Handling plan consolidation request")
+		controller.PlanConsolidation(c)
+	})
+
+	r.POST("/consolidation/evaluate", func(c *gin.Context) {
+		log.Println("Handling evaluate consolidation request")
+		controller.EvaluateConsolidation(c)
+	})
+
+	r.POST("/announcements/queue", func(c *gin.Context) {
+		log.Println("Handling queue announcements request")
+		controller.QueueAnnouncements(c, s)
+	})
+
+	r.GET("/announcements/queue", func(c *gin.Context) {
+		log.Println("Handling announcement queue status request")
+		controller.GetAnnouncementQueueStatus(c, s)
+	})
+
+	r.GET("/announcements/queue/:id", func(c *gin.Context) {
+		log.Println("Handling get queued announcement request")
+		controller.GetQueuedAnnouncement(c, s)
+	})
+
+	r.POST("/gas-relay/quote", func(c *gin.Context) {
+		log.Println("Handling gas relay quote request")
+		controller.QuoteGasRelay(c, s)
+	})
+
+	r.POST("/gas-relay/sign", func(c *gin.Context) {
+		log.Println("Handling sign sweep request")
+		controller.SignRelayedSweep(c, s)
+	})
+
+	r.POST("/gas-relay/sweep", func(c *gin.Context) {
+		log.Println("Handling relayed sweep request")
+		controller.RelaySweep(c, s)
+	})
+
+	r.POST("/smart-account/payment", func(c *gin.Context) {
+		log.Println("Handling smart account payment request")
+		controller.GenerateSmartAccountPayment(c, s)
+	})
+
+	r.POST("/smart-account/sweep", func(c *gin.Context) {
+		log.Println("Handling smart account sweep request")
+		controller.SignSmartAccountSweep(c, s)
+	})
+
+	r.POST("/airgap/envelope", func(c *gin.Context) {
+		log.Println("Handling air-gapped envelope request")
+		controller.CreateAirgapEnvelope(c, s)
+	})
+
+	r.POST("/airgap/sign", func(c *gin.Context) {
+		log.Println("Handling air-gapped signing request")
+		controller.SignAirgapEnvelope(c, s)
+	})
+
+	r.POST("/airgap/broadcast", func(c *gin.Context) {
+		log.Println("Handling air-gapped broadcast request")
+		controller.BroadcastAirgapTransaction(c, s)
+	})
+
+	r.GET("/ephemeral-keys/status", func(c *gin.Context) {
+		log.Println("Handling ephemeral key store status request")
+		controller.GetEphemeralKeyStatus(c, s)
+	})
+
+	r.POST("/ephemeral-keys/audit", func(c *gin.Context) {
+		log.Println("Handling ephemeral key audit request")
+		controller.AuditEphemeralKeys(c)
+	})
+
+	r.POST("/analyzer/report", func(c *gin.Context) {
+		log.Println("Handling privacy leak report request")
+		controller.ReportPrivacyLeaks(c)
+	})
+
+	r.POST("/threshold/split-key", func(c *gin.Context) {
+		log.Println("Handling split spending key request")
+		controller.SplitSpendingKey(c)
+	})
+
+	r.POST("/threshold/partial-ecdh", func(c *gin.Context) {
+		log.Println("Handling partial ECDH request")
+		controller.ComputePartialECDH(c)
+	})
+
+	r.POST("/threshold/stealth-key-share", func(c *gin.Context) {
+		log.Println("Handling recover stealth key share request")
+		controller.RecoverStealthKeyShare(c, s)
+	})
+
+	r.GET("/hybrid/generate-keys", func(c *gin.Context) {
+		log.Println("Handling generate hybrid keys request")
+		controller.GenerateHybridKeys(c)
+	})
+
+	r.POST("/hybrid/generate-stealth", func(c *gin.Context) {
+		log.Println("Handling generate hybrid stealth account request")
+		controller.GenerateHybridStealthAccount(c, s)
+	})
+
+	r.POST("/hybrid/scan", func(c *gin.Context) {
+		log.Println("Handling scan hybrid announcements request")
+		controller.ScanHybridAnnouncements(c, s)
+	})
+
+	r.POST("/hybrid/recover-stealth-priv-key", func(c *gin.Context) {
+		log.Println("Handling recover hybrid stealth private key request")
+		controller.RecoverHybridStealthPrivKey(c, s)
+	})
+
+	r.POST("/backup/split", func(c *gin.Context) {
+		log.Println("Handling split backup request")
+		controller.SplitBackup(c)
+	})
+
+	r.POST("/backup/restore", func(c *gin.Context) {
+		log.Println("Handling restore backup request")
+		controller.RestoreBackup(c)
+	})
+
+	r.POST("/escrow/register", func(c *gin.Context) {
+		log.Println("Handling register escrowed viewing key request")
+		controller.RegisterEscrow(c, s)
+	})
+
+	r.GET("/escrow/records/:id", func(c *gin.Context) {
+		log.Println("Handling get escrow record request")
+		controller.GetEscrowRecord(c, s)
+	})
+
+	r.POST("/escrow/release", func(c *gin.Context) {
+		log.Println("Handling escrow release request")
+		controller.RequestEscrowRelease(c, s)
+	})
+
+	r.POST("/escrow/release/:id/approve", func(c *gin.Context) {
+		log.Println("Handling approve escrow release request")
+		controller.ApproveEscrowRelease(c, s)
+	})
+
+	r.POST("/escrow/release/:id/execute", func(c *gin.Context) {
+		log.Println("Handling execute escrow release request")
+		controller.ExecuteEscrowRelease(c, s)
+	})
+
+	r.GET("/escrow/audit", func(c *gin.Context) {
+		log.Println("Handling escrow audit log request")
+		controller.GetEscrowAudit(c, s)
+	})
+
 	r.POST("/sanctions/add", func(c *gin.Context) {
 		log.Println("Handling add sanction request")
 		controller.HandleAddSanctionedAddress(c, s)
@@ -53,6 +255,56 @@ func Start(s *models.Server) error {
 		controller.HandleCheckSanction(c, s)
 	})
 
+	r.GET("/sanctions/lists", func(c *gin.Context) {
+		log.Println("Handling sanctions lists request")
+		controller.HandleGetSanctionsLists(c, s)
+	})
+
+	r.GET("/sanctions/lists/:list", func(c *gin.Context) {
+		log.Println("Handling sanctions list entries request")
+		controller.HandleGetSanctionsList(c, s)
+	})
+
+	r.POST("/sanctions/lists/:list/add", func(c *gin.Context) {
+		log.Println("Handling add to sanctions list request")
+		controller.HandleAddToSanctionsList(c, s)
+	})
+
+	r.POST("/sanctions/lists/:list/remove", func(c *gin.Context) {
+		log.Println("Handling remove from sanctions list request")
+		controller.HandleRemoveFromSanctionsList(c, s)
+	})
+
+	r.GET("/sanctions/history/:address", func(c *gin.Context) {
+		log.Println("Handling sanctions history request")
+		controller.HandleGetSanctionsHistory(c, s)
+	})
+
+	r.GET("/sanctions/reloads", func(c *gin.Context) {
+		log.Println("Handling sanctions list reloads request")
+		controller.HandleGetSanctionsReloads(c, s)
+	})
+
+	r.POST("/sanctions/reloads/:list/force", func(c *gin.Context) {
+		log.Println("Handling force sanctions list reload request")
+		controller.HandleForceSanctionsReload(c, s)
+	})
+
+	r.GET("/sanctions/exemptions", func(c *gin.Context) {
+		log.Println("Handling sanctions exemptions request")
+		controller.HandleGetSanctionsExemptions(c, s)
+	})
+
+	r.POST("/sanctions/exemptions", func(c *gin.Context) {
+		log.Println("Handling add sanctions exemption request")
+		controller.HandleAddSanctionsExemption(c, s)
+	})
+
+	r.POST("/sanctions/exemptions/remove", func(c *gin.Context) {
+		log.Println("Handling remove sanctions exemption request")
+		controller.HandleRemoveSanctionsExemption(c, s)
+	})
+
 	// Start server
 	port := os.Getenv("PORT")
 	if port == "" {